@@ -5,18 +5,30 @@ import (
 	"fmt"
 	"hot-coffee/help"
 	"hot-coffee/internal/dal"
+	"hot-coffee/internal/gateway"
 	"hot-coffee/internal/handler"
+	"hot-coffee/internal/idgen"
+	"hot-coffee/internal/router"
 	"hot-coffee/internal/service"
 	"log"
 	"net/http"
 	"path/filepath"
-	"strings"
+	"time"
+	_ "time/tzdata"
 )
 
 func main() {
 	helpFlag := flag.Bool("help", false, "Prints help information")
 	port := flag.Int("port", 8080, "Port number for the server")
 	dir := flag.String("dir", "data", "Path to the data directory")
+	gatewayURL := flag.String("gateway-url", "", "Base URL of the card payment gateway (in-process mock if empty)")
+	timeZone := flag.String("timezone", "", "IANA time zone used for report day boundaries (system zone if empty)")
+	taxRate := flag.Float64("tax-rate", 0, "Sales tax rate in percent applied to orders")
+	orderIDs := flag.String("order-ids", idgen.KindSequential, "Order ID format: sequential, ulid or uuidv7")
+	idempotencyTTL := flag.Duration("idempotency-ttl", service.DefaultIdempotencyTTL, "How long responses are replayed for a repeated Idempotency-Key")
+	requireIfMatch := flag.Bool("require-if-match", false, "Reject inventory, menu and order writes without an If-Match header")
+	gatewayTimeout := flag.Duration("gateway-timeout", 3*time.Second, "Timeout for a single payment gateway request")
+	gatewaySecret := flag.String("gateway-secret", "", "Shared secret that signs payment gateway callbacks")
 	flag.Parse()
 
 	if *helpFlag {
@@ -24,108 +36,76 @@ func main() {
 		return
 	}
 
+	location := time.Local
+	if *timeZone != "" {
+		loc, err := time.LoadLocation(*timeZone)
+		if err != nil {
+			log.Fatalf("Invalid time zone %q: %v", *timeZone, err)
+		}
+		location = loc
+	}
+
 	if err := help.CreateDataDirWithFiles(*dir); err != nil {
 		log.Fatalf("Failed to initialize data directory: %v", err)
 	}
 
-	inventoryRepo := dal.NewJSONInventoryManager(filepath.Join(*dir, "inventory.json"))
+	inventoryRepo := dal.NewJSONInventoryManager(filepath.Join(*dir, "inventory.json"), filepath.Join(*dir, "inventory_movements.json"))
 	menuRepo := dal.NewJSONMenuManager(filepath.Join(*dir, "menu_items.json"))
-	orderRepo := dal.NewJSONOrderManager(filepath.Join(*dir, "orders.json"))
-
-	inventoryService := service.NewInventoryService(inventoryRepo, menuRepo)
-	menuService := service.NewMenuService(menuRepo, orderRepo)
-	orderService := service.NewOrderService(orderRepo, menuRepo, inventoryRepo)
-	reportService := service.NewReportService(orderRepo, menuRepo)
-
-	inventoryHandler := handler.NewInventoryHandler(inventoryService)
-	menuHandler := handler.NewMenuHandler(menuService)
-	orderHandler := handler.NewOrderHandler(orderService)
-	reportHandler := handler.NewReportHandler(reportService)
-
-	mux := http.NewServeMux()
+	orderIDGenerator, err := idgen.New(*orderIDs, location)
+	if err != nil {
+		log.Fatalf("Invalid order ID format: %v", err)
+	}
+	orderRepo := dal.NewJSONOrderManager(filepath.Join(*dir, "orders.json"), filepath.Join(*dir, "order_counters.json"), orderIDGenerator, location)
+	paymentRepo := dal.NewJSONPaymentManager(filepath.Join(*dir, "payments.json"))
+	refundRepo := dal.NewJSONRefundManager(filepath.Join(*dir, "refunds.json"))
+	sessionRepo := dal.NewJSONCashSessionManager(filepath.Join(*dir, "cash_sessions.json"))
+	customerRepo := dal.NewJSONCustomerManager(filepath.Join(*dir, "customers.json"))
+	giftCardRepo := dal.NewJSONGiftCardManager(filepath.Join(*dir, "gift_cards.json"), filepath.Join(*dir, "gift_card_transactions.json"))
+	accountingRepo := dal.NewJSONAccountingManager(filepath.Join(*dir, "chart_of_accounts.json"))
+	aggregateRepo := dal.NewJSONAggregateManager(filepath.Join(*dir, "report_aggregates.json"))
+	idempotencyRepo := dal.NewJSONIdempotencyManager(filepath.Join(*dir, "idempotency_keys.json"))
+	loyaltyRepo := dal.NewJSONLoyaltyManager(filepath.Join(*dir, "loyalty_program.json"), filepath.Join(*dir, "loyalty_ledger.json"))
 
-	mux.HandleFunc("/inventory", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodGet:
-			inventoryHandler.GetAllInventoryItems(w, r)
-		case http.MethodPost:
-			inventoryHandler.AddNewInventoryItem(w, r)
-		default:
-			help.WriteError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
-		}
-	})
-	mux.HandleFunc("/inventory/", func(w http.ResponseWriter, r *http.Request) {
-		id := strings.TrimPrefix(r.URL.Path, "/inventory/")
-		switch r.Method {
-		case http.MethodGet:
-			inventoryHandler.GetInventoryItem(w, r, id)
-		case http.MethodPut:
-			inventoryHandler.UpdateInventoryItem(w, r, id)
-		case http.MethodDelete:
-			inventoryHandler.DeleteInventoryItem(w, r, id)
-		default:
-			help.WriteError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
-		}
-	})
+	var paymentGateway gateway.PaymentGateway = gateway.NewMockProcessor()
+	if *gatewayURL != "" {
+		paymentGateway = gateway.NewHTTPGateway(*gatewayURL, *gatewayTimeout, 2)
+	}
 
-	mux.HandleFunc("/menu", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodGet:
-			menuHandler.GetAllMenuItems(w, r)
-		case http.MethodPost:
-			menuHandler.AddNewMenuItem(w, r)
-		default:
-			help.WriteError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
-		}
-	})
-	mux.HandleFunc("/menu/", func(w http.ResponseWriter, r *http.Request) {
-		id := strings.TrimPrefix(r.URL.Path, "/menu/")
-		switch r.Method {
-		case http.MethodGet:
-			menuHandler.GetMenuItem(w, r, id)
-		case http.MethodPut:
-			menuHandler.UpdateMenuItem(w, r, id)
-		case http.MethodDelete:
-			menuHandler.DeleteMenuItem(w, r, id)
-		default:
-			help.WriteError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
-		}
-	})
+	aggregateService := service.NewAggregateService(aggregateRepo, orderRepo, menuRepo, refundRepo, location)
+	if err := aggregateService.EnsureFresh(); err != nil {
+		log.Fatalf("Failed to build report aggregates: %v", err)
+	}
+	loyaltyService := service.NewLoyaltyService(loyaltyRepo, customerRepo)
+	giftCardService := service.NewGiftCardService(giftCardRepo, sessionRepo, paymentGateway)
+	inventoryService := service.NewInventoryService(inventoryRepo, menuRepo)
+	menuService := service.NewMenuService(menuRepo, orderRepo, inventoryRepo)
+	orderService := service.NewOrderService(orderRepo, menuRepo, inventoryRepo, paymentRepo, customerRepo, loyaltyService, aggregateService, *taxRate/100, location)
+	paymentService := service.NewPaymentService(paymentRepo, orderRepo, menuRepo, refundRepo, sessionRepo, loyaltyService, giftCardService, aggregateService, paymentGateway, *gatewaySecret)
+	refundService := service.NewRefundService(refundRepo, orderRepo, menuRepo, inventoryRepo, paymentRepo, sessionRepo, loyaltyService, giftCardService, aggregateService, paymentGateway)
+	cashSessionService := service.NewCashSessionService(sessionRepo, paymentRepo, refundRepo, giftCardRepo)
+	customerService := service.NewCustomerService(customerRepo, orderRepo, menuRepo, refundRepo)
+	accountingService := service.NewAccountingService(accountingRepo)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo, *idempotencyTTL)
+	reportService := service.NewReportService(orderRepo, menuRepo, paymentRepo, refundRepo, sessionRepo, inventoryRepo, aggregateRepo, accountingRepo, giftCardRepo, location)
 
-	mux.HandleFunc("/orders", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodGet:
-			orderHandler.GetAllOrders(w, r)
-		case http.MethodPost:
-			orderHandler.CreateOrder(w, r)
-		default:
-			help.WriteError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
-		}
-	})
-	mux.HandleFunc("/orders/", func(w http.ResponseWriter, r *http.Request) {
-		path := strings.TrimPrefix(r.URL.Path, "/orders/")
-		if strings.HasSuffix(path, "/close") && r.Method == http.MethodPost {
-			id := strings.TrimSuffix(path, "/close")
-			orderHandler.CloseOrder(w, r, id)
-			return
-		}
+	mux := router.New(router.Handlers{
+		Inventory:   handler.NewInventoryHandler(inventoryService),
+		Menu:        handler.NewMenuHandler(menuService),
+		Order:       handler.NewOrderHandler(orderService),
+		Payment:     handler.NewPaymentHandler(paymentService),
+		Refund:      handler.NewRefundHandler(refundService),
+		CashSession: handler.NewCashSessionHandler(cashSessionService),
+		Customer:    handler.NewCustomerHandler(customerService),
+		Loyalty:     handler.NewLoyaltyHandler(loyaltyService),
+		GiftCard:    handler.NewGiftCardHandler(giftCardService),
+		Report:      handler.NewReportHandler(reportService),
+		Aggregate:   handler.NewAggregateHandler(aggregateService),
+		Accounting:  handler.NewAccountingHandler(accountingService),
+		Idempotency: handler.NewIdempotencyHandler(idempotencyService),
 
-		id := strings.TrimSuffix(path, "/")
-		switch r.Method {
-		case http.MethodGet:
-			orderHandler.GetOrderByID(w, r, id)
-		case http.MethodPut:
-			orderHandler.UpdateOrder(w, r, id)
-		case http.MethodDelete:
-			orderHandler.DeleteOrder(w, r, id)
-		default:
-			help.WriteError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
-		}
+		RequireIfMatch: *requireIfMatch,
 	})
 
-	mux.HandleFunc("/reports/total-sales", reportHandler.GetTotalSales)
-	mux.HandleFunc("/reports/popular-items", reportHandler.GetPopularItems)
-
 	if *port < 1 || *port > 65535 {
 		log.Fatalf("Invalid port number: %d. Must be between 1 and 65535.", *port)
 	}
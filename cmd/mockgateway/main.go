@@ -0,0 +1,27 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"hot-coffee/internal/gateway"
+	"log"
+	"net/http"
+	"time"
+)
+
+func main() {
+	port := flag.Int("port", 9090, "Port number for the mock gateway")
+	callbackURL := flag.String("callback-url", "", "URL that receives transaction callbacks")
+	secret := flag.String("secret", "", "Shared secret used to sign callbacks")
+	timeoutDelay := flag.Duration("timeout-delay", 5*time.Second, "Response delay for the tok_timeout card token")
+	flag.Parse()
+
+	server := gateway.NewMockServer(*callbackURL, *secret)
+	server.TimeoutDelay = *timeoutDelay
+
+	address := fmt.Sprintf(":%d", *port)
+	log.Printf("Mock payment gateway started at http://localhost%s\n", address)
+	if err := http.ListenAndServe(address, server); err != nil {
+		log.Fatalf("Server error: %v", err)
+	}
+}
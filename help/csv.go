@@ -0,0 +1,41 @@
+package help
+
+import (
+	"encoding/csv"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// WantsCSV reports whether the client asked for CSV through ?format=csv or an
+// Accept header listing text/csv. An explicit ?format wins over Accept.
+func WantsCSV(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return strings.EqualFold(format, "csv")
+	}
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err == nil && mediaType == "text/csv" {
+			return true
+		}
+	}
+	return false
+}
+
+func SetCSVHeaders(w http.ResponseWriter, filename string) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+}
+
+func WriteCSV(w http.ResponseWriter, filename string, header []string, rows [][]string) error {
+	SetCSVHeaders(w, filename)
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
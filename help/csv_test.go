@@ -0,0 +1,64 @@
+package help_test
+
+import (
+	"hot-coffee/help"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWantsCSV(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		accept string
+		want   bool
+	}{
+		{name: "default", target: "/reports/sales", want: false},
+		{name: "format", target: "/reports/sales?format=CSV", want: true},
+		{name: "accept", target: "/reports/sales", accept: "application/json;q=0.5, text/csv; charset=utf-8", want: true},
+		{name: "format wins over accept", target: "/reports/sales?format=json", accept: "text/csv", want: false},
+		{name: "other types", target: "/reports/sales", accept: "text/plain, application/csv-ish", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			if got := help.WantsCSV(r); got != tt.want {
+				t.Errorf("WantsCSV() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteCSVEscaping(t *testing.T) {
+	w := httptest.NewRecorder()
+	rows := [][]string{
+		{"plain", "1.50"},
+		{"Smith, Jo", "2.00"},
+		{`The "Big" one`, "3.00"},
+		{"two\nlines", "4.00"},
+		{" padded ", ""},
+	}
+	if err := help.WriteCSV(w, "items.csv", []string{"name", "price"}, rows); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+
+	want := "name,price\n" +
+		"plain,1.50\n" +
+		"\"Smith, Jo\",2.00\n" +
+		"\"The \"\"Big\"\" one\",3.00\n" +
+		"\"two\nlines\",4.00\n" +
+		"\" padded \",\n"
+	if got := w.Body.String(); got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want text/csv; charset=utf-8", got)
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="items.csv"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+}
@@ -2,11 +2,128 @@ package help
 
 import (
 	"encoding/json"
+	"errors"
+	"hot-coffee/internal/validation"
+	"hot-coffee/models"
+	"log/slog"
 	"net/http"
 )
 
+// Machine-readable error codes returned alongside the human-readable message.
+const (
+	CodeBadRequest          = "bad_request"
+	CodeInvalidPayload      = "invalid_payload"
+	CodeValidationFailed    = "validation_failed"
+	CodeUnauthorized        = "unauthorized"
+	CodeNotFound            = "not_found"
+	CodeMethodNotAllowed    = "method_not_allowed"
+	CodeUnsupportedMedia    = "unsupported_media_type"
+	CodeConflict            = "conflict"
+	CodePreconditionFailed  = "precondition_failed"
+	CodePreconditionNeeded  = "precondition_required"
+	CodeInvalidTransition   = "invalid_transition"
+	CodeInsufficientStock   = "insufficient_stock"
+	CodeIdempotencyMismatch = "idempotency_key_reused"
+	CodePaymentDeclined     = "payment_declined"
+	CodeUnprocessable       = "unprocessable"
+	CodeBadGateway          = "bad_gateway"
+	CodeGatewayTimeout      = "gateway_timeout"
+	CodeInternal            = "internal_error"
+)
+
+type errorBody struct {
+	Error   string `json:"error"`
+	Code    string `json:"code"`
+	Details any    `json:"errors,omitempty"`
+}
+
 func WriteError(w http.ResponseWriter, status int, message string) {
+	WriteErrorDetails(w, status, statusCode(status), message, nil)
+}
+
+// WriteErrorDetails writes an error body with an explicit code and an optional
+// machine-readable list of details, such as per-field validation failures.
+func WriteErrorDetails(w http.ResponseWriter, status int, code string, message string, details any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
+	json.NewEncoder(w).Encode(errorBody{Error: message, Code: code, Details: details})
+}
+
+// WriteServiceError maps the domain errors a service can return to their HTTP
+// status. Anything unrecognised is logged and answered with 500 and fallback,
+// so internal details never reach the client.
+func WriteServiceError(w http.ResponseWriter, err error, fallback string) {
+	var fieldErrs validation.Errors
+	var stockErr *models.InsufficientStockError
+	switch {
+	case errors.As(err, &fieldErrs):
+		slog.Warn("Validation failed", "error", err)
+		WriteErrorDetails(w, http.StatusUnprocessableEntity, CodeValidationFailed, "Validation failed", fieldErrs)
+	case errors.As(err, &stockErr):
+		slog.Warn(fallback, "error", err)
+		WriteErrorDetails(w, http.StatusUnprocessableEntity, CodeInsufficientStock, err.Error(), stockErr.Shortages)
+	case errors.Is(err, models.ErrNotFound):
+		slog.Warn(fallback, "error", err)
+		WriteErrorDetails(w, http.StatusNotFound, CodeNotFound, err.Error(), nil)
+	case errors.Is(err, models.ErrInvalidTransition):
+		slog.Warn(fallback, "error", err)
+		WriteErrorDetails(w, http.StatusConflict, CodeInvalidTransition, err.Error(), nil)
+	case errors.Is(err, models.ErrConflict):
+		slog.Warn(fallback, "error", err)
+		WriteErrorDetails(w, http.StatusConflict, CodeConflict, err.Error(), nil)
+	case errors.Is(err, models.ErrVersionMismatch):
+		slog.Warn(fallback, "error", err)
+		WriteErrorDetails(w, http.StatusPreconditionFailed, CodePreconditionFailed, err.Error(), nil)
+	case errors.Is(err, models.ErrInvalidInput):
+		slog.Warn(fallback, "error", err)
+		WriteErrorDetails(w, http.StatusBadRequest, CodeBadRequest, err.Error(), nil)
+	case errors.Is(err, models.ErrUnauthorized):
+		slog.Warn(fallback, "error", err)
+		WriteErrorDetails(w, http.StatusUnauthorized, CodeUnauthorized, err.Error(), nil)
+	case errors.Is(err, models.ErrDeclined):
+		slog.Warn(fallback, "error", err)
+		WriteErrorDetails(w, http.StatusPaymentRequired, CodePaymentDeclined, err.Error(), nil)
+	// Upstream errors can carry addresses and transport details, so the client
+	// only gets the fallback message.
+	case errors.Is(err, models.ErrUpstreamTimeout):
+		slog.Error(fallback, "error", err)
+		WriteErrorDetails(w, http.StatusGatewayTimeout, CodeGatewayTimeout, fallback, nil)
+	case errors.Is(err, models.ErrUpstreamUnavailable):
+		slog.Error(fallback, "error", err)
+		WriteErrorDetails(w, http.StatusBadGateway, CodeBadGateway, fallback, nil)
+	default:
+		slog.Error(fallback, "error", err)
+		WriteErrorDetails(w, http.StatusInternalServerError, CodeInternal, fallback, nil)
+	}
+}
+
+func statusCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusPreconditionFailed:
+		return CodePreconditionFailed
+	case http.StatusUnsupportedMediaType:
+		return CodeUnsupportedMedia
+	case http.StatusPreconditionRequired:
+		return CodePreconditionNeeded
+	case http.StatusPaymentRequired:
+		return CodePaymentDeclined
+	case http.StatusUnprocessableEntity:
+		return CodeUnprocessable
+	case http.StatusBadGateway:
+		return CodeBadGateway
+	case http.StatusGatewayTimeout:
+		return CodeGatewayTimeout
+	default:
+		return CodeInternal
+	}
 }
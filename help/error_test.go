@@ -0,0 +1,53 @@
+package help_test
+
+import (
+	"fmt"
+	"hot-coffee/help"
+	"hot-coffee/internal/gateway"
+	"hot-coffee/internal/service"
+	"hot-coffee/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteServiceErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{models.ErrNotFound, http.StatusNotFound},
+		{models.ErrConflict, http.StatusConflict},
+		{models.ErrInvalidTransition, http.StatusConflict},
+		{models.ErrVersionMismatch, http.StatusPreconditionFailed},
+		{service.ErrInvalidCustomer, http.StatusBadRequest},
+		{service.ErrInvalidLoyalty, http.StatusBadRequest},
+		{service.ErrInvalidPayment, http.StatusBadRequest},
+		{service.ErrInvalidCallback, http.StatusBadRequest},
+		{service.ErrInvalidGiftCard, http.StatusBadRequest},
+		{service.ErrInvalidInventory, http.StatusBadRequest},
+		{service.ErrInvalidAccounting, http.StatusBadRequest},
+		{service.ErrInvalidQuery, http.StatusBadRequest},
+		{service.ErrInvalidPatch, http.StatusBadRequest},
+		{service.ErrInvalidIdempotencyKey, http.StatusBadRequest},
+		{service.ErrInvalidRefund, http.StatusConflict},
+		{service.ErrInvalidCashSession, http.StatusConflict},
+		{service.ErrPaymentDeclined, http.StatusPaymentRequired},
+		{gateway.ErrDeclined, http.StatusPaymentRequired},
+		{gateway.ErrInvalidSignature, http.StatusUnauthorized},
+		{gateway.ErrTimeout, http.StatusGatewayTimeout},
+		{gateway.ErrUnavailable, http.StatusBadGateway},
+		{fmt.Errorf("disk full"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			for _, err := range []error{tt.err, fmt.Errorf("%w: with context", tt.err)} {
+				rec := httptest.NewRecorder()
+				help.WriteServiceError(rec, err, "Failed")
+				if rec.Code != tt.want {
+					t.Errorf("WriteServiceError(%q) status = %d, want %d", err, rec.Code, tt.want)
+				}
+			}
+		})
+	}
+}
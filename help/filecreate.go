@@ -11,9 +11,22 @@ func CreateDataDirWithFiles(dir string) error {
 	}
 
 	files := map[string]string{
-		"inventory.json":  "[]",
-		"menu_items.json": "[]",
-		"orders.json":     "[]",
+		"inventory.json":              "[]",
+		"inventory_movements.json":    "[]",
+		"menu_items.json":             "[]",
+		"orders.json":                 "[]",
+		"order_counters.json":         "{}",
+		"payments.json":               "[]",
+		"refunds.json":                "[]",
+		"report_aggregates.json":      "{}",
+		"cash_sessions.json":          "[]",
+		"chart_of_accounts.json":      `{"revenue": {"code": "4000", "name": "Sales revenue"}, "discounts": {"code": "4100", "name": "Sales discounts"}, "refunds": {"code": "4200", "name": "Sales returns"}, "sales_tax": {"code": "2200", "name": "Sales tax payable"}, "tips": {"code": "2100", "name": "Tips payable"}, "receivable": {"code": "1300", "name": "Sales clearing"}, "cost_of_goods": {"code": "5000", "name": "Cost of goods sold"}, "inventory": {"code": "1200", "name": "Inventory"}, "tenders": {"cash": {"code": "1000", "name": "Cash on hand"}, "card": {"code": "1100", "name": "Card clearing"}, "gift_card": {"code": "2300", "name": "Gift card liability"}}}`,
+		"customers.json":              "[]",
+		"gift_cards.json":             "[]",
+		"gift_card_transactions.json": "[]",
+		"idempotency_keys.json":       "[]",
+		"loyalty_ledger.json":         "[]",
+		"loyalty_program.json":        `{"points_per_currency_unit": 1, "points_per_item": 0, "rewards": []}`,
 	}
 
 	for name, content := range files {
@@ -6,11 +6,20 @@ func PrintInfo() {
 	fmt.Println(`Coffee Shop Management System
 
 Usage:
-  hot-coffee [--port <N>] [--dir <S>] 
+  hot-coffee [--port <N>] [--dir <S>] [--timezone <Z>] [--tax-rate <R>] [--order-ids <F>] [--idempotency-ttl <D>] [--require-if-match] [--gateway-url <U>] [--gateway-timeout <D>] [--gateway-secret <K>]
   hot-coffee --help
 
 Options:
-  --help       Show this screen.
-  --port N     Port number.
-  --dir S      Path to the data directory.`)
+  --help               Show this screen.
+  --port N             Port number.
+  --dir S              Path to the data directory.
+  --timezone Z         IANA time zone for report day boundaries (e.g. Asia/Almaty).
+  --tax-rate R         Sales tax rate in percent applied to orders (default 0).
+  --order-ids F        Order ID format: sequential (e.g. 20261018-0042, default), ulid or uuidv7.
+  --idempotency-ttl D  How long a response is replayed for a repeated Idempotency-Key (default 24h).
+  --require-if-match   Reject inventory, menu and order updates and deletes without an If-Match header.
+  --gateway-url U      Base URL of the card payment gateway. Uses an in-process mock if empty.
+  --gateway-timeout D  Timeout for a single gateway request (e.g. 3s).
+  --gateway-secret K   Shared secret that signs gateway callbacks.
+                       Callbacks are rejected with 401 until one is set.`)
 }
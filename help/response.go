@@ -0,0 +1,16 @@
+package help
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// WriteCreated answers 201 with the new resource and a Location header
+// pointing at collection/id.
+func WriteCreated(w http.ResponseWriter, collection string, id string, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", collection+"/"+url.PathEscape(id))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,54 @@
+package dal
+
+import (
+	"encoding/json"
+	"hot-coffee/models"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+type JSONAccountingManager struct {
+	filePath string
+	chart    models.ChartOfAccounts
+	mu       sync.Mutex
+}
+
+func NewJSONAccountingManager(filePath string) *JSONAccountingManager {
+	m := &JSONAccountingManager{filePath: filePath}
+	m.load()
+	return m
+}
+
+func (m *JSONAccountingManager) load() {
+	file, err := os.ReadFile(m.filePath)
+	if err != nil {
+		slog.Error("Failed to read chart of accounts file", "path", m.filePath, "error", err)
+		return
+	}
+
+	if err := json.Unmarshal(file, &m.chart); err != nil {
+		slog.Error("Invalid JSON format in chart of accounts file", "path", m.filePath, "error", err)
+	}
+}
+
+func (m *JSONAccountingManager) save() error {
+	data, err := json.MarshalIndent(m.chart, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.filePath, data, 0o644)
+}
+
+func (m *JSONAccountingManager) GetChart() (models.ChartOfAccounts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.chart, nil
+}
+
+func (m *JSONAccountingManager) UpdateChart(chart models.ChartOfAccounts) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.chart = chart
+	return m.save()
+}
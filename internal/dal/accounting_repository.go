@@ -0,0 +1,12 @@
+package dal
+
+import "hot-coffee/models"
+
+type AccountingManager interface {
+	GetChart() (models.ChartOfAccounts, error)
+	UpdateChart(chart models.ChartOfAccounts) error
+}
+
+func (m *JSONAccountingManager) LoadChart() (models.ChartOfAccounts, error) {
+	return m.GetChart()
+}
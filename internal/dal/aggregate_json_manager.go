@@ -0,0 +1,103 @@
+package dal
+
+import (
+	"encoding/json"
+	"hot-coffee/models"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+type JSONAggregateManager struct {
+	filePath   string
+	aggregates models.ReportAggregates
+	mu         sync.Mutex
+}
+
+func NewJSONAggregateManager(filePath string) *JSONAggregateManager {
+	m := &JSONAggregateManager{filePath: filePath}
+	m.load()
+	return m
+}
+
+func (m *JSONAggregateManager) load() {
+	file, err := os.ReadFile(m.filePath)
+	if err != nil {
+		slog.Error("Failed to read report aggregates file", "path", m.filePath, "error", err)
+		return
+	}
+
+	if err := json.Unmarshal(file, &m.aggregates); err != nil {
+		slog.Error("Invalid JSON format in report aggregates file", "path", m.filePath, "error", err)
+	}
+}
+
+func (m *JSONAggregateManager) save() error {
+	data, err := json.MarshalIndent(m.aggregates, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.filePath, data, 0o644)
+}
+
+// GetAggregates returns a deep copy so callers can read it while updates
+// continue.
+func (m *JSONAggregateManager) GetAggregates() (models.ReportAggregates, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	agg := m.aggregates
+	agg.Days = make(map[string]*models.DailyAggregate, len(m.aggregates.Days))
+	for date, day := range m.aggregates.Days {
+		d := *day
+		d.Items = make(map[string]*models.ItemAggregate, len(day.Items))
+		for id, item := range day.Items {
+			i := *item
+			d.Items[id] = &i
+		}
+		agg.Days[date] = &d
+	}
+	agg.Orders = make(map[string]string, len(m.aggregates.Orders))
+	for id, date := range m.aggregates.Orders {
+		agg.Orders[id] = date
+	}
+	agg.Refunds = make(map[string]string, len(m.aggregates.Refunds))
+	for id, date := range m.aggregates.Refunds {
+		agg.Refunds[id] = date
+	}
+	return agg, nil
+}
+
+// LoadAggregates lets the manager serve as the report service's read-only
+// AggregateRepository.
+func (m *JSONAggregateManager) LoadAggregates() (models.ReportAggregates, error) {
+	return m.GetAggregates()
+}
+
+// UpdateAggregates runs update on the stored aggregates under the manager's
+// lock and persists the result, so concurrent order closes and refunds cannot
+// overwrite each other.
+func (m *JSONAggregateManager) UpdateAggregates(update func(agg *models.ReportAggregates) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.aggregates.Days == nil {
+		m.aggregates.Days = make(map[string]*models.DailyAggregate)
+	}
+	if m.aggregates.Orders == nil {
+		m.aggregates.Orders = make(map[string]string)
+	}
+	if m.aggregates.Refunds == nil {
+		m.aggregates.Refunds = make(map[string]string)
+	}
+	if err := update(&m.aggregates); err != nil {
+		m.load()
+		return err
+	}
+	return m.save()
+}
+
+func (m *JSONAggregateManager) ReplaceAggregates(agg models.ReportAggregates) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.aggregates = agg
+	return m.save()
+}
@@ -0,0 +1,9 @@
+package dal
+
+import "hot-coffee/models"
+
+type AggregateManager interface {
+	GetAggregates() (models.ReportAggregates, error)
+	UpdateAggregates(update func(agg *models.ReportAggregates) error) error
+	ReplaceAggregates(agg models.ReportAggregates) error
+}
@@ -0,0 +1,94 @@
+package dal
+
+import (
+	"encoding/json"
+	"fmt"
+	"hot-coffee/models"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+type JSONCashSessionManager struct {
+	filePath string
+	sessions []models.CashSession
+	mu       sync.Mutex
+}
+
+func NewJSONCashSessionManager(filePath string) *JSONCashSessionManager {
+	m := &JSONCashSessionManager{filePath: filePath}
+	m.load()
+	return m
+}
+
+func (m *JSONCashSessionManager) load() {
+	file, err := os.ReadFile(m.filePath)
+	if err != nil {
+		slog.Error("Failed to read cash sessions file", "path", m.filePath, "error", err)
+		return
+	}
+
+	if err := json.Unmarshal(file, &m.sessions); err != nil {
+		slog.Error("Invalid JSON format in cash sessions file", "path", m.filePath, "error", err)
+	}
+}
+
+func (m *JSONCashSessionManager) save() error {
+	data, err := json.MarshalIndent(m.sessions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.filePath, data, 0o644)
+}
+
+func (m *JSONCashSessionManager) AddSession(session models.CashSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.sessions {
+		if s.ID == session.ID {
+			return fmt.Errorf("%w: cash session ID '%s' already exists", models.ErrConflict, session.ID)
+		}
+	}
+	m.sessions = append(m.sessions, session)
+	return m.save()
+}
+
+func (m *JSONCashSessionManager) GetAllSessions() ([]models.CashSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sessions, nil
+}
+
+func (m *JSONCashSessionManager) GetSession(id string) (models.CashSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.sessions {
+		if s.ID == id {
+			return s, nil
+		}
+	}
+	return models.CashSession{}, fmt.Errorf("%w: cash session '%s'", models.ErrNotFound, id)
+}
+
+func (m *JSONCashSessionManager) GetOpenSession() (models.CashSession, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.sessions {
+		if s.Status == "open" {
+			return s, true, nil
+		}
+	}
+	return models.CashSession{}, false, nil
+}
+
+func (m *JSONCashSessionManager) UpdateSession(updated models.CashSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, s := range m.sessions {
+		if s.ID == updated.ID {
+			m.sessions[i] = updated
+			return m.save()
+		}
+	}
+	return fmt.Errorf("%w: cash session '%s'", models.ErrNotFound, updated.ID)
+}
@@ -0,0 +1,15 @@
+package dal
+
+import "hot-coffee/models"
+
+type CashSessionManager interface {
+	AddSession(session models.CashSession) error
+	GetAllSessions() ([]models.CashSession, error)
+	GetSession(id string) (models.CashSession, error)
+	GetOpenSession() (models.CashSession, bool, error)
+	UpdateSession(session models.CashSession) error
+}
+
+func (m *JSONCashSessionManager) LoadSessions() ([]models.CashSession, error) {
+	return m.GetAllSessions()
+}
@@ -0,0 +1,95 @@
+package dal
+
+import (
+	"encoding/json"
+	"fmt"
+	"hot-coffee/models"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+type JSONCustomerManager struct {
+	filePath  string
+	customers []models.Customer
+	mu        sync.Mutex
+}
+
+func NewJSONCustomerManager(filePath string) *JSONCustomerManager {
+	m := &JSONCustomerManager{filePath: filePath}
+	m.load()
+	return m
+}
+
+func (m *JSONCustomerManager) load() {
+	file, err := os.ReadFile(m.filePath)
+	if err != nil {
+		slog.Error("Failed to read customers file", "path", m.filePath, "error", err)
+		return
+	}
+
+	if err := json.Unmarshal(file, &m.customers); err != nil {
+		slog.Error("Invalid JSON format in customers file", "path", m.filePath, "error", err)
+	}
+}
+
+func (m *JSONCustomerManager) save() error {
+	data, err := json.MarshalIndent(m.customers, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.filePath, data, 0o644)
+}
+
+func (m *JSONCustomerManager) AddCustomer(customer models.Customer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, c := range m.customers {
+		if c.ID == customer.ID {
+			return fmt.Errorf("%w: customer ID '%s' already exists", models.ErrConflict, customer.ID)
+		}
+	}
+	m.customers = append(m.customers, customer)
+	return m.save()
+}
+
+func (m *JSONCustomerManager) GetAllCustomers() ([]models.Customer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.customers, nil
+}
+
+func (m *JSONCustomerManager) GetCustomer(id string) (models.Customer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, c := range m.customers {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return models.Customer{}, fmt.Errorf("%w: customer '%s'", models.ErrNotFound, id)
+}
+
+func (m *JSONCustomerManager) UpdateCustomer(updated models.Customer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, c := range m.customers {
+		if c.ID == updated.ID {
+			m.customers[i] = updated
+			return m.save()
+		}
+	}
+	return fmt.Errorf("%w: customer '%s'", models.ErrNotFound, updated.ID)
+}
+
+func (m *JSONCustomerManager) DeleteCustomer(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, c := range m.customers {
+		if c.ID == id {
+			m.customers = append(m.customers[:i], m.customers[i+1:]...)
+			return m.save()
+		}
+	}
+	return fmt.Errorf("%w: customer '%s'", models.ErrNotFound, id)
+}
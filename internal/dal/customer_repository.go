@@ -0,0 +1,11 @@
+package dal
+
+import "hot-coffee/models"
+
+type CustomerManager interface {
+	AddCustomer(customer models.Customer) error
+	GetAllCustomers() ([]models.Customer, error)
+	GetCustomer(id string) (models.Customer, error)
+	UpdateCustomer(customer models.Customer) error
+	DeleteCustomer(id string) error
+}
@@ -0,0 +1,123 @@
+package dal
+
+import (
+	"encoding/json"
+	"fmt"
+	"hot-coffee/models"
+	"log/slog"
+	"math"
+	"os"
+	"strings"
+	"sync"
+)
+
+type JSONGiftCardManager struct {
+	cardsPath        string
+	transactionsPath string
+	cards            []models.GiftCard
+	transactions     []models.GiftCardTransaction
+	mu               sync.Mutex
+}
+
+func NewJSONGiftCardManager(cardsPath string, transactionsPath string) *JSONGiftCardManager {
+	m := &JSONGiftCardManager{cardsPath: cardsPath, transactionsPath: transactionsPath}
+	m.load()
+	return m
+}
+
+func (m *JSONGiftCardManager) load() {
+	if file, err := os.ReadFile(m.cardsPath); err != nil {
+		slog.Error("Failed to read gift cards file", "path", m.cardsPath, "error", err)
+	} else if err := json.Unmarshal(file, &m.cards); err != nil {
+		slog.Error("Invalid JSON format in gift cards file", "path", m.cardsPath, "error", err)
+	}
+
+	if file, err := os.ReadFile(m.transactionsPath); err != nil {
+		slog.Error("Failed to read gift card transactions file", "path", m.transactionsPath, "error", err)
+	} else if err := json.Unmarshal(file, &m.transactions); err != nil {
+		slog.Error("Invalid JSON format in gift card transactions file", "path", m.transactionsPath, "error", err)
+	}
+}
+
+func (m *JSONGiftCardManager) save() error {
+	data, err := json.MarshalIndent(m.cards, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(m.cardsPath, data, 0o644); err != nil {
+		return err
+	}
+
+	data, err = json.MarshalIndent(m.transactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.transactionsPath, data, 0o644)
+}
+
+func (m *JSONGiftCardManager) AddGiftCard(card models.GiftCard, txn models.GiftCardTransaction) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, c := range m.cards {
+		if strings.EqualFold(c.Code, card.Code) {
+			return fmt.Errorf("%w: gift card code '%s' already exists", models.ErrConflict, card.Code)
+		}
+	}
+	m.cards = append(m.cards, card)
+	txn.ID = fmt.Sprintf("gct-%d", len(m.transactions)+1)
+	txn.BalanceAfter = card.Balance
+	m.transactions = append(m.transactions, txn)
+	return m.save()
+}
+
+func (m *JSONGiftCardManager) GetGiftCard(code string) (models.GiftCard, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, c := range m.cards {
+		if strings.EqualFold(c.Code, code) {
+			return c, nil
+		}
+	}
+	return models.GiftCard{}, fmt.Errorf("%w: gift card '%s'", models.ErrNotFound, code)
+}
+
+// AdjustBalance applies txn.Amount (negative for redemptions) and records the
+// transaction in one step so the balance can never go below zero.
+func (m *JSONGiftCardManager) AdjustBalance(code string, txn models.GiftCardTransaction) (models.GiftCard, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, c := range m.cards {
+		if !strings.EqualFold(c.Code, code) {
+			continue
+		}
+		balance := math.Round((c.Balance+txn.Amount)*100) / 100
+		if balance < 0 {
+			return c, fmt.Errorf("insufficient gift card balance: %.2f available", c.Balance)
+		}
+		m.cards[i].Balance = balance
+		txn.ID = fmt.Sprintf("gct-%d", len(m.transactions)+1)
+		txn.Code = c.Code
+		txn.BalanceAfter = balance
+		m.transactions = append(m.transactions, txn)
+		return m.cards[i], m.save()
+	}
+	return models.GiftCard{}, fmt.Errorf("%w: gift card '%s'", models.ErrNotFound, code)
+}
+
+func (m *JSONGiftCardManager) GetTransactions(code string) ([]models.GiftCardTransaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []models.GiftCardTransaction
+	for _, t := range m.transactions {
+		if strings.EqualFold(t.Code, code) {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+func (m *JSONGiftCardManager) GetAllTransactions() ([]models.GiftCardTransaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.transactions, nil
+}
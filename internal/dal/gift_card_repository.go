@@ -0,0 +1,15 @@
+package dal
+
+import "hot-coffee/models"
+
+type GiftCardManager interface {
+	AddGiftCard(card models.GiftCard, txn models.GiftCardTransaction) error
+	GetGiftCard(code string) (models.GiftCard, error)
+	AdjustBalance(code string, txn models.GiftCardTransaction) (models.GiftCard, error)
+	GetTransactions(code string) ([]models.GiftCardTransaction, error)
+	GetAllTransactions() ([]models.GiftCardTransaction, error)
+}
+
+func (m *JSONGiftCardManager) LoadGiftCardTransactions() ([]models.GiftCardTransaction, error) {
+	return m.GetAllTransactions()
+}
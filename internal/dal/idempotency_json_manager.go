@@ -0,0 +1,89 @@
+package dal
+
+import (
+	"encoding/json"
+	"hot-coffee/models"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+type JSONIdempotencyManager struct {
+	filePath string
+	records  map[string]models.IdempotencyRecord
+	mu       sync.Mutex
+}
+
+func NewJSONIdempotencyManager(filePath string) *JSONIdempotencyManager {
+	m := &JSONIdempotencyManager{filePath: filePath, records: make(map[string]models.IdempotencyRecord)}
+	m.load()
+	return m
+}
+
+func (m *JSONIdempotencyManager) load() {
+	file, err := os.ReadFile(m.filePath)
+	if err != nil {
+		slog.Error("Failed to read idempotency keys file", "path", m.filePath, "error", err)
+		return
+	}
+
+	var records []models.IdempotencyRecord
+	if err := json.Unmarshal(file, &records); err != nil {
+		slog.Error("Invalid JSON format in idempotency keys file", "path", m.filePath, "error", err)
+		return
+	}
+	for _, r := range records {
+		m.records[r.Key] = r
+	}
+}
+
+func (m *JSONIdempotencyManager) save() error {
+	records := make([]models.IdempotencyRecord, 0, len(m.records))
+	for _, r := range m.records {
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].CreatedAt != records[j].CreatedAt {
+			return records[i].CreatedAt < records[j].CreatedAt
+		}
+		return records[i].Key < records[j].Key
+	})
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.filePath, data, 0o644)
+}
+
+func (m *JSONIdempotencyManager) GetRecord(key string) (models.IdempotencyRecord, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.records[key]
+	return r, ok, nil
+}
+
+func (m *JSONIdempotencyManager) SaveRecord(record models.IdempotencyRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[record.Key] = record
+	return m.save()
+}
+
+func (m *JSONIdempotencyManager) PruneExpired(now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pruned := false
+	for key, r := range m.records {
+		expiresAt, err := time.Parse(time.RFC3339, r.ExpiresAt)
+		if err == nil && !now.Before(expiresAt) {
+			delete(m.records, key)
+			pruned = true
+		}
+	}
+	if !pruned {
+		return nil
+	}
+	return m.save()
+}
@@ -0,0 +1,12 @@
+package dal
+
+import (
+	"hot-coffee/models"
+	"time"
+)
+
+type IdempotencyManager interface {
+	GetRecord(key string) (models.IdempotencyRecord, bool, error)
+	SaveRecord(record models.IdempotencyRecord) error
+	PruneExpired(now time.Time) error
+}
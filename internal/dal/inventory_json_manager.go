@@ -2,35 +2,43 @@ package dal
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"hot-coffee/models"
 	"log/slog"
 	"os"
 	"sync"
+	"time"
 )
 
 type JSONInventoryManager struct {
-	filePath string
-	items    []models.InventoryItem
-	mu       sync.Mutex
+	filePath      string
+	movementsPath string
+	items         []models.InventoryItem
+	movements     []models.InventoryMovement
+	mu            sync.Mutex
 }
 
-func NewJSONInventoryManager(filePath string) *JSONInventoryManager {
-	m := &JSONInventoryManager{filePath: filePath}
+func NewJSONInventoryManager(filePath string, movementsPath string) *JSONInventoryManager {
+	m := &JSONInventoryManager{filePath: filePath, movementsPath: movementsPath}
 	m.load()
 	return m
 }
 
 func (m *JSONInventoryManager) load() {
-	file, err := os.ReadFile(m.filePath)
-	if err != nil {
+	if file, err := os.ReadFile(m.filePath); err != nil {
 		slog.Error("Failed to read inventory file", "path", m.filePath, "error", err)
-		return
+	} else if err := json.Unmarshal(file, &m.items); err != nil {
+		slog.Error("Invalid JSON format in inventory file", "path", m.filePath, "error", err)
+	}
+	// Items saved before versioning start at version 1.
+	for i := range m.items {
+		m.items[i].Version = max(m.items[i].Version, 1)
 	}
 
-	if err := json.Unmarshal(file, &m.items); err != nil {
-		slog.Error("Invalid JSON format in inventory file", "path", m.filePath, "error", err)
+	if file, err := os.ReadFile(m.movementsPath); err != nil {
+		slog.Error("Failed to read inventory movements file", "path", m.movementsPath, "error", err)
+	} else if err := json.Unmarshal(file, &m.movements); err != nil {
+		slog.Error("Invalid JSON format in inventory movements file", "path", m.movementsPath, "error", err)
 	}
 }
 
@@ -39,14 +47,54 @@ func (m *JSONInventoryManager) save() error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(m.filePath, data, 0o644)
+	if err := os.WriteFile(m.filePath, data, 0o644); err != nil {
+		return err
+	}
+
+	data, err = json.MarshalIndent(m.movements, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.movementsPath, data, 0o644)
+}
+
+func (m *JSONInventoryManager) record(movement models.InventoryMovement) models.InventoryMovement {
+	movement.ID = fmt.Sprintf("im-%d", len(m.movements)+1)
+	if movement.CreatedAt == "" {
+		movement.CreatedAt = time.Now().Format(time.RFC3339)
+	}
+	m.movements = append(m.movements, movement)
+	return movement
 }
 
-func (m *JSONInventoryManager) AddNewInventoryItem(item models.InventoryItem) error {
+// AddNewInventoryItem stores item under its ingredient_id, or under a slug of
+// its name when no ID is given.
+func (m *JSONInventoryManager) AddNewInventoryItem(item models.InventoryItem) (models.InventoryItem, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if item.IngredientID == "" {
+		item.IngredientID = uniqueSlug(item.Name, m.idExists)
+	} else if m.idExists(item.IngredientID) {
+		return models.InventoryItem{}, fmt.Errorf("%w: inventory item '%s' already exists", models.ErrConflict, item.IngredientID)
+	}
+	item.Version = 1
 	m.items = append(m.items, item)
-	return m.save()
+	m.record(models.InventoryMovement{
+		IngredientID: item.IngredientID,
+		Type:         models.MovementInitial,
+		Quantity:     item.Quantity,
+		StockAfter:   item.Quantity,
+	})
+	return item, m.save()
+}
+
+func (m *JSONInventoryManager) idExists(id string) bool {
+	for _, item := range m.items {
+		if item.IngredientID == id {
+			return true
+		}
+	}
+	return false
 }
 
 func (m *JSONInventoryManager) GetAllInventoryItems() ([]models.InventoryItem, error) {
@@ -63,55 +111,140 @@ func (m *JSONInventoryManager) GetInventoryItem(id string) (models.InventoryItem
 			return item, nil
 		}
 	}
-	return models.InventoryItem{}, errors.New("item not found")
+	return models.InventoryItem{}, fmt.Errorf("%w: inventory item '%s'", models.ErrNotFound, id)
 }
 
+// UpdateInventoryItem replaces an item. A non-zero updated.Version must match
+// the stored version.
 func (m *JSONInventoryManager) UpdateInventoryItem(updated models.InventoryItem) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	for i, item := range m.items {
 		if item.IngredientID == updated.IngredientID {
+			if err := models.CheckVersion("inventory item", item.IngredientID, updated.Version, item.Version); err != nil {
+				return err
+			}
+			updated.Version = item.Version + 1
 			m.items[i] = updated
+			if delta := updated.Quantity - item.Quantity; delta != 0 {
+				m.record(models.InventoryMovement{
+					IngredientID: item.IngredientID,
+					Type:         models.MovementAdjust,
+					Quantity:     delta,
+					StockAfter:   updated.Quantity,
+					Reason:       "item updated",
+				})
+			}
 			return m.save()
 		}
 	}
-	return errors.New("item not found")
+	return fmt.Errorf("%w: inventory item '%s'", models.ErrNotFound, updated.IngredientID)
 }
 
-func (m *JSONInventoryManager) DeleteInventoryItem(id string) error {
+// ApplyMovement changes the stock of one ingredient and logs the movement in
+// one step. Count movements set the stock to movement.StockAfter and record
+// the difference; all other types add movement.Quantity.
+func (m *JSONInventoryManager) ApplyMovement(movement models.InventoryMovement) (models.InventoryMovement, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, item := range m.items {
+		if item.IngredientID != movement.IngredientID {
+			continue
+		}
+		if movement.Type == models.MovementCount {
+			movement.Quantity = movement.StockAfter - item.Quantity
+		} else {
+			movement.StockAfter = item.Quantity + movement.Quantity
+		}
+		if movement.StockAfter < 0 {
+			return models.InventoryMovement{}, &models.InsufficientStockError{Shortages: []models.StockShortage{{
+				IngredientID: item.IngredientID,
+				Name:         item.Name,
+				Required:     -movement.Quantity,
+				Available:    item.Quantity,
+				Unit:         item.Unit,
+			}}}
+		}
+		m.items[i].Quantity = movement.StockAfter
+		m.items[i].Version++
+		movement = m.record(movement)
+		return movement, m.save()
+	}
+	return models.InventoryMovement{}, fmt.Errorf("%w: inventory item '%s'", models.ErrNotFound, movement.IngredientID)
+}
+
+func (m *JSONInventoryManager) GetMovements(ingredientID string) ([]models.InventoryMovement, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := []models.InventoryMovement{}
+	for _, movement := range m.movements {
+		if movement.IngredientID == ingredientID {
+			result = append(result, movement)
+		}
+	}
+	return result, nil
+}
+
+func (m *JSONInventoryManager) GetAllMovements() ([]models.InventoryMovement, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]models.InventoryMovement{}, m.movements...), nil
+}
+
+func (m *JSONInventoryManager) DeleteInventoryItem(id string, version int) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	for i, item := range m.items {
 		if item.IngredientID == id {
+			if err := models.CheckVersion("inventory item", id, version, item.Version); err != nil {
+				return err
+			}
 			m.items = append(m.items[:i], m.items[i+1:]...)
 			return m.save()
 		}
 	}
-	return errors.New("item not found")
+	return fmt.Errorf("%w: inventory item '%s'", models.ErrNotFound, id)
 }
 
 func (m *JSONInventoryManager) CheckSufficientIngredients(required []models.MenuItemIngredient) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	// Menu items may share ingredients, so compare the combined requirement.
+	totals := make(map[string]float64)
+	var order []string
 	for _, req := range required {
+		if _, ok := totals[req.IngredientID]; !ok {
+			order = append(order, req.IngredientID)
+		}
+		totals[req.IngredientID] += req.Quantity
+	}
+
+	var shortages []models.StockShortage
+	for _, id := range order {
 		found := false
 		for _, inv := range m.items {
-			if inv.IngredientID == req.IngredientID {
+			if inv.IngredientID == id {
 				found = true
-				if inv.Quantity < req.Quantity {
-					return fmt.Errorf(
-						"insufficient inventory for ingredient '%s'. Required: %.2f, Available: %.2f",
-						inv.Name, req.Quantity, inv.Quantity,
-					)
+				if inv.Quantity < totals[id] {
+					shortages = append(shortages, models.StockShortage{
+						IngredientID: id,
+						Name:         inv.Name,
+						Required:     totals[id],
+						Available:    inv.Quantity,
+						Unit:         inv.Unit,
+					})
 				}
 				break
 			}
 		}
 		if !found {
-			return fmt.Errorf("ingredient '%s' not found in inventory", req.IngredientID)
+			return fmt.Errorf("%w: ingredient '%s' in inventory", models.ErrNotFound, id)
 		}
 	}
+	if len(shortages) > 0 {
+		return &models.InsufficientStockError{Shortages: shortages}
+	}
 	return nil
 }
 
@@ -123,6 +256,7 @@ func (m *JSONInventoryManager) DeductIngredients(required []models.MenuItemIngre
 		for i := range m.items {
 			if m.items[i].IngredientID == req.IngredientID {
 				m.items[i].Quantity -= req.Quantity
+				m.items[i].Version++
 				break
 			}
 		}
@@ -138,6 +272,7 @@ func (m *JSONInventoryManager) RestoreIngredients(ingredients []models.MenuItemI
 		for i, item := range m.items {
 			if item.IngredientID == ing.IngredientID {
 				m.items[i].Quantity += ing.Quantity
+				m.items[i].Version++
 				break
 			}
 		}
@@ -3,12 +3,23 @@ package dal
 import "hot-coffee/models"
 
 type InventoryManager interface {
-	AddNewInventoryItem(item models.InventoryItem) error
+	AddNewInventoryItem(item models.InventoryItem) (models.InventoryItem, error)
 	GetAllInventoryItems() ([]models.InventoryItem, error)
 	GetInventoryItem(id string) (models.InventoryItem, error)
 	UpdateInventoryItem(item models.InventoryItem) error
-	DeleteInventoryItem(id string) error
+	DeleteInventoryItem(id string, version int) error
 	CheckSufficientIngredients(required []models.MenuItemIngredient) error
 	DeductIngredients(required []models.MenuItemIngredient) error
 	RestoreIngredients([]models.MenuItemIngredient) error
+	ApplyMovement(movement models.InventoryMovement) (models.InventoryMovement, error)
+	GetMovements(ingredientID string) ([]models.InventoryMovement, error)
+	GetAllMovements() ([]models.InventoryMovement, error)
+}
+
+func (m *JSONInventoryManager) LoadInventory() ([]models.InventoryItem, error) {
+	return m.GetAllInventoryItems()
+}
+
+func (m *JSONInventoryManager) LoadMovements() ([]models.InventoryMovement, error) {
+	return m.GetAllMovements()
 }
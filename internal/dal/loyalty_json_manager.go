@@ -0,0 +1,93 @@
+package dal
+
+import (
+	"encoding/json"
+	"fmt"
+	"hot-coffee/models"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+type JSONLoyaltyManager struct {
+	programPath string
+	ledgerPath  string
+	program     models.LoyaltyProgram
+	entries     []models.LoyaltyEntry
+	mu          sync.Mutex
+}
+
+func NewJSONLoyaltyManager(programPath string, ledgerPath string) *JSONLoyaltyManager {
+	m := &JSONLoyaltyManager{programPath: programPath, ledgerPath: ledgerPath}
+	m.load()
+	return m
+}
+
+func (m *JSONLoyaltyManager) load() {
+	if file, err := os.ReadFile(m.programPath); err != nil {
+		slog.Error("Failed to read loyalty program file", "path", m.programPath, "error", err)
+	} else if err := json.Unmarshal(file, &m.program); err != nil {
+		slog.Error("Invalid JSON format in loyalty program file", "path", m.programPath, "error", err)
+	}
+
+	if file, err := os.ReadFile(m.ledgerPath); err != nil {
+		slog.Error("Failed to read loyalty ledger file", "path", m.ledgerPath, "error", err)
+	} else if err := json.Unmarshal(file, &m.entries); err != nil {
+		slog.Error("Invalid JSON format in loyalty ledger file", "path", m.ledgerPath, "error", err)
+	}
+}
+
+func (m *JSONLoyaltyManager) saveProgram() error {
+	data, err := json.MarshalIndent(m.program, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.programPath, data, 0o644)
+}
+
+func (m *JSONLoyaltyManager) saveLedger() error {
+	data, err := json.MarshalIndent(m.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.ledgerPath, data, 0o644)
+}
+
+func (m *JSONLoyaltyManager) GetProgram() (models.LoyaltyProgram, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.program, nil
+}
+
+func (m *JSONLoyaltyManager) UpdateProgram(program models.LoyaltyProgram) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.program = program
+	return m.saveProgram()
+}
+
+func (m *JSONLoyaltyManager) AddEntry(entry models.LoyaltyEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry.ID = fmt.Sprintf("lp-%d", len(m.entries)+1)
+	m.entries = append(m.entries, entry)
+	return m.saveLedger()
+}
+
+func (m *JSONLoyaltyManager) GetAllEntries() ([]models.LoyaltyEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.entries, nil
+}
+
+func (m *JSONLoyaltyManager) GetEntriesByCustomer(customerID string) ([]models.LoyaltyEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []models.LoyaltyEntry
+	for _, e := range m.entries {
+		if e.CustomerID == customerID {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
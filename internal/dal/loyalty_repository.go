@@ -0,0 +1,11 @@
+package dal
+
+import "hot-coffee/models"
+
+type LoyaltyManager interface {
+	GetProgram() (models.LoyaltyProgram, error)
+	UpdateProgram(program models.LoyaltyProgram) error
+	AddEntry(entry models.LoyaltyEntry) error
+	GetAllEntries() ([]models.LoyaltyEntry, error)
+	GetEntriesByCustomer(customerID string) ([]models.LoyaltyEntry, error)
+}
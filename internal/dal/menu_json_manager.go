@@ -2,7 +2,7 @@ package dal
 
 import (
 	"encoding/json"
-	"errors"
+	"fmt"
 	"hot-coffee/models"
 	"log/slog"
 	"os"
@@ -31,6 +31,10 @@ func (m *JSONMenuManager) load() {
 	if err := json.Unmarshal(file, &m.items); err != nil {
 		slog.Error("Invalid JSON format in inventory file", "path", m.filePath, "error", err)
 	}
+	// Items saved before versioning start at version 1.
+	for i := range m.items {
+		m.items[i].Version = max(m.items[i].Version, 1)
+	}
 }
 
 func (m *JSONMenuManager) save() error {
@@ -41,11 +45,28 @@ func (m *JSONMenuManager) save() error {
 	return os.WriteFile(m.filePath, data, 0o644)
 }
 
-func (m *JSONMenuManager) AddNewMenuItem(item models.MenuItem) error {
+// AddNewMenuItem stores item under its product_id, or under a slug of its
+// name when no ID is given.
+func (m *JSONMenuManager) AddNewMenuItem(item models.MenuItem) (models.MenuItem, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if item.ID == "" {
+		item.ID = uniqueSlug(item.Name, m.idExists)
+	} else if m.idExists(item.ID) {
+		return models.MenuItem{}, fmt.Errorf("%w: menu item '%s' already exists", models.ErrConflict, item.ID)
+	}
+	item.Version = 1
 	m.items = append(m.items, item)
-	return m.save()
+	return item, m.save()
+}
+
+func (m *JSONMenuManager) idExists(id string) bool {
+	for _, item := range m.items {
+		if item.ID == id {
+			return true
+		}
+	}
+	return false
 }
 
 func (m *JSONMenuManager) GetAllMenuItems() ([]models.MenuItem, error) {
@@ -62,29 +83,38 @@ func (m *JSONMenuManager) GetMenuItem(id string) (models.MenuItem, error) {
 			return item, nil
 		}
 	}
-	return models.MenuItem{}, errors.New("menu item not found")
+	return models.MenuItem{}, fmt.Errorf("%w: menu item '%s'", models.ErrNotFound, id)
 }
 
+// UpdateMenuItem replaces an item. A non-zero updated.Version must match the
+// stored version.
 func (m *JSONMenuManager) UpdateMenuItem(updated models.MenuItem) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	for i, item := range m.items {
 		if item.ID == updated.ID {
+			if err := models.CheckVersion("menu item", item.ID, updated.Version, item.Version); err != nil {
+				return err
+			}
+			updated.Version = item.Version + 1
 			m.items[i] = updated
 			return m.save()
 		}
 	}
-	return errors.New("menu item not found")
+	return fmt.Errorf("%w: menu item '%s'", models.ErrNotFound, updated.ID)
 }
 
-func (m *JSONMenuManager) DeleteMenuItem(id string) error {
+func (m *JSONMenuManager) DeleteMenuItem(id string, version int) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	for i, item := range m.items {
 		if item.ID == id {
+			if err := models.CheckVersion("menu item", id, version, item.Version); err != nil {
+				return err
+			}
 			m.items = append(m.items[:i], m.items[i+1:]...)
 			return m.save()
 		}
 	}
-	return errors.New("menu item not found")
+	return fmt.Errorf("%w: menu item '%s'", models.ErrNotFound, id)
 }
@@ -3,11 +3,11 @@ package dal
 import "hot-coffee/models"
 
 type MenuManager interface {
-	AddNewMenuItem(item models.MenuItem) error
+	AddNewMenuItem(item models.MenuItem) (models.MenuItem, error)
 	GetAllMenuItems() ([]models.MenuItem, error)
 	GetMenuItem(id string) (models.MenuItem, error)
 	UpdateMenuItem(item models.MenuItem) error
-	DeleteMenuItem(id string) error
+	DeleteMenuItem(id string, version int) error
 }
 
 func (m *JSONMenuManager) LoadMenuItems() ([]models.MenuItem, error) {
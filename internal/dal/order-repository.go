@@ -3,12 +3,15 @@ package dal
 import "hot-coffee/models"
 
 type OrderManager interface {
-	CreateOrder(order models.Order) error
+	CreateOrder(order models.Order) (models.Order, error)
 	GetAllOrders() ([]models.Order, error)
 	GetOrderByID(id string) (models.Order, error)
 	UpdateOrder(order models.Order) error
-	DeleteOrder(id string) error
+	DeleteOrder(id string, version int) error
 	CloseOrder(id string) error
+	VoidOrder(id string, reason string) error
+	SetOrderStatus(id string, status string) error
+	MarkReady(id string, staffID string) (models.Order, error)
 }
 
 func (m *JSONOrderManager) LoadOrders() ([]models.Order, error) {
@@ -2,25 +2,44 @@ package dal
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
+	"hot-coffee/internal/idgen"
 	"hot-coffee/models"
 	"log/slog"
-	"math/rand"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 )
 
+// maxTicket is where ticket numbers wrap; three digits are easy to call out.
+const maxTicket = 999
+
+// orderCounters are the high-water marks for generated order IDs and tickets.
+// They are stored apart from the orders so deleting the newest order does not
+// hand its ID or ticket out again.
+type orderCounters struct {
+	LastID     string `json:"last_id,omitempty"`
+	TicketDay  string `json:"ticket_day,omitempty"`
+	LastTicket int    `json:"last_ticket,omitempty"`
+}
+
 type JSONOrderManager struct {
-	filePath string
-	orders   []models.Order
-	mu       sync.Mutex
+	filePath     string
+	countersPath string
+	orders       []models.Order
+	counters     orderCounters
+	ids          idgen.Generator
+	location     *time.Location
+	mu           sync.Mutex
 }
 
-func NewJSONOrderManager(filePath string) *JSONOrderManager {
-	m := &JSONOrderManager{filePath: filePath}
+func NewJSONOrderManager(filePath string, countersPath string, ids idgen.Generator, location *time.Location) *JSONOrderManager {
+	m := &JSONOrderManager{filePath: filePath, countersPath: countersPath, ids: ids, location: location}
 	m.load()
+	if r, ok := ids.(idgen.Resumer); ok && m.counters.LastID != "" {
+		r.Resume(m.counters.LastID)
+	}
 	return m
 }
 
@@ -34,6 +53,24 @@ func (m *JSONOrderManager) load() {
 	if err := json.Unmarshal(file, &m.orders); err != nil {
 		slog.Error("Invalid JSON format in inventory file", "path", m.filePath, "error", err)
 	}
+	// Orders saved before versioning start at version 1.
+	for i := range m.orders {
+		m.orders[i].Version = max(m.orders[i].Version, 1)
+	}
+
+	if file, err := os.ReadFile(m.countersPath); err != nil {
+		slog.Error("Failed to read order counters file", "path", m.countersPath, "error", err)
+	} else if err := json.Unmarshal(file, &m.counters); err != nil {
+		slog.Error("Invalid JSON format in order counters file", "path", m.countersPath, "error", err)
+	}
+}
+
+func (m *JSONOrderManager) saveCounters() error {
+	data, err := json.MarshalIndent(m.counters, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.countersPath, data, 0o644)
 }
 
 func (m *JSONOrderManager) save() error {
@@ -44,26 +81,55 @@ func (m *JSONOrderManager) save() error {
 	return os.WriteFile(m.filePath, data, 0o644)
 }
 
-func (m *JSONOrderManager) CreateOrder(order models.Order) error {
+func (m *JSONOrderManager) CreateOrder(order models.Order) (models.Order, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	rand.Seed(time.Now().UnixNano())
+	now := time.Now()
 
+	counters := m.counters
 	if order.ID == "" {
-		for {
-			randomID := fmt.Sprintf("%03d", rand.Intn(1000))
-			if !m.idExists(randomID) {
-				order.ID = randomID
-				break
-			}
-		}
+		order.ID = m.ids.NewID(now, m.idExists)
+		counters.LastID = order.ID
 	} else if m.idExists(order.ID) {
-		return errors.New("order ID already exists")
+		return models.Order{}, fmt.Errorf("%w: order ID '%s' already exists", models.ErrConflict, order.ID)
 	}
+	ticket := m.nextTicket(now)
+	counters.TicketDay = now.In(m.location).Format(time.DateOnly)
+	counters.LastTicket = ticket
+	order.Ticket = fmt.Sprintf("%03d", ticket)
+	order.Version = 1
 
+	// Counters go first: if the orders file then fails to save, a number is
+	// skipped rather than reused.
+	m.counters = counters
+	if err := m.saveCounters(); err != nil {
+		return models.Order{}, err
+	}
 	m.orders = append(m.orders, order)
-	return m.save()
+	return order, m.save()
+}
+
+// nextTicket numbers orders from 1 each business day, wrapping after maxTicket
+// so the number stays short enough to call out. Days from before the counters
+// were stored fall back to the newest ticket among the day's orders.
+func (m *JSONOrderManager) nextTicket(now time.Time) int {
+	day := now.In(m.location).Format(time.DateOnly)
+	if m.counters.TicketDay == day {
+		return m.counters.LastTicket%maxTicket + 1
+	}
+	last := 0
+	for i := len(m.orders) - 1; i >= 0; i-- {
+		createdAt, err := time.Parse(time.RFC3339, m.orders[i].CreatedAt)
+		if err != nil || createdAt.In(m.location).Format(time.DateOnly) != day {
+			continue
+		}
+		if n, err := strconv.Atoi(m.orders[i].Ticket); err == nil {
+			last = n
+			break
+		}
+	}
+	return last%maxTicket + 1
 }
 
 func (m *JSONOrderManager) idExists(id string) bool {
@@ -89,39 +155,53 @@ func (m *JSONOrderManager) GetOrderByID(id string) (models.Order, error) {
 			return order, nil
 		}
 	}
-	return models.Order{}, errors.New("order not found")
+	return models.Order{}, fmt.Errorf("%w: order '%s'", models.ErrNotFound, id)
 }
 
+// UpdateOrder changes the editable fields of an open order. A non-zero
+// updated.Version must match the stored version.
 func (m *JSONOrderManager) UpdateOrder(updated models.Order) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	for i, existing := range m.orders {
 		if existing.ID == updated.ID {
-			if existing.Status == "closed" {
-				return fmt.Errorf("cannot update a closed order (ID: %s)", existing.ID)
+			if err := models.CheckVersion("order", existing.ID, updated.Version, existing.Version); err != nil {
+				return err
+			}
+			if existing.Status != "open" {
+				return fmt.Errorf("%w: cannot update a %s order (ID: %s)", models.ErrInvalidTransition, existing.Status, existing.ID)
 			}
 
+			existing.CustomerID = updated.CustomerID
 			existing.CustomerName = updated.CustomerName
 			existing.Items = updated.Items
+			existing.Discounts = updated.Discounts
+			existing.Tax = updated.Tax
+			existing.Subtotal = updated.Subtotal
+			existing.Total = updated.Total
+			existing.Version++
 
 			m.orders[i] = existing
 			return m.save()
 		}
 	}
-	return errors.New("order not found")
+	return fmt.Errorf("%w: order '%s'", models.ErrNotFound, updated.ID)
 }
 
-func (m *JSONOrderManager) DeleteOrder(id string) error {
+func (m *JSONOrderManager) DeleteOrder(id string, version int) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	for i, order := range m.orders {
 		if order.ID == id {
+			if err := models.CheckVersion("order", id, version, order.Version); err != nil {
+				return err
+			}
 			m.orders = append(m.orders[:i], m.orders[i+1:]...)
 			return m.save()
 		}
 	}
-	return errors.New("order not found")
+	return fmt.Errorf("%w: order '%s'", models.ErrNotFound, id)
 }
 
 func (m *JSONOrderManager) CloseOrder(id string) error {
@@ -133,9 +213,73 @@ func (m *JSONOrderManager) CloseOrder(id string) error {
 		if order.ID == id {
 			slog.Info("Found and closing", "orderID", id)
 			m.orders[i].Status = "closed"
+			m.orders[i].History = appendStatus(order.History, "closed", "")
+			m.orders[i].Version++
 			return m.save()
 		}
 	}
 	slog.Warn("Order not found to close", "givenID", id)
-	return errors.New("order not found")
+	return fmt.Errorf("%w: order '%s'", models.ErrNotFound, id)
+}
+
+func (m *JSONOrderManager) VoidOrder(id string, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, order := range m.orders {
+		if order.ID == id {
+			if order.Status != "open" {
+				return fmt.Errorf("%w: cannot void a %s order (ID: %s)", models.ErrInvalidTransition, order.Status, id)
+			}
+			m.orders[i].Status = "voided"
+			m.orders[i].VoidReason = reason
+			m.orders[i].VoidedAt = time.Now().Format(time.RFC3339)
+			m.orders[i].History = appendStatus(order.History, "voided", "")
+			m.orders[i].Version++
+			return m.save()
+		}
+	}
+	return fmt.Errorf("%w: order '%s'", models.ErrNotFound, id)
+}
+
+func (m *JSONOrderManager) SetOrderStatus(id string, status string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, order := range m.orders {
+		if order.ID == id {
+			m.orders[i].Status = status
+			m.orders[i].History = appendStatus(order.History, status, "")
+			m.orders[i].Version++
+			return m.save()
+		}
+	}
+	return fmt.Errorf("%w: order '%s'", models.ErrNotFound, id)
+}
+
+// MarkReady records that an open order has been prepared. The order stays open
+// until it is paid; readiness only appears in its history.
+func (m *JSONOrderManager) MarkReady(id string, staffID string) (models.Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, order := range m.orders {
+		if order.ID != id {
+			continue
+		}
+		if order.Status != "open" {
+			return models.Order{}, fmt.Errorf("%w: cannot mark a %s order as ready (ID: %s)", models.ErrInvalidTransition, order.Status, id)
+		}
+		for _, change := range order.History {
+			if change.Status == "ready" {
+				return models.Order{}, fmt.Errorf("%w: order '%s' is already ready", models.ErrInvalidTransition, id)
+			}
+		}
+		m.orders[i].History = appendStatus(order.History, "ready", staffID)
+		m.orders[i].Version++
+		return m.orders[i], m.save()
+	}
+	return models.Order{}, fmt.Errorf("%w: order '%s'", models.ErrNotFound, id)
+}
+
+func appendStatus(history []models.StatusChange, status string, staffID string) []models.StatusChange {
+	change := models.StatusChange{Status: status, At: time.Now().Format(time.RFC3339), StaffID: staffID}
+	return append(append([]models.StatusChange{}, history...), change)
 }
@@ -0,0 +1,97 @@
+package dal
+
+import (
+	"encoding/json"
+	"fmt"
+	"hot-coffee/models"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+type JSONPaymentManager struct {
+	filePath string
+	payments []models.Payment
+	mu       sync.Mutex
+}
+
+func NewJSONPaymentManager(filePath string) *JSONPaymentManager {
+	m := &JSONPaymentManager{filePath: filePath}
+	m.load()
+	return m
+}
+
+func (m *JSONPaymentManager) load() {
+	file, err := os.ReadFile(m.filePath)
+	if err != nil {
+		slog.Error("Failed to read payments file", "path", m.filePath, "error", err)
+		return
+	}
+
+	if err := json.Unmarshal(file, &m.payments); err != nil {
+		slog.Error("Invalid JSON format in payments file", "path", m.filePath, "error", err)
+	}
+}
+
+func (m *JSONPaymentManager) save() error {
+	data, err := json.MarshalIndent(m.payments, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.filePath, data, 0o644)
+}
+
+func (m *JSONPaymentManager) AddPayment(payment models.Payment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, p := range m.payments {
+		if p.ID == payment.ID {
+			return fmt.Errorf("%w: payment ID '%s' already exists", models.ErrConflict, payment.ID)
+		}
+	}
+	m.payments = append(m.payments, payment)
+	return m.save()
+}
+
+func (m *JSONPaymentManager) GetAllPayments() ([]models.Payment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.payments, nil
+}
+
+func (m *JSONPaymentManager) GetPaymentsByOrder(orderID string) ([]models.Payment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []models.Payment
+	for _, p := range m.payments {
+		if p.OrderID == orderID {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+func (m *JSONPaymentManager) UpdatePayment(updated models.Payment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, p := range m.payments {
+		if p.ID == updated.ID {
+			m.payments[i] = updated
+			return m.save()
+		}
+	}
+	return fmt.Errorf("%w: payment '%s'", models.ErrNotFound, updated.ID)
+}
+
+func (m *JSONPaymentManager) DeletePayment(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, p := range m.payments {
+		if p.ID == id {
+			// Callers may still hold the slice GetAllPayments returned.
+			m.payments = append(m.payments[:i:i], m.payments[i+1:]...)
+			return m.save()
+		}
+	}
+	return fmt.Errorf("%w: payment '%s'", models.ErrNotFound, id)
+}
@@ -0,0 +1,15 @@
+package dal
+
+import "hot-coffee/models"
+
+type PaymentManager interface {
+	AddPayment(payment models.Payment) error
+	GetAllPayments() ([]models.Payment, error)
+	GetPaymentsByOrder(orderID string) ([]models.Payment, error)
+	UpdatePayment(payment models.Payment) error
+	DeletePayment(id string) error
+}
+
+func (m *JSONPaymentManager) LoadPayments() ([]models.Payment, error) {
+	return m.GetAllPayments()
+}
@@ -0,0 +1,72 @@
+package dal
+
+import (
+	"encoding/json"
+	"fmt"
+	"hot-coffee/models"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+type JSONRefundManager struct {
+	filePath string
+	refunds  []models.Refund
+	mu       sync.Mutex
+}
+
+func NewJSONRefundManager(filePath string) *JSONRefundManager {
+	m := &JSONRefundManager{filePath: filePath}
+	m.load()
+	return m
+}
+
+func (m *JSONRefundManager) load() {
+	file, err := os.ReadFile(m.filePath)
+	if err != nil {
+		slog.Error("Failed to read refunds file", "path", m.filePath, "error", err)
+		return
+	}
+
+	if err := json.Unmarshal(file, &m.refunds); err != nil {
+		slog.Error("Invalid JSON format in refunds file", "path", m.filePath, "error", err)
+	}
+}
+
+func (m *JSONRefundManager) save() error {
+	data, err := json.MarshalIndent(m.refunds, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.filePath, data, 0o644)
+}
+
+func (m *JSONRefundManager) AddRefund(refund models.Refund) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, p := range m.refunds {
+		if p.ID == refund.ID {
+			return fmt.Errorf("%w: refund ID '%s' already exists", models.ErrConflict, refund.ID)
+		}
+	}
+	m.refunds = append(m.refunds, refund)
+	return m.save()
+}
+
+func (m *JSONRefundManager) GetAllRefunds() ([]models.Refund, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.refunds, nil
+}
+
+func (m *JSONRefundManager) GetRefundsByOrder(orderID string) ([]models.Refund, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []models.Refund
+	for _, p := range m.refunds {
+		if p.OrderID == orderID {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,13 @@
+package dal
+
+import "hot-coffee/models"
+
+type RefundManager interface {
+	AddRefund(refund models.Refund) error
+	GetAllRefunds() ([]models.Refund, error)
+	GetRefundsByOrder(orderID string) ([]models.Refund, error)
+}
+
+func (m *JSONRefundManager) LoadRefunds() ([]models.Refund, error) {
+	return m.GetAllRefunds()
+}
@@ -0,0 +1,35 @@
+package dal
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// uniqueSlug derives a URL-safe ID from name, appending -2, -3, ... until
+// taken reports the candidate as free.
+func uniqueSlug(name string, taken func(id string) bool) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	base := b.String()
+	if base == "" {
+		base = "item"
+	}
+
+	slug := base
+	for n := 2; taken(slug); n++ {
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+	return slug
+}
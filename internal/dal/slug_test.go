@@ -0,0 +1,34 @@
+package dal
+
+import "testing"
+
+func TestUniqueSlug(t *testing.T) {
+	tests := []struct {
+		name  string
+		taken []string
+		want  string
+	}{
+		{name: "Flat White", want: "flat-white"},
+		{name: "  Café au lait!! ", want: "caf-au-lait"},
+		{name: "Espresso (double) 2x", want: "espresso-double-2x"},
+		{name: "Flat White", taken: []string{"flat-white"}, want: "flat-white-2"},
+		{name: "Flat White", taken: []string{"flat-white", "flat-white-2"}, want: "flat-white-3"},
+		{name: "☕", want: "item"},
+		{name: "", taken: []string{"item"}, want: "item-2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taken := func(id string) bool {
+				for _, existing := range tt.taken {
+					if existing == id {
+						return true
+					}
+				}
+				return false
+			}
+			if got := uniqueSlug(tt.name, taken); got != tt.want {
+				t.Errorf("uniqueSlug(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
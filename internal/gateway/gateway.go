@@ -0,0 +1,58 @@
+package gateway
+
+import "hot-coffee/models"
+
+var (
+	ErrDeclined    = models.NewError(models.ErrDeclined, "payment declined")
+	ErrTimeout     = models.NewError(models.ErrUpstreamTimeout, "payment gateway timeout")
+	ErrUnavailable = models.NewError(models.ErrUpstreamUnavailable, "payment gateway unavailable")
+)
+
+const (
+	StatusAuthorized = "authorized"
+	StatusCaptured   = "captured"
+	StatusRefunded   = "refunded"
+	StatusVoided     = "voided"
+	StatusDeclined   = "declined"
+)
+
+type PaymentGateway interface {
+	Authorize(req AuthorizeRequest) (Result, error)
+	Capture(transactionID string, amount float64, idempotencyKey string) (Result, error)
+	Refund(transactionID string, amount float64, idempotencyKey string) (Result, error)
+	Void(transactionID string, idempotencyKey string) (Result, error)
+}
+
+type AuthorizeRequest struct {
+	IdempotencyKey string  `json:"idempotency_key"`
+	OrderID        string  `json:"order_id"`
+	Amount         float64 `json:"amount"`
+	CardToken      string  `json:"card_token"`
+}
+
+type Result struct {
+	TransactionID string  `json:"transaction_id"`
+	Status        string  `json:"status"`
+	Amount        float64 `json:"amount"`
+	Message       string  `json:"message,omitempty"`
+}
+
+type Callback struct {
+	EventID       string `json:"event_id"`
+	TransactionID string `json:"transaction_id"`
+	Status        string `json:"status"`
+}
+
+// StatusRank orders gateway statuses so that late or duplicate callbacks
+// never move a transaction backwards.
+func StatusRank(status string) int {
+	switch status {
+	case StatusAuthorized:
+		return 1
+	case StatusCaptured:
+		return 2
+	case StatusRefunded, StatusVoided, StatusDeclined:
+		return 3
+	}
+	return 0
+}
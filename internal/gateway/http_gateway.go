@@ -0,0 +1,91 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HTTPGateway talks to a remote processor. Requests that time out are retried
+// with the same Idempotency-Key, so the processor replays its first response
+// instead of charging twice.
+type HTTPGateway struct {
+	baseURL string
+	client  *http.Client
+	retries int
+}
+
+func NewHTTPGateway(baseURL string, timeout time.Duration, retries int) *HTTPGateway {
+	return &HTTPGateway{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: timeout},
+		retries: retries,
+	}
+}
+
+func (g *HTTPGateway) Authorize(req AuthorizeRequest) (Result, error) {
+	return g.post("/authorize", req.IdempotencyKey, req)
+}
+
+func (g *HTTPGateway) Capture(transactionID string, amount float64, idempotencyKey string) (Result, error) {
+	return g.post("/capture", idempotencyKey, map[string]any{"transaction_id": transactionID, "amount": amount})
+}
+
+func (g *HTTPGateway) Refund(transactionID string, amount float64, idempotencyKey string) (Result, error) {
+	return g.post("/refund", idempotencyKey, map[string]any{"transaction_id": transactionID, "amount": amount})
+}
+
+func (g *HTTPGateway) Void(transactionID string, idempotencyKey string) (Result, error) {
+	return g.post("/void", idempotencyKey, map[string]any{"transaction_id": transactionID})
+}
+
+func (g *HTTPGateway) post(path string, idempotencyKey string, payload any) (Result, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= g.retries; attempt++ {
+		req, err := http.NewRequest(http.MethodPost, g.baseURL+path, bytes.NewReader(body))
+		if err != nil {
+			return Result{}, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if idempotencyKey != "" {
+			req.Header.Set("Idempotency-Key", idempotencyKey)
+		}
+
+		resp, err := g.client.Do(req)
+		if err != nil {
+			var netErr interface{ Timeout() bool }
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				lastErr = ErrTimeout
+				continue
+			}
+			return Result{}, fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+
+		var result Result
+		decodeErr := json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusPaymentRequired:
+			return result, ErrDeclined
+		case resp.StatusCode >= 500:
+			lastErr = fmt.Errorf("%w: status %d", ErrUnavailable, resp.StatusCode)
+			continue
+		case resp.StatusCode != http.StatusOK:
+			return result, fmt.Errorf("gateway rejected request: %s", result.Message)
+		case decodeErr != nil:
+			return Result{}, fmt.Errorf("invalid gateway response: %w", decodeErr)
+		}
+		return result, nil
+	}
+	return Result{}, lastErr
+}
@@ -0,0 +1,144 @@
+package gateway
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+const (
+	TokenDecline   = "tok_decline"
+	TokenTimeout   = "tok_timeout"
+	TokenDuplicate = "tok_duplicate"
+)
+
+type mockTransaction struct {
+	token      string
+	authorized float64
+	captured   float64
+	refunded   float64
+	status     string
+}
+
+type idempotentResponse struct {
+	result Result
+	err    error
+}
+
+// MockProcessor is an in-memory PaymentGateway. Card tokens listed above
+// trigger declines, timeouts and duplicate callbacks; any other token is
+// approved. Responses are cached per idempotency key and replayed.
+type MockProcessor struct {
+	mu           sync.Mutex
+	seq          int
+	transactions map[string]*mockTransaction
+	responses    map[string]idempotentResponse
+}
+
+func NewMockProcessor() *MockProcessor {
+	return &MockProcessor{
+		transactions: make(map[string]*mockTransaction),
+		responses:    make(map[string]idempotentResponse),
+	}
+}
+
+func (p *MockProcessor) Authorize(req AuthorizeRequest) (Result, error) {
+	return p.once("authorize", req.IdempotencyKey, func() (Result, error) {
+		if req.Amount <= 0 {
+			return Result{Status: StatusDeclined, Message: "invalid amount"}, ErrDeclined
+		}
+		p.seq++
+		id := fmt.Sprintf("txn_%06d", p.seq)
+		tx := &mockTransaction{token: req.CardToken, authorized: req.Amount, status: StatusAuthorized}
+		if req.CardToken == TokenDecline {
+			tx.status = StatusDeclined
+			p.transactions[id] = tx
+			return Result{TransactionID: id, Status: StatusDeclined, Message: "card declined"}, ErrDeclined
+		}
+		p.transactions[id] = tx
+		return Result{TransactionID: id, Status: StatusAuthorized, Amount: req.Amount}, nil
+	})
+}
+
+func (p *MockProcessor) Capture(transactionID string, amount float64, idempotencyKey string) (Result, error) {
+	return p.once("capture", idempotencyKey, func() (Result, error) {
+		tx, ok := p.transactions[transactionID]
+		if !ok {
+			return Result{}, fmt.Errorf("unknown transaction '%s'", transactionID)
+		}
+		if tx.status != StatusAuthorized {
+			return Result{TransactionID: transactionID, Status: tx.status}, fmt.Errorf("cannot capture a %s transaction", tx.status)
+		}
+		if amount > tx.authorized {
+			return Result{TransactionID: transactionID, Status: tx.status}, fmt.Errorf("capture exceeds authorized amount")
+		}
+		tx.captured = amount
+		tx.status = StatusCaptured
+		return Result{TransactionID: transactionID, Status: StatusCaptured, Amount: amount}, nil
+	})
+}
+
+func (p *MockProcessor) Refund(transactionID string, amount float64, idempotencyKey string) (Result, error) {
+	return p.once("refund", idempotencyKey, func() (Result, error) {
+		tx, ok := p.transactions[transactionID]
+		if !ok {
+			return Result{}, fmt.Errorf("unknown transaction '%s'", transactionID)
+		}
+		if tx.status != StatusCaptured && tx.status != StatusRefunded {
+			return Result{TransactionID: transactionID, Status: tx.status}, fmt.Errorf("cannot refund a %s transaction", tx.status)
+		}
+		if math.Round((tx.refunded+amount)*100) > math.Round(tx.captured*100) {
+			return Result{TransactionID: transactionID, Status: tx.status}, fmt.Errorf("refund exceeds captured amount")
+		}
+		tx.refunded += amount
+		if math.Round(tx.refunded*100) == math.Round(tx.captured*100) {
+			tx.status = StatusRefunded
+		}
+		return Result{TransactionID: transactionID, Status: tx.status, Amount: amount}, nil
+	})
+}
+
+func (p *MockProcessor) Void(transactionID string, idempotencyKey string) (Result, error) {
+	return p.once("void", idempotencyKey, func() (Result, error) {
+		tx, ok := p.transactions[transactionID]
+		if !ok {
+			return Result{}, fmt.Errorf("unknown transaction '%s'", transactionID)
+		}
+		if tx.status != StatusAuthorized {
+			return Result{TransactionID: transactionID, Status: tx.status}, fmt.Errorf("cannot void a %s transaction", tx.status)
+		}
+		tx.status = StatusVoided
+		return Result{TransactionID: transactionID, Status: StatusVoided}, nil
+	})
+}
+
+func (p *MockProcessor) token(transactionID string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if tx, ok := p.transactions[transactionID]; ok {
+		return tx.token
+	}
+	return ""
+}
+
+func (p *MockProcessor) seen(op string, idempotencyKey string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.responses[op+":"+idempotencyKey]
+	return ok && idempotencyKey != ""
+}
+
+func (p *MockProcessor) once(op string, idempotencyKey string, fn func() (Result, error)) (Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if idempotencyKey == "" {
+		return fn()
+	}
+	key := op + ":" + idempotencyKey
+	if cached, ok := p.responses[key]; ok {
+		return cached.result, cached.err
+	}
+	result, err := fn()
+	p.responses[key] = idempotentResponse{result: result, err: err}
+	return result, err
+}
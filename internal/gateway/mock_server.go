@@ -0,0 +1,148 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MockServer exposes a MockProcessor over HTTP so the HTTPGateway client and
+// the callback endpoint can be exercised end to end, e.g. with
+// httptest.NewServer(gateway.NewMockServer(callbackURL, secret)). Callbacks
+// are signed with secret.
+type MockServer struct {
+	Processor     *MockProcessor
+	CallbackURL   string
+	Secret        string
+	CallbackDelay time.Duration
+	TimeoutDelay  time.Duration
+
+	mu     sync.Mutex
+	events int
+}
+
+func NewMockServer(callbackURL string, secret string) *MockServer {
+	return &MockServer{
+		Processor:     NewMockProcessor(),
+		CallbackURL:   callbackURL,
+		Secret:        secret,
+		CallbackDelay: 200 * time.Millisecond,
+		TimeoutDelay:  5 * time.Second,
+	}
+}
+
+type mockRequest struct {
+	AuthorizeRequest
+	TransactionID string `json:"transaction_id"`
+}
+
+func (s *MockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeResult(w, http.StatusMethodNotAllowed, Result{Message: "method not allowed"})
+		return
+	}
+
+	var req mockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeResult(w, http.StatusBadRequest, Result{Message: "invalid request payload"})
+		return
+	}
+	key := r.Header.Get("Idempotency-Key")
+	op := strings.TrimPrefix(r.URL.Path, "/")
+	replay := s.Processor.seen(op, key)
+
+	var (
+		result Result
+		err    error
+		token  string
+	)
+	switch r.URL.Path {
+	case "/authorize":
+		req.IdempotencyKey = key
+		token = req.CardToken
+		result, err = s.Processor.Authorize(req.AuthorizeRequest)
+	case "/capture":
+		token = s.Processor.token(req.TransactionID)
+		result, err = s.Processor.Capture(req.TransactionID, req.Amount, key)
+	case "/refund":
+		token = s.Processor.token(req.TransactionID)
+		result, err = s.Processor.Refund(req.TransactionID, req.Amount, key)
+	case "/void":
+		token = s.Processor.token(req.TransactionID)
+		result, err = s.Processor.Void(req.TransactionID, key)
+	default:
+		writeResult(w, http.StatusNotFound, Result{Message: "not found"})
+		return
+	}
+
+	// The processor has already applied the request; a timeout token only
+	// delays the first response so clients have to retry with the same key.
+	if token == TokenTimeout && !replay {
+		time.Sleep(s.TimeoutDelay)
+	}
+
+	switch {
+	case errors.Is(err, ErrDeclined):
+		writeResult(w, http.StatusPaymentRequired, result)
+	case err != nil:
+		result.Message = err.Error()
+		writeResult(w, http.StatusUnprocessableEntity, result)
+	default:
+		writeResult(w, http.StatusOK, result)
+		if !replay {
+			s.notify(result, token == TokenDuplicate)
+		}
+	}
+}
+
+func (s *MockServer) notify(result Result, duplicate bool) {
+	if s.CallbackURL == "" {
+		return
+	}
+
+	s.mu.Lock()
+	s.events++
+	callback := Callback{
+		EventID:       fmt.Sprintf("evt_%06d", s.events),
+		TransactionID: result.TransactionID,
+		Status:        result.Status,
+	}
+	s.mu.Unlock()
+
+	sends := 1
+	if duplicate {
+		sends = 2
+	}
+	go func() {
+		time.Sleep(s.CallbackDelay)
+		body, _ := json.Marshal(callback)
+		signature := Sign(s.Secret, body)
+		for i := 0; i < sends; i++ {
+			req, err := http.NewRequest(http.MethodPost, s.CallbackURL, bytes.NewReader(body))
+			if err != nil {
+				slog.Warn("Mock gateway callback failed", "eventID", callback.EventID, "error", err)
+				return
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(SignatureHeader, signature)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				slog.Warn("Mock gateway callback failed", "eventID", callback.EventID, "error", err)
+				continue
+			}
+			resp.Body.Close()
+		}
+	}()
+}
+
+func writeResult(w http.ResponseWriter, status int, result Result) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
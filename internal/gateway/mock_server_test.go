@@ -0,0 +1,152 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testSecret = "test-secret"
+
+// callbackRecorder collects the callbacks a MockServer delivers.
+type callbackRecorder struct {
+	mu         sync.Mutex
+	callbacks  []Callback
+	signatures []error
+	received   chan struct{}
+}
+
+func newCallbackRecorder() *callbackRecorder {
+	return &callbackRecorder{received: make(chan struct{}, 16)}
+}
+
+func (c *callbackRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var callback Callback
+	json.Unmarshal(body, &callback)
+
+	c.mu.Lock()
+	c.callbacks = append(c.callbacks, callback)
+	c.signatures = append(c.signatures, VerifySignature(testSecret, body, r.Header.Get(SignatureHeader)))
+	c.mu.Unlock()
+	c.received <- struct{}{}
+}
+
+func (c *callbackRecorder) wait(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-c.received:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("received %d callbacks, want %d", i, n)
+		}
+	}
+}
+
+func newTestGateway(t *testing.T, callbackURL string, timeout time.Duration, retries int) (*HTTPGateway, *MockServer) {
+	t.Helper()
+	mock := NewMockServer(callbackURL, testSecret)
+	mock.CallbackDelay = 0
+	mock.TimeoutDelay = 300 * time.Millisecond
+	server := httptest.NewServer(mock)
+	t.Cleanup(server.Close)
+	return NewHTTPGateway(server.URL, timeout, retries), mock
+}
+
+func TestDecline(t *testing.T) {
+	gw, _ := newTestGateway(t, "", time.Second, 0)
+
+	result, err := gw.Authorize(AuthorizeRequest{IdempotencyKey: "k1", OrderID: "o1", Amount: 5, CardToken: TokenDecline})
+	if !errors.Is(err, ErrDeclined) {
+		t.Fatalf("Authorize() error = %v, want ErrDeclined", err)
+	}
+	if result.Status != StatusDeclined {
+		t.Errorf("status = %q, want %q", result.Status, StatusDeclined)
+	}
+}
+
+func TestTimeoutRetriesWithSameKey(t *testing.T) {
+	gw, mock := newTestGateway(t, "", 100*time.Millisecond, 2)
+
+	auth, err := gw.Authorize(AuthorizeRequest{IdempotencyKey: "k1", OrderID: "o1", Amount: 5, CardToken: TokenTimeout})
+	if err != nil {
+		t.Fatalf("Authorize() error = %v", err)
+	}
+	if auth.Status != StatusAuthorized {
+		t.Errorf("status = %q, want %q", auth.Status, StatusAuthorized)
+	}
+	mock.Processor.mu.Lock()
+	defer mock.Processor.mu.Unlock()
+	if len(mock.Processor.transactions) != 1 {
+		t.Errorf("processor created %d transactions, want 1", len(mock.Processor.transactions))
+	}
+}
+
+func TestTimeoutWithoutRetries(t *testing.T) {
+	gw, _ := newTestGateway(t, "", 100*time.Millisecond, 0)
+
+	_, err := gw.Authorize(AuthorizeRequest{IdempotencyKey: "k1", OrderID: "o1", Amount: 5, CardToken: TokenTimeout})
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Authorize() error = %v, want ErrTimeout", err)
+	}
+}
+
+func TestDuplicateCallbacks(t *testing.T) {
+	recorder := newCallbackRecorder()
+	callbacks := httptest.NewServer(recorder)
+	defer callbacks.Close()
+	gw, _ := newTestGateway(t, callbacks.URL, time.Second, 0)
+
+	auth, err := gw.Authorize(AuthorizeRequest{IdempotencyKey: "k1", OrderID: "o1", Amount: 5, CardToken: TokenDuplicate})
+	if err != nil {
+		t.Fatalf("Authorize() error = %v", err)
+	}
+	recorder.wait(t, 2)
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	first, second := recorder.callbacks[0], recorder.callbacks[1]
+	if first.EventID == "" || first.EventID != second.EventID {
+		t.Errorf("event IDs = %q, %q, want the same event delivered twice", first.EventID, second.EventID)
+	}
+	if first.TransactionID != auth.TransactionID || first.Status != StatusAuthorized {
+		t.Errorf("callback = %+v, want %s authorized", first, auth.TransactionID)
+	}
+	for i, err := range recorder.signatures {
+		if err != nil {
+			t.Errorf("callback %d signature: %v", i, err)
+		}
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"event_id":"evt_000001"}`)
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		wantErr   bool
+	}{
+		{"valid", testSecret, Sign(testSecret, body), false},
+		{"wrong secret", testSecret, Sign("other", body), true},
+		{"missing", testSecret, "", true},
+		{"no scheme", testSecret, Sign(testSecret, body)[len("sha256="):], true},
+		{"no secret configured", "", Sign("", body), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifySignature(tt.secret, body, tt.signature)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifySignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("error = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
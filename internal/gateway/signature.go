@@ -0,0 +1,38 @@
+package gateway
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hot-coffee/models"
+	"strings"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of a callback body, keyed with the
+// secret shared between the gateway and the shop, as "sha256=<hex>".
+const SignatureHeader = "X-Gateway-Signature"
+
+var ErrInvalidSignature = models.NewError(models.ErrUnauthorized, "invalid gateway signature")
+
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports ErrInvalidSignature unless signature is the HMAC of
+// body under secret. An empty secret never verifies, so callbacks stay closed
+// until one is configured.
+func VerifySignature(secret string, body []byte, signature string) error {
+	if secret == "" {
+		return fmt.Errorf("%w: no callback secret is configured", ErrInvalidSignature)
+	}
+	if !strings.HasPrefix(signature, "sha256=") {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
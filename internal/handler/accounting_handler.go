@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"encoding/json"
+	"hot-coffee/help"
+	"hot-coffee/internal/service"
+	"hot-coffee/models"
+	"log/slog"
+	"net/http"
+)
+
+type AccountingHandler struct {
+	AccountingService *service.AccountingService
+}
+
+func NewAccountingHandler(service *service.AccountingService) *AccountingHandler {
+	return &AccountingHandler{AccountingService: service}
+}
+
+func (h *AccountingHandler) GetChart(w http.ResponseWriter, r *http.Request) {
+	chart, err := h.AccountingService.GetChart()
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to fetch chart of accounts")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(chart)
+}
+
+func (h *AccountingHandler) UpdateChart(w http.ResponseWriter, r *http.Request) {
+	var chart models.ChartOfAccounts
+	if err := json.NewDecoder(r.Body).Decode(&chart); err != nil {
+		slog.Warn("Invalid chart of accounts JSON", "error", err)
+		help.WriteError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	chart, err := h.AccountingService.UpdateChart(chart)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to update chart of accounts")
+		return
+	}
+
+	slog.Info("Chart of accounts updated")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(chart)
+}
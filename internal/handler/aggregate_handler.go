@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"encoding/json"
+	"hot-coffee/help"
+	"hot-coffee/internal/service"
+	"log/slog"
+	"net/http"
+)
+
+type AggregateHandler struct {
+	AggregateService *service.AggregateService
+}
+
+func NewAggregateHandler(service *service.AggregateService) *AggregateHandler {
+	return &AggregateHandler{AggregateService: service}
+}
+
+func (h *AggregateHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.AggregateService.GetStatus()
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to read report aggregates")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+func (h *AggregateHandler) Rebuild(w http.ResponseWriter, r *http.Request) {
+	status, err := h.AggregateService.Rebuild()
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to rebuild report aggregates")
+		return
+	}
+	slog.Info("Report aggregates rebuilt", "days", status.Days, "orders", status.Orders, "refunds", status.Refunds)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
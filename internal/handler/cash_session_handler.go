@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"encoding/json"
+	"hot-coffee/help"
+	"hot-coffee/internal/service"
+	"hot-coffee/models"
+	"log/slog"
+	"net/http"
+)
+
+type CashSessionHandler struct {
+	CashSessionService *service.CashSessionService
+}
+
+func NewCashSessionHandler(service *service.CashSessionService) *CashSessionHandler {
+	return &CashSessionHandler{CashSessionService: service}
+}
+
+func (h *CashSessionHandler) GetAllSessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := h.CashSessionService.GetAllSessions()
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to fetch cash sessions")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
+
+func (h *CashSessionHandler) OpenSession(w http.ResponseWriter, r *http.Request) {
+	var session models.CashSession
+	if err := json.NewDecoder(r.Body).Decode(&session); err != nil {
+		slog.Warn("Invalid cash session JSON", "error", err)
+		help.WriteError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	session, err := h.CashSessionService.OpenSession(session)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to open cash session")
+		return
+	}
+
+	slog.Info("Cash session opened", "sessionID", session.ID, "float", session.OpeningFloat)
+	help.WriteCreated(w, "/cash-sessions", session.ID, session)
+}
+
+func (h *CashSessionHandler) GetSession(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	session, err := h.CashSessionService.GetSession(id)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to fetch cash session")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
+
+func (h *CashSessionHandler) AddEvent(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var event models.CashEvent
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		slog.Warn("Invalid cash event JSON", "error", err)
+		help.WriteError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	session, err := h.CashSessionService.AddEvent(id, event)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to record cash event")
+		return
+	}
+
+	slog.Info("Cash event recorded", "sessionID", id, "type", event.Type, "amount", event.Amount)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(session)
+}
+
+func (h *CashSessionHandler) CloseSession(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var req models.CloseCashSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.Warn("Invalid cash session close JSON", "error", err)
+		help.WriteError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	session, err := h.CashSessionService.CloseSession(id, req.CountedAmount)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to close cash session")
+		return
+	}
+
+	slog.Info("Cash session closed", "sessionID", id, "overShort", session.OverShort)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
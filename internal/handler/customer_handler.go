@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"encoding/json"
+	"hot-coffee/help"
+	"hot-coffee/internal/service"
+	"hot-coffee/models"
+	"log/slog"
+	"net/http"
+)
+
+type CustomerHandler struct {
+	CustomerService *service.CustomerService
+}
+
+func NewCustomerHandler(service *service.CustomerService) *CustomerHandler {
+	return &CustomerHandler{CustomerService: service}
+}
+
+func (h *CustomerHandler) GetAllCustomers(w http.ResponseWriter, r *http.Request) {
+	customers, err := h.CustomerService.GetAllCustomers()
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to fetch customers")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(customers)
+}
+
+func (h *CustomerHandler) AddCustomer(w http.ResponseWriter, r *http.Request) {
+	var customer models.Customer
+	if err := json.NewDecoder(r.Body).Decode(&customer); err != nil {
+		slog.Warn("Invalid customer JSON", "error", err)
+		help.WriteError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	customer, err := h.CustomerService.AddCustomer(customer)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to add customer")
+		return
+	}
+
+	slog.Info("Customer added", "customerID", customer.ID)
+	help.WriteCreated(w, "/customers", customer.ID, customer)
+}
+
+func (h *CustomerHandler) GetCustomer(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	customer, err := h.CustomerService.GetCustomer(id)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to fetch customer")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(customer)
+}
+
+func (h *CustomerHandler) UpdateCustomer(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var customer models.Customer
+	if err := json.NewDecoder(r.Body).Decode(&customer); err != nil {
+		slog.Warn("Invalid JSON for customer update", "error", err)
+		help.WriteError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	customer.ID = id
+	customer, err := h.CustomerService.UpdateCustomer(customer)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to update customer")
+		return
+	}
+
+	slog.Info("Customer updated", "customerID", id)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(customer)
+}
+
+func (h *CustomerHandler) DeleteCustomer(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := h.CustomerService.DeleteCustomer(id); err != nil {
+		help.WriteServiceError(w, err, "Failed to delete customer")
+		return
+	}
+
+	slog.Info("Customer deleted", "customerID", id)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *CustomerHandler) GetCustomerHistory(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	history, err := h.CustomerService.GetCustomerHistory(id)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to fetch customer history")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
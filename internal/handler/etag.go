@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"hot-coffee/help"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", `"`+strconv.Itoa(version)+`"`)
+}
+
+// ifMatch returns the version the If-Match header expects, or 0 when the
+// header is absent or "*". When the header lists several tags, current is
+// called for the stored version, which is returned if listed so the write
+// still fails should it change before saving. It answers 412 itself when no
+// tag can match, weak tags included, which If-Match never matches.
+func ifMatch(w http.ResponseWriter, r *http.Request, current func() (int, error)) (int, bool) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return 0, true
+	}
+	var versions []int
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.TrimSpace(tag)
+		if !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+			continue
+		}
+		if version, err := strconv.Atoi(strings.Trim(tag, `"`)); err == nil && version > 0 {
+			versions = append(versions, version)
+		}
+	}
+	if len(versions) == 1 {
+		return versions[0], true
+	}
+	if len(versions) > 1 {
+		version, err := current()
+		if err != nil {
+			help.WriteServiceError(w, err, "Failed to check If-Match")
+			return 0, false
+		}
+		if slices.Contains(versions, version) {
+			return version, true
+		}
+	}
+	help.WriteError(w, http.StatusPreconditionFailed, "If-Match does not match the current version")
+	return 0, false
+}
+
+// notModified answers 304 when If-None-Match names the current version. Weak
+// and strong tags compare equal here, as RFC 9110 requires for GET.
+func notModified(w http.ResponseWriter, r *http.Request, version int) bool {
+	value := r.Header.Get("If-None-Match")
+	if value == "" {
+		return false
+	}
+	current := strconv.Itoa(version)
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || strings.Trim(tag, `"`) == current {
+			setETag(w, version)
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// RequireIfMatch answers 428 to writes that do not say which version they
+// expect, so clients cannot overwrite changes they have not seen.
+func RequireIfMatch(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Match") == "" {
+			help.WriteError(w, http.StatusPreconditionRequired, "If-Match header is required")
+			return
+		}
+		next(w, r)
+	}
+}
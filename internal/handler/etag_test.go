@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"errors"
+	"hot-coffee/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		header     string
+		want       int
+		wantStatus int
+	}{
+		{header: "", want: 0},
+		{header: "*", want: 0},
+		{header: `"2"`, want: 2},
+		{header: `"1", "3"`, want: 3},
+		{header: `W/"3", "1"`, want: 1},
+		{header: `"1", "2"`, wantStatus: http.StatusPreconditionFailed},
+		{header: `W/"3"`, wantStatus: http.StatusPreconditionFailed},
+		{header: `3`, wantStatus: http.StatusPreconditionFailed},
+		{header: `"0"`, wantStatus: http.StatusPreconditionFailed},
+	}
+	current := func() (int, error) { return 3, nil }
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/menu/latte", nil)
+			if tt.header != "" {
+				r.Header.Set("If-Match", tt.header)
+			}
+			w := httptest.NewRecorder()
+			got, ok := ifMatch(w, r, current)
+			if tt.wantStatus != 0 {
+				if ok || w.Code != tt.wantStatus {
+					t.Errorf("ifMatch() ok = %v, status %d, want status %d", ok, w.Code, tt.wantStatus)
+				}
+				return
+			}
+			if !ok || got != tt.want {
+				t.Errorf("ifMatch() = %d, %v, want %d", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestIfMatchListMissingItem(t *testing.T) {
+	r := httptest.NewRequest(http.MethodDelete, "/menu/latte", nil)
+	r.Header.Set("If-Match", `"1", "2"`)
+	w := httptest.NewRecorder()
+	_, ok := ifMatch(w, r, func() (int, error) { return 0, models.ErrNotFound })
+	if ok || w.Code != http.StatusNotFound {
+		t.Errorf("ifMatch() ok = %v, status %d, want 404", ok, w.Code)
+	}
+	if _, ok := ifMatch(httptest.NewRecorder(), r, func() (int, error) { return 0, errors.New("disk full") }); ok {
+		t.Error("ifMatch() ok = true after a lookup failure")
+	}
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"encoding/json"
+	"hot-coffee/help"
+	"hot-coffee/internal/service"
+	"hot-coffee/models"
+	"log/slog"
+	"net/http"
+)
+
+type GiftCardHandler struct {
+	GiftCardService *service.GiftCardService
+}
+
+func NewGiftCardHandler(service *service.GiftCardService) *GiftCardHandler {
+	return &GiftCardHandler{GiftCardService: service}
+}
+
+func (h *GiftCardHandler) IssueGiftCard(w http.ResponseWriter, r *http.Request) {
+	var req models.GiftCardAmountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.Warn("Invalid gift card JSON", "error", err)
+		help.WriteError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	card, err := h.GiftCardService.IssueGiftCard(req)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to issue gift card")
+		return
+	}
+
+	slog.Info("Gift card issued", "code", card.Code, "amount", card.InitialValue)
+	help.WriteCreated(w, "/giftcards", card.Code, card)
+}
+
+func (h *GiftCardHandler) GetGiftCard(w http.ResponseWriter, r *http.Request) {
+	code := r.PathValue("code")
+	card, err := h.GiftCardService.GetGiftCard(code)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to fetch gift card")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(card)
+}
+
+func (h *GiftCardHandler) ReloadGiftCard(w http.ResponseWriter, r *http.Request) {
+	code := r.PathValue("code")
+	var req models.GiftCardAmountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.Warn("Invalid gift card reload JSON", "error", err)
+		help.WriteError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	card, err := h.GiftCardService.ReloadGiftCard(code, req)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to reload gift card")
+		return
+	}
+
+	slog.Info("Gift card reloaded", "code", card.Code, "amount", req.Amount)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(card)
+}
+
+func (h *GiftCardHandler) GetTransactions(w http.ResponseWriter, r *http.Request) {
+	code := r.PathValue("code")
+	txns, err := h.GiftCardService.GetTransactions(code)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to fetch gift card")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(txns)
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"hot-coffee/help"
+	"hot-coffee/internal/service"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// replayedHeaders are the response headers stored with a record. Others are
+// either per-response or irrelevant to a retrying client.
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+type IdempotencyHandler struct {
+	IdempotencyService *service.IdempotencyService
+}
+
+func NewIdempotencyHandler(service *service.IdempotencyService) *IdempotencyHandler {
+	return &IdempotencyHandler{IdempotencyService: service}
+}
+
+// Wrap makes next safe to retry: the first response for an Idempotency-Key is
+// stored and replayed for later requests with the same key and body. Requests
+// without the header pass straight through.
+func (h *IdempotencyHandler) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			slog.Warn("Failed to read request body", "error", err)
+			help.WriteError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		record, replay, err := h.IdempotencyService.Begin(key, r.Method, r.URL.Path, body)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrIdempotencyMismatch):
+				slog.Warn("Idempotency key reused", "key", key, "error", err)
+				help.WriteErrorDetails(w, http.StatusUnprocessableEntity, help.CodeIdempotencyMismatch, err.Error(), nil)
+			default:
+				help.WriteServiceError(w, err, "Failed to check idempotency key")
+			}
+			return
+		}
+		if replay {
+			slog.Info("Replaying idempotent response", "key", key, "path", r.URL.Path)
+			for name, value := range record.Headers {
+				w.Header().Set(name, value)
+			}
+			w.Header().Set(idempotentReplayedHeader, "true")
+			w.WriteHeader(record.Status)
+			io.WriteString(w, record.Body)
+			return
+		}
+
+		rec := &responseRecorder{header: make(http.Header), status: http.StatusOK}
+		completed := false
+		defer func() {
+			if !completed {
+				h.IdempotencyService.Release(key)
+			}
+		}()
+		next(rec, r)
+
+		headers := make(map[string]string)
+		for _, name := range replayedHeaders {
+			if value := rec.header.Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+		if err := h.IdempotencyService.Complete(key, r.Method, r.URL.Path, body, rec.status, headers, rec.body.Bytes()); err != nil {
+			slog.Error("Failed to store idempotent response", "key", key, "error", err)
+		}
+		completed = true
+
+		for name, values := range rec.header {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(rec.status)
+		w.Write(rec.body.Bytes())
+	}
+}
+
+// responseRecorder buffers a handler's response so it can be stored before it
+// is sent.
+type responseRecorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.body.Write(b)
+}
@@ -7,6 +7,7 @@ import (
 	"hot-coffee/models"
 	"log/slog"
 	"net/http"
+	"net/url"
 )
 
 type InventoryHandler struct {
@@ -18,75 +19,179 @@ func NewInventoryHandler(service *service.InventoryService) *InventoryHandler {
 }
 
 func (h *InventoryHandler) GetAllInventoryItems(w http.ResponseWriter, r *http.Request) {
-	items, err := h.InventoryService.GetAllInventoryItems()
+	q := r.URL.Query()
+	opts, err := parseListOptions(q)
 	if err != nil {
-		slog.Error("Failed to fetch inventory items", "error", err)
-		help.WriteError(w, http.StatusInternalServerError, "Failed to fetch inventory items")
+		help.WriteServiceError(w, err, "Failed to fetch inventory items")
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(items)
+	maxQuantity, err := floatParam(q, "max_quantity")
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to fetch inventory items")
+		return
+	}
+	filter := service.InventoryFilter{
+		Query:       q.Get("q"),
+		Unit:        q.Get("unit"),
+		MaxQuantity: maxQuantity,
+	}
+	page, err := h.InventoryService.ListInventoryItems(filter, opts)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to fetch inventory items")
+		return
+	}
+	writePage(w, r, page, opts.Fields)
 }
 
 func (h *InventoryHandler) AddNewInventoryItem(w http.ResponseWriter, r *http.Request) {
 	var item models.InventoryItem
-	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
-		slog.Warn("Invalid inventory JSON", "error", err)
-		help.WriteError(w, http.StatusBadRequest, "Invalid request payload")
+	if !decodeBody(w, r, &item) {
 		return
 	}
-	if err := h.InventoryService.AddNewInventoryItem(item); err != nil {
-		slog.Error("Failed to add inventory item", "error", err)
-		help.WriteError(w, http.StatusInternalServerError, "Failed to add inventory item")
+	item, err := h.InventoryService.AddNewInventoryItem(item)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to add inventory item")
 		return
 	}
 	slog.Info("Inventory item added", "ingredientID", item.IngredientID)
-	w.WriteHeader(http.StatusCreated)
+	setETag(w, item.Version)
+	help.WriteCreated(w, "/inventory", item.IngredientID, item)
 }
 
-func (h *InventoryHandler) GetInventoryItem(w http.ResponseWriter, r *http.Request, id string) {
+func (h *InventoryHandler) GetInventoryItem(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
 	item, err := h.InventoryService.GetInventoryItem(id)
 	if err != nil {
-		slog.Warn("Inventory item not found", "ingredientID", id)
-		help.WriteError(w, http.StatusNotFound, "Inventory item not found")
+		help.WriteServiceError(w, err, "Failed to fetch inventory item")
+		return
+	}
+	if notModified(w, r, item.Version) {
 		return
 	}
+	setETag(w, item.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(item)
 }
 
-func (h *InventoryHandler) UpdateInventoryItem(w http.ResponseWriter, r *http.Request, id string) {
+func (h *InventoryHandler) UpdateInventoryItem(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	version, ok := ifMatch(w, r, h.inventoryVersion(id))
+	if !ok {
+		return
+	}
 	var updatedItem models.InventoryItem
-	if err := json.NewDecoder(r.Body).Decode(&updatedItem); err != nil {
-		slog.Warn("Invalid JSON for inventory update", "error", err)
-		help.WriteError(w, http.StatusBadRequest, "Invalid request payload")
+	if !decodeBody(w, r, &updatedItem) {
 		return
 	}
 
 	updatedItem.IngredientID = id
-	if err := h.InventoryService.UpdateInventoryItem(updatedItem); err != nil {
-		slog.Error("Failed to update inventory item", "ingredientID", id, "error", err)
-		help.WriteError(w, http.StatusInternalServerError, "Failed to update inventory item")
+	if version != 0 {
+		updatedItem.Version = version
+	}
+	item, err := h.InventoryService.UpdateInventoryItem(updatedItem)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to update inventory item")
 		return
 	}
 
 	slog.Info("Inventory item updated", "ingredientID", id)
-	w.WriteHeader(http.StatusOK)
+	setETag(w, item.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
 }
 
-func (h *InventoryHandler) DeleteInventoryItem(w http.ResponseWriter, r *http.Request, id string) {
-	if id == "" {
-		slog.Warn("Missing inventory item ID in path")
-		help.WriteError(w, http.StatusBadRequest, "Missing inventory item ID")
+func (h *InventoryHandler) PatchInventoryItem(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	version, ok := ifMatch(w, r, h.inventoryVersion(id))
+	if !ok {
+		return
+	}
+	patch, ok := readMergePatch(w, r)
+	if !ok {
+		return
+	}
+	item, err := h.InventoryService.PatchInventoryItem(id, patch, version)
+	if err != nil {
+		writePatchError(w, err, "Failed to update inventory item")
 		return
 	}
 
-	if err := h.InventoryService.DeleteInventoryItem(id); err != nil {
-		slog.Error("Failed to delete inventory item", "ingredientID", id, "error", err)
-		help.WriteError(w, http.StatusInternalServerError, "Failed to delete inventory item")
+	slog.Info("Inventory item patched", "ingredientID", id)
+	setETag(w, item.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+func (h *InventoryHandler) DeleteInventoryItem(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	version, ok := ifMatch(w, r, h.inventoryVersion(id))
+	if !ok {
+		return
+	}
+	if err := h.InventoryService.DeleteInventoryItem(id, version); err != nil {
+		help.WriteServiceError(w, err, "Failed to delete inventory item")
 		return
 	}
 
 	slog.Info("Inventory item deleted", "ingredientID", id)
 	w.WriteHeader(http.StatusOK)
 }
+
+func (h *InventoryHandler) AddMovement(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var req models.InventoryMovementRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	movement, err := h.InventoryService.AddMovement(id, req)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to record inventory movement")
+		return
+	}
+
+	slog.Info("Inventory movement recorded", "ingredientID", id, "type", movement.Type, "quantity", movement.Quantity)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/inventory/"+url.PathEscape(id)+"/movements")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(movement)
+}
+
+func (h *InventoryHandler) RecordCount(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var req models.InventoryCountRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	movement, err := h.InventoryService.RecordCount(id, req)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to record inventory count")
+		return
+	}
+
+	slog.Info("Inventory count recorded", "ingredientID", id, "counted", movement.StockAfter, "difference", movement.Quantity)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/inventory/"+url.PathEscape(id)+"/movements")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(movement)
+}
+
+func (h *InventoryHandler) GetMovements(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	movements, err := h.InventoryService.GetMovements(id)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to fetch inventory item")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(movements)
+}
+
+// inventoryVersion looks up the stored version for ifMatch.
+func (h *InventoryHandler) inventoryVersion(id string) func() (int, error) {
+	return func() (int, error) {
+		item, err := h.InventoryService.GetInventoryItem(id)
+		return item.Version, err
+	}
+}
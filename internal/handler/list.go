@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"hot-coffee/help"
+	"hot-coffee/internal/service"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// parseListOptions reads limit, offset, sort and fields from the query string.
+// Without a limit the whole list is returned.
+func parseListOptions(q url.Values) (service.ListOptions, error) {
+	var opts service.ListOptions
+	var err error
+	if opts.Limit, err = intParam(q, "limit"); err != nil {
+		return service.ListOptions{}, err
+	}
+	if opts.Offset, err = intParam(q, "offset"); err != nil {
+		return service.ListOptions{}, err
+	}
+	opts.Sort = q.Get("sort")
+	if v := q.Get("fields"); v != "" {
+		for _, field := range strings.Split(v, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				opts.Fields = append(opts.Fields, field)
+			}
+		}
+	}
+	return opts, nil
+}
+
+func intParam(q url.Values, name string) (int, error) {
+	v := q.Get(name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s must be an integer", service.ErrInvalidQuery, name)
+	}
+	return n, nil
+}
+
+func floatParam(q url.Values, name string) (*float64, error) {
+	v := q.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s must be a number", service.ErrInvalidQuery, name)
+	}
+	return &n, nil
+}
+
+// writePage answers with the page's items as a JSON array. The total count
+// goes in X-Total-Count and neighbouring pages in a Link header.
+func writePage[T any](w http.ResponseWriter, r *http.Request, page service.Page[T], fields []string) {
+	var body any = page.Items
+	if len(fields) > 0 {
+		projected, err := service.SelectFields(page.Items, fields)
+		if err != nil {
+			help.WriteServiceError(w, err, "Failed to select fields")
+			return
+		}
+		body = projected
+	}
+
+	var links []string
+	if page.HasNext() {
+		links = append(links, pageLink(r, page.Offset+page.Limit, page.Limit, "next"))
+	}
+	if page.Offset > 0 {
+		if page.Limit > 0 {
+			links = append(links, pageLink(r, max(page.Offset-page.Limit, 0), page.Limit, "prev"))
+		} else {
+			links = append(links, pageLink(r, 0, page.Offset, "prev"))
+		}
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+func pageLink(r *http.Request, offset int, limit int, rel string) string {
+	q := r.URL.Query()
+	q.Set("offset", strconv.Itoa(offset))
+	q.Set("limit", strconv.Itoa(limit))
+	u := url.URL{Path: r.URL.Path, RawQuery: q.Encode()}
+	return fmt.Sprintf("<%s>; rel=\"%s\"", u.String(), rel)
+}
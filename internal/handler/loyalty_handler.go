@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"encoding/json"
+	"hot-coffee/help"
+	"hot-coffee/internal/service"
+	"hot-coffee/models"
+	"log/slog"
+	"net/http"
+)
+
+type LoyaltyHandler struct {
+	LoyaltyService *service.LoyaltyService
+}
+
+func NewLoyaltyHandler(service *service.LoyaltyService) *LoyaltyHandler {
+	return &LoyaltyHandler{LoyaltyService: service}
+}
+
+func (h *LoyaltyHandler) GetProgram(w http.ResponseWriter, r *http.Request) {
+	program, err := h.LoyaltyService.GetProgram()
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to fetch loyalty program")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(program)
+}
+
+func (h *LoyaltyHandler) UpdateProgram(w http.ResponseWriter, r *http.Request) {
+	var program models.LoyaltyProgram
+	if err := json.NewDecoder(r.Body).Decode(&program); err != nil {
+		slog.Warn("Invalid loyalty program JSON", "error", err)
+		help.WriteError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	program, err := h.LoyaltyService.UpdateProgram(program)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to update loyalty program")
+		return
+	}
+
+	slog.Info("Loyalty program updated", "rewards", len(program.Rewards))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(program)
+}
+
+func (h *LoyaltyHandler) GetAccount(w http.ResponseWriter, r *http.Request) {
+	customerID := r.PathValue("id")
+	account, err := h.LoyaltyService.GetAccount(customerID)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to fetch loyalty account")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(account)
+}
@@ -18,75 +18,123 @@ func NewMenuHandler(service *service.MenuService) *MenuHandler {
 }
 
 func (h *MenuHandler) GetAllMenuItems(w http.ResponseWriter, r *http.Request) {
-	items, err := h.MenuService.GetAllMenuItems()
+	q := r.URL.Query()
+	opts, err := parseListOptions(q)
 	if err != nil {
-		slog.Error("Failed to fetch menu items", "error", err)
-		help.WriteError(w, http.StatusInternalServerError, "Failed to fetch menu items")
+		help.WriteServiceError(w, err, "Failed to fetch menu items")
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(items)
+	filter := service.MenuFilter{
+		Category:     q.Get("category"),
+		IngredientID: q.Get("ingredient_id"),
+		Query:        q.Get("q"),
+	}
+	page, err := h.MenuService.ListMenuItems(filter, opts)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to fetch menu items")
+		return
+	}
+	writePage(w, r, page, opts.Fields)
 }
 
 func (h *MenuHandler) AddNewMenuItem(w http.ResponseWriter, r *http.Request) {
 	var item models.MenuItem
-	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
-		slog.Warn("Invalid menu item JSON", "error", err)
-		help.WriteError(w, http.StatusBadRequest, "Invalid request payload")
+	if !decodeBody(w, r, &item) {
 		return
 	}
-	if err := h.MenuService.AddNewMenuItem(item); err != nil {
-		slog.Error("Failed to add menu item", "error", err)
-		help.WriteError(w, http.StatusInternalServerError, "Failed to add menu item")
+	item, err := h.MenuService.AddNewMenuItem(item)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to add menu item")
 		return
 	}
 	slog.Info("Menu item added", "productID", item.ID)
-	w.WriteHeader(http.StatusCreated)
+	setETag(w, item.Version)
+	help.WriteCreated(w, "/menu", item.ID, item)
 }
 
-func (h *MenuHandler) GetMenuItem(w http.ResponseWriter, r *http.Request, id string) {
+func (h *MenuHandler) GetMenuItem(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
 	item, err := h.MenuService.GetMenuItem(id)
 	if err != nil {
-		slog.Warn("Menu item not found", "productID", id)
-		help.WriteError(w, http.StatusNotFound, "Menu item not found")
+		help.WriteServiceError(w, err, "Failed to fetch menu item")
+		return
+	}
+	if notModified(w, r, item.Version) {
 		return
 	}
+	setETag(w, item.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(item)
 }
 
-func (h *MenuHandler) UpdateMenuItem(w http.ResponseWriter, r *http.Request, id string) {
+func (h *MenuHandler) UpdateMenuItem(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	version, ok := ifMatch(w, r, h.menuVersion(id))
+	if !ok {
+		return
+	}
 	var updatedItem models.MenuItem
-	if err := json.NewDecoder(r.Body).Decode(&updatedItem); err != nil {
-		slog.Warn("Invalid JSON for menu item update", "error", err)
-		help.WriteError(w, http.StatusBadRequest, "Invalid request payload")
+	if !decodeBody(w, r, &updatedItem) {
 		return
 	}
 
 	updatedItem.ID = id
-	if err := h.MenuService.UpdateMenuItem(updatedItem); err != nil {
-		slog.Error("Failed to update menu item", "productID", id, "error", err)
-		help.WriteError(w, http.StatusInternalServerError, "Failed to update menu item")
+	if version != 0 {
+		updatedItem.Version = version
+	}
+	item, err := h.MenuService.UpdateMenuItem(updatedItem)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to update menu item")
 		return
 	}
 
 	slog.Info("Menu item updated", "productID", id)
-	w.WriteHeader(http.StatusOK)
+	setETag(w, item.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
 }
 
-func (h *MenuHandler) DeleteMenuItem(w http.ResponseWriter, r *http.Request, id string) {
-	if id == "" {
-		slog.Warn("Missing menu item ID in path")
-		help.WriteError(w, http.StatusBadRequest, "Missing menu item ID")
+func (h *MenuHandler) PatchMenuItem(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	version, ok := ifMatch(w, r, h.menuVersion(id))
+	if !ok {
 		return
 	}
+	patch, ok := readMergePatch(w, r)
+	if !ok {
+		return
+	}
+	item, err := h.MenuService.PatchMenuItem(id, patch, version)
+	if err != nil {
+		writePatchError(w, err, "Failed to update menu item")
+		return
+	}
+
+	slog.Info("Menu item patched", "productID", id)
+	setETag(w, item.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
 
-	if err := h.MenuService.DeleteMenuItem(id); err != nil {
-		slog.Error("Failed to delete menu item", "productID", id, "error", err)
-		help.WriteError(w, http.StatusInternalServerError, "Failed to delete menu item")
+func (h *MenuHandler) DeleteMenuItem(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	version, ok := ifMatch(w, r, h.menuVersion(id))
+	if !ok {
+		return
+	}
+	if err := h.MenuService.DeleteMenuItem(id, version); err != nil {
+		help.WriteServiceError(w, err, "Failed to delete menu item")
 		return
 	}
 
 	slog.Info("Menu item deleted", "productID", id)
 	w.WriteHeader(http.StatusOK)
 }
+
+// menuVersion looks up the stored version for ifMatch.
+func (h *MenuHandler) menuVersion(id string) func() (int, error) {
+	return func() (int, error) {
+		item, err := h.MenuService.GetMenuItem(id)
+		return item.Version, err
+	}
+}
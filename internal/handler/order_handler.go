@@ -2,9 +2,11 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"hot-coffee/help"
 	"hot-coffee/internal/service"
 	"hot-coffee/models"
+	"io"
 	"log/slog"
 	"net/http"
 )
@@ -18,81 +20,156 @@ func NewOrderHandler(service *service.OrderService) *OrderHandler {
 }
 
 func (h *OrderHandler) GetAllOrders(w http.ResponseWriter, r *http.Request) {
-	orders, err := h.OrderService.GetAllOrders()
+	q := r.URL.Query()
+	opts, err := parseListOptions(q)
 	if err != nil {
-		slog.Error("Failed to fetch orders", "error", err)
-		help.WriteError(w, http.StatusInternalServerError, "Failed to fetch errors")
+		help.WriteServiceError(w, err, "Failed to fetch orders")
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(orders)
+	filter := service.OrderFilter{
+		Status:     q.Get("status"),
+		CustomerID: q.Get("customer_id"),
+		Customer:   q.Get("customer"),
+		ProductID:  q.Get("product_id"),
+		From:       q.Get("from"),
+		To:         q.Get("to"),
+	}
+	page, err := h.OrderService.ListOrders(filter, opts)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to fetch orders")
+		return
+	}
+	writePage(w, r, page, opts.Fields)
 }
 
 func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	var order models.Order
-	if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
-		slog.Warn("Invalid order JSON", "error", err)
-		help.WriteError(w, http.StatusBadRequest, "Invalid request payload")
+	if !decodeBody(w, r, &order) {
 		return
 	}
-	if err := h.OrderService.CreateOrder(order); err != nil {
-		slog.Error("Failed to create order", "error", err)
-		help.WriteError(w, http.StatusInternalServerError, "Failed to create order")
+	order, err := h.OrderService.CreateOrder(order)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to create order")
 		return
 	}
-	slog.Info("Order created", "orderID", order.ID)
-	w.WriteHeader(http.StatusCreated)
+	slog.Info("Order created", "orderID", order.ID, "ticket", order.Ticket)
+	setETag(w, order.Version)
+	help.WriteCreated(w, "/orders", order.ID, order)
 }
 
-func (h *OrderHandler) GetOrderByID(w http.ResponseWriter, r *http.Request, id string) {
+func (h *OrderHandler) GetOrderByID(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
 	order, err := h.OrderService.GetOrderByID(id)
 	if err != nil {
-		slog.Warn("Order not found", "orderID", id)
-		help.WriteError(w, http.StatusNotFound, "Order not found")
+		help.WriteServiceError(w, err, "Failed to fetch order")
+		return
+	}
+	if notModified(w, r, order.Version) {
 		return
 	}
+	setETag(w, order.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(order)
 }
 
-func (h *OrderHandler) UpdateOrder(w http.ResponseWriter, r *http.Request, id string) {
+func (h *OrderHandler) UpdateOrder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	version, ok := ifMatch(w, r, h.orderVersion(id))
+	if !ok {
+		return
+	}
 	var order models.Order
-	if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
-		help.WriteError(w, http.StatusBadRequest, "Invalid JSON")
+	if !decodeBody(w, r, &order) {
 		return
 	}
 	order.ID = id
+	if version != 0 {
+		order.Version = version
+	}
 
-	if err := h.OrderService.UpdateOrder(order); err != nil {
-		help.WriteError(w, http.StatusInternalServerError, "Failed to update order")
+	order, err := h.OrderService.UpdateOrder(order)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to update order")
 		return
 	}
 
 	slog.Info("Order updated", "orderID", id)
-	w.WriteHeader(http.StatusOK)
+	setETag(w, order.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *OrderHandler) PatchOrder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	version, ok := ifMatch(w, r, h.orderVersion(id))
+	if !ok {
+		return
+	}
+	patch, ok := readMergePatch(w, r)
+	if !ok {
+		return
+	}
+	order, err := h.OrderService.PatchOrder(id, patch, version)
+	if err != nil {
+		writePatchError(w, err, "Failed to update order")
+		return
+	}
+
+	slog.Info("Order patched", "orderID", id)
+	setETag(w, order.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
 }
 
-func (h *OrderHandler) DeleteOrder(w http.ResponseWriter, r *http.Request, id string) {
-	if id == "" {
-		slog.Warn("Missing order ID in path")
-		help.WriteError(w, http.StatusBadRequest, "Missing order ID")
+func (h *OrderHandler) DeleteOrder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	version, ok := ifMatch(w, r, h.orderVersion(id))
+	if !ok {
 		return
 	}
-	if err := h.OrderService.DeleteOrder(id); err != nil {
-		slog.Error("Failed to delete order", "orderID", id, "error", err)
-		help.WriteError(w, http.StatusInternalServerError, "Failed to delete order")
+	if err := h.OrderService.DeleteOrder(id, version); err != nil {
+		help.WriteServiceError(w, err, "Failed to delete order")
 		return
 	}
 	slog.Info("Order deleted", "orderID", id)
 	w.WriteHeader(http.StatusOK)
 }
 
-func (h *OrderHandler) CloseOrder(w http.ResponseWriter, r *http.Request, id string) {
-	if err := h.OrderService.CloseOrder(id); err != nil {
-		slog.Error("Failed to close order", "orderID", id, "error", err)
-		help.WriteError(w, http.StatusInternalServerError, "Failed to close order")
+func (h *OrderHandler) MarkReady(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var req models.ReadyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		slog.Warn("Invalid ready JSON", "error", err)
+		help.WriteError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	order, err := h.OrderService.MarkReady(id, req.StaffID)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to mark order ready")
+		return
+	}
+	slog.Info("Order ready", "orderID", id, "staffID", req.StaffID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *OrderHandler) CloseOrder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	order, err := h.OrderService.CloseOrder(id)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to close order")
 		return
 	}
 	slog.Info("Order closed", "orderID", id)
-	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+// orderVersion looks up the stored version for ifMatch.
+func (h *OrderHandler) orderVersion(id string) func() (int, error) {
+	return func() (int, error) {
+		order, err := h.OrderService.GetOrderByID(id)
+		return order.Version, err
+	}
 }
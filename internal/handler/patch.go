@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"errors"
+	"hot-coffee/help"
+	"hot-coffee/internal/service"
+	"hot-coffee/internal/validation"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+)
+
+const mergePatchType = "application/merge-patch+json"
+
+// readMergePatch returns the request body, answering 415 unless it is sent as
+// a JSON merge patch.
+func readMergePatch(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != mergePatchType {
+		w.Header().Set("Accept-Patch", mergePatchType)
+		help.WriteError(w, http.StatusUnsupportedMediaType, "Content-Type must be "+mergePatchType)
+		return nil, false
+	}
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		help.WriteError(w, http.StatusBadRequest, "Failed to read request body")
+		return nil, false
+	}
+	return patch, true
+}
+
+// writePatchError answers 400 for patches that cannot be applied and falls
+// back to the usual service error mapping for everything else.
+func writePatchError(w http.ResponseWriter, err error, fallback string) {
+	if !errors.Is(err, service.ErrInvalidPatch) {
+		help.WriteServiceError(w, err, fallback)
+		return
+	}
+	slog.Warn("Invalid merge patch", "error", err)
+	var errs validation.Errors
+	if errors.As(err, &errs) {
+		help.WriteErrorDetails(w, http.StatusBadRequest, help.CodeInvalidPayload, "Invalid merge patch", errs)
+		return
+	}
+	help.WriteError(w, http.StatusBadRequest, err.Error())
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"encoding/json"
+	"hot-coffee/help"
+	"hot-coffee/internal/gateway"
+	"hot-coffee/internal/service"
+	"hot-coffee/models"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+)
+
+type PaymentHandler struct {
+	PaymentService *service.PaymentService
+}
+
+func NewPaymentHandler(service *service.PaymentService) *PaymentHandler {
+	return &PaymentHandler{PaymentService: service}
+}
+
+func (h *PaymentHandler) AddPayment(w http.ResponseWriter, r *http.Request) {
+	orderID := r.PathValue("id")
+	var payment models.Payment
+	if err := json.NewDecoder(r.Body).Decode(&payment); err != nil {
+		slog.Warn("Invalid payment JSON", "error", err)
+		help.WriteError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	summary, err := h.PaymentService.AddPayment(orderID, payment)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to record payment")
+		return
+	}
+
+	payment = summary.Payments[len(summary.Payments)-1]
+	slog.Info("Payment recorded", "orderID", orderID, "paymentID", payment.ID, "tender", payment.Tender, "balanceDue", summary.BalanceDue)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/orders/"+url.PathEscape(orderID)+"/payments/"+url.PathEscape(payment.ID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(summary)
+}
+
+func (h *PaymentHandler) GetOrderPayments(w http.ResponseWriter, r *http.Request) {
+	orderID := r.PathValue("id")
+	summary, err := h.PaymentService.GetOrderPayments(orderID)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to fetch order")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
+func (h *PaymentHandler) GetOrderPayment(w http.ResponseWriter, r *http.Request) {
+	payment, err := h.PaymentService.GetOrderPayment(r.PathValue("id"), r.PathValue("pid"))
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to fetch payment")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payment)
+}
+
+func (h *PaymentHandler) GatewayCallback(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.Warn("Failed to read gateway callback", "error", err)
+		help.WriteError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	callback, err := h.PaymentService.HandleGatewayCallback(body, r.Header.Get(gateway.SignatureHeader))
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to apply gateway callback")
+		return
+	}
+
+	slog.Info("Gateway callback processed", "eventID", callback.EventID, "transactionID", callback.TransactionID, "status", callback.Status)
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"hot-coffee/help"
+	"hot-coffee/internal/service"
+	"hot-coffee/models"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+)
+
+type RefundHandler struct {
+	RefundService *service.RefundService
+}
+
+func NewRefundHandler(service *service.RefundService) *RefundHandler {
+	return &RefundHandler{RefundService: service}
+}
+
+func (h *RefundHandler) VoidOrder(w http.ResponseWriter, r *http.Request) {
+	orderID := r.PathValue("id")
+	var req models.VoidRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		slog.Warn("Invalid void JSON", "error", err)
+		help.WriteError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	order, err := h.RefundService.VoidOrder(orderID, req)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to void order")
+		return
+	}
+
+	slog.Info("Order voided", "orderID", orderID, "reason", req.Reason)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *RefundHandler) RefundOrder(w http.ResponseWriter, r *http.Request) {
+	orderID := r.PathValue("id")
+	var req models.RefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.Warn("Invalid refund JSON", "error", err)
+		help.WriteError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	refund, err := h.RefundService.RefundOrder(orderID, req)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to refund order")
+		return
+	}
+
+	slog.Info("Order refunded", "orderID", orderID, "refundID", refund.ID, "amount", refund.Amount)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/orders/"+url.PathEscape(orderID)+"/refunds/"+url.PathEscape(refund.ID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(refund)
+}
+
+func (h *RefundHandler) GetOrderRefunds(w http.ResponseWriter, r *http.Request) {
+	orderID := r.PathValue("id")
+	refunds, err := h.RefundService.GetOrderRefunds(orderID)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to fetch order")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(refunds)
+}
+
+func (h *RefundHandler) GetOrderRefund(w http.ResponseWriter, r *http.Request) {
+	refund, err := h.RefundService.GetOrderRefund(r.PathValue("id"), r.PathValue("rid"))
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to fetch refund")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(refund)
+}
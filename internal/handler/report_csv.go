@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"encoding/csv"
+	"hot-coffee/help"
+	"hot-coffee/models"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+var (
+	totalSalesCSVHeader          = []string{"total_sales"}
+	popularItemsCSVHeader        = []string{"product_id", "name", "category", "count", "revenue"}
+	tendersCSVHeader             = []string{"tender", "count", "amount", "tips", "change_given", "refunds"}
+	zReportCSVHeader             = []string{"section", "key", "count", "amount", "tips", "change_given", "refunds"}
+	salesCSVHeader               = []string{"bucket", "name", "order_count", "items_sold", "gross", "discounts", "refunds", "net"}
+	inventoryUsageCSVHeader      = []string{"ingredient_id", "name", "unit", "theoretical_usage", "received", "wasted", "adjusted", "opening_count", "closing_count", "actual_usage", "variance", "variance_percent", "flagged"}
+	forecastCSVHeader            = []string{"date", "weekday", "product_id", "name", "expected", "prep", "peak_hour"}
+	forecastIngredientsCSVHeader = []string{"ingredient_id", "name", "unit", "required", "par_level", "on_hand", "suggested_reorder"}
+	operationsCSVHeader          = []string{"section", "key", "value"}
+	journalCSVHeader             = []string{"date", "entry_id", "account_code", "account_name", "description", "debit", "credit"}
+	ordersExportCSVHeader        = []string{"order_id", "created_at", "status", "customer_id", "customer_name", "product_id", "name", "category", "quantity", "unit_price", "line_total", "order_subtotal", "order_discounts", "order_tax", "order_total"}
+)
+
+func formatMoney(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func formatQuantity(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatOptional(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return formatQuantity(*v)
+}
+
+func tenderCSVRow(t models.TenderReport) []string {
+	return []string{t.Tender, strconv.Itoa(t.Count), formatMoney(t.Amount), formatMoney(t.Tips), formatMoney(t.ChangeGiven), formatMoney(t.Refunds)}
+}
+
+func popularItemsCSV(items []models.PopularItemReport) [][]string {
+	rows := [][]string{}
+	for _, item := range items {
+		rows = append(rows, []string{item.ProductID, item.Name, item.Category, strconv.Itoa(item.Count), formatMoney(item.Revenue)})
+	}
+	return rows
+}
+
+func paymentsCSV(report models.PaymentsReport) [][]string {
+	rows := [][]string{}
+	count := 0
+	for _, t := range report.Tenders {
+		rows = append(rows, tenderCSVRow(t))
+		count += t.Count
+	}
+	return append(rows, []string{"total", strconv.Itoa(count), formatMoney(report.TotalAmount), formatMoney(report.TotalTips), "", formatMoney(report.TotalRefunds)})
+}
+
+// zReportCSV flattens the report into one table: summary figures first, then
+// one row per tender and one per cash session.
+func zReportCSV(report models.ZReport) [][]string {
+	rows := [][]string{
+		{"summary", "order_count", strconv.Itoa(report.OrderCount), "", "", "", ""},
+		{"summary", "gross_sales", "", formatMoney(report.GrossSales), "", "", ""},
+		{"summary", "discounts", "", formatMoney(report.Discounts), "", "", ""},
+		{"summary", "taxes", "", formatMoney(report.Taxes), "", "", ""},
+		{"summary", "refunds", "", formatMoney(report.Refunds), "", "", ""},
+		{"summary", "net_sales", "", formatMoney(report.NetSales), "", "", ""},
+	}
+	for _, t := range report.Tenders {
+		rows = append(rows, append([]string{"tender"}, tenderCSVRow(t)...))
+	}
+	for _, session := range report.Sessions {
+		rows = append(rows, []string{"session", session.SessionID, "", formatMoney(session.Expected), "", "", formatMoney(session.CashRefunds)})
+		rows = append(rows, []string{"session_over_short", session.SessionID, "", formatMoney(session.OverShort), "", "", ""})
+	}
+	return rows
+}
+
+func salesBucketCSVRow(b models.SalesBucket) []string {
+	return []string{b.Bucket, b.Name, strconv.Itoa(b.OrderCount), strconv.Itoa(b.ItemsSold), formatMoney(b.Gross), formatMoney(b.Discounts), formatMoney(b.Refunds), formatMoney(b.Net)}
+}
+
+func salesCSV(report models.SalesReport) [][]string {
+	rows := [][]string{}
+	for _, b := range report.Buckets {
+		rows = append(rows, salesBucketCSVRow(b))
+	}
+	totals := report.Totals
+	totals.Bucket = "total"
+	return append(rows, salesBucketCSVRow(totals))
+}
+
+func inventoryUsageCSV(report models.InventoryUsageReport) [][]string {
+	rows := [][]string{}
+	for _, u := range report.Ingredients {
+		rows = append(rows, []string{
+			u.IngredientID, u.Name, u.Unit,
+			formatQuantity(u.Theoretical), formatQuantity(u.Received), formatQuantity(u.Wasted), formatQuantity(u.Adjusted),
+			formatOptional(u.OpeningCount), formatOptional(u.ClosingCount), formatOptional(u.ActualUsage),
+			formatOptional(u.Variance), formatOptional(u.VariancePercent), strconv.FormatBool(u.Flagged),
+		})
+	}
+	return rows
+}
+
+func forecastCSV(report models.ForecastReport) [][]string {
+	rows := [][]string{}
+	for _, day := range report.Days {
+		for _, item := range day.Items {
+			rows = append(rows, []string{day.Date, day.Weekday, item.ProductID, item.Name, formatQuantity(item.Expected), strconv.Itoa(item.Prep), strconv.Itoa(item.PeakHour)})
+		}
+	}
+	return rows
+}
+
+func forecastIngredientsCSVRows(report models.ForecastReport) [][]string {
+	rows := [][]string{}
+	for _, ing := range report.Ingredients {
+		rows = append(rows, []string{ing.IngredientID, ing.Name, ing.Unit, formatQuantity(ing.Required), formatQuantity(ing.ParLevel), formatQuantity(ing.OnHand), formatQuantity(ing.SuggestedReorder)})
+	}
+	return rows
+}
+
+func orderExportCSVRow(line models.OrderExportLine) []string {
+	return []string{
+		line.OrderID, line.CreatedAt, line.Status, line.CustomerID, line.CustomerName,
+		line.ProductID, line.Name, line.Category, strconv.Itoa(line.Quantity),
+		formatMoney(line.UnitPrice), formatMoney(line.LineTotal), formatMoney(line.Subtotal),
+		formatMoney(line.Discounts), formatMoney(line.Tax), formatMoney(line.Total),
+	}
+}
+
+func durationCSVRows(section string, d models.DurationStats) [][]string {
+	return [][]string{
+		{section, "count", strconv.Itoa(d.Count)},
+		{section, "average_seconds", formatQuantity(d.AverageSeconds)},
+		{section, "p50_seconds", formatQuantity(d.P50Seconds)},
+		{section, "p90_seconds", formatQuantity(d.P90Seconds)},
+		{section, "p95_seconds", formatQuantity(d.P95Seconds)},
+		{section, "max_seconds", formatQuantity(d.MaxSeconds)},
+	}
+}
+
+// operationsCSV flattens the report into section/key/value rows.
+func operationsCSV(report models.OperationsReport) [][]string {
+	rows := [][]string{
+		{"summary", "order_count", strconv.Itoa(report.OrderCount)},
+		{"summary", "item_count", strconv.Itoa(report.ItemCount)},
+		{"summary", "average_order_value", formatMoney(report.AverageOrderValue)},
+		{"summary", "items_per_order", formatQuantity(report.ItemsPerOrder)},
+		{"summary", "open_hours", formatQuantity(report.OpenHours)},
+		{"summary", "orders_per_hour", formatQuantity(report.OrdersPerHour)},
+	}
+	rows = append(rows, durationCSVRows("time_to_ready", report.TimeToReady)...)
+	rows = append(rows, durationCSVRows("time_to_close", report.TimeToClose)...)
+	for _, h := range report.Hourly {
+		rows = append(rows, []string{"hourly_orders", h.Hour, strconv.Itoa(h.Orders)})
+	}
+	for _, m := range report.ByStaff {
+		section := "staff:" + m.StaffID
+		rows = append(rows, []string{section, "order_count", strconv.Itoa(m.OrderCount)})
+		rows = append(rows, []string{section, "average_order_value", formatMoney(m.AverageOrderValue)})
+		rows = append(rows, []string{section, "time_to_ready_p50_seconds", formatQuantity(m.TimeToReady.P50Seconds)})
+		rows = append(rows, []string{section, "time_to_close_p50_seconds", formatQuantity(m.TimeToClose.P50Seconds)})
+	}
+	return rows
+}
+
+func journalCSVRow(line models.JournalLine) []string {
+	return []string{line.Date, line.EntryID, line.Account.Code, line.Account.Name, line.Description, formatMoney(line.Debit), formatMoney(line.Credit)}
+}
+
+// writeCSV sends a whole report as CSV. The status line is already out by
+// the time a write fails, so the error can only be logged.
+func writeCSV(w http.ResponseWriter, filename string, header []string, rows [][]string) {
+	if err := help.WriteCSV(w, filename, header, rows); err != nil {
+		slog.Error("Failed to write CSV", "file", filename, "error", err)
+	}
+}
+
+// csvStream writes the header lazily so a request that fails validation can
+// still get a JSON error instead of a half-written CSV body.
+type csvStream struct {
+	w        http.ResponseWriter
+	cw       *csv.Writer
+	filename string
+	header   []string
+	started  bool
+	rows     int
+}
+
+func newCSVStream(w http.ResponseWriter, filename string, header []string) *csvStream {
+	return &csvStream{w: w, cw: csv.NewWriter(w), filename: filename, header: header}
+}
+
+func (s *csvStream) start() error {
+	s.started = true
+	help.SetCSVHeaders(s.w, s.filename)
+	return s.cw.Write(s.header)
+}
+
+func (s *csvStream) write(row []string) error {
+	if !s.started {
+		if err := s.start(); err != nil {
+			return err
+		}
+	}
+	if err := s.cw.Write(row); err != nil {
+		return err
+	}
+	s.rows++
+	if s.rows%500 == 0 {
+		s.cw.Flush()
+		if flusher, ok := s.w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+	return s.cw.Error()
+}
+
+// finish completes the export and reports whether it succeeded.
+func (s *csvStream) finish(w http.ResponseWriter, err error, name string) bool {
+	if err != nil {
+		if !s.started {
+			help.WriteServiceError(w, err, "Failed to export "+name)
+			return false
+		}
+		slog.Error("Export interrupted", "export", name, "rows", s.rows, "error", err)
+		return false
+	}
+	if !s.started {
+		if err := s.start(); err != nil {
+			slog.Error("Export interrupted", "export", name, "rows", s.rows, "error", err)
+			return false
+		}
+	}
+	s.cw.Flush()
+	if err := s.cw.Error(); err != nil {
+		slog.Error("Export interrupted", "export", name, "rows", s.rows, "error", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"encoding/csv"
+	"hot-coffee/internal/service"
+	"hot-coffee/models"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeOrders []models.Order
+
+func (f fakeOrders) LoadOrders() ([]models.Order, error) { return f, nil }
+
+type fakeMenu []models.MenuItem
+
+func (f fakeMenu) LoadMenuItems() ([]models.MenuItem, error) { return f, nil }
+
+func newTestReportHandler(orders ...models.Order) *ReportHandler {
+	menu := fakeMenu{{ID: "latte", Name: "Latte", Category: "coffee", Price: 5}}
+	return NewReportHandler(service.NewReportService(fakeOrders(orders), menu, nil, nil, nil, nil, nil, nil, nil, time.UTC))
+}
+
+func TestExportOrdersCSV(t *testing.T) {
+	h := newTestReportHandler(
+		models.Order{ID: "o2", Status: "closed", CreatedAt: "2026-03-02T09:00:00Z", CustomerName: `Jo "JJ" Smith, Jr.`,
+			Items: []models.OrderItem{{ProductID: "cake", Quantity: 1, Name: "Cake\nof the day", Price: 4, Category: "bakery"}}},
+		models.Order{ID: "o1", Status: "closed", CreatedAt: "2026-03-01T09:00:00Z", CustomerName: "Alice", Tax: 1,
+			Items: []models.OrderItem{{ProductID: "latte", Quantity: 2}}},
+		models.Order{ID: "o3", Status: "open", CreatedAt: "2026-03-01T10:00:00Z", CustomerName: "Bob",
+			Items: []models.OrderItem{{ProductID: "latte", Quantity: 1}}},
+	)
+	w := httptest.NewRecorder()
+	h.ExportOrders(w, httptest.NewRequest(http.MethodGet, "/exports/orders?from=2026-03-01", nil))
+
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("status %d, Content-Type %q, want 200 and text/csv", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Body.String(), `"Jo ""JJ"" Smith, Jr."`) {
+		t.Errorf("body = %q, want the customer name quoted and escaped", w.Body.String())
+	}
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("reading the export: %v", err)
+	}
+	want := [][]string{
+		ordersExportCSVHeader,
+		{"o1", "2026-03-01T09:00:00Z", "closed", "", "Alice", "latte", "Latte", "coffee", "2", "5.00", "10.00", "10.00", "0.00", "1.00", "11.00"},
+		{"o2", "2026-03-02T09:00:00Z", "closed", "", `Jo "JJ" Smith, Jr.`, "cake", "Cake\nof the day", "bakery", "1", "4.00", "4.00", "4.00", "0.00", "0.00", "4.00"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("export = %q, want %q", records, want)
+	}
+}
+
+func TestExportOrdersInvalidPeriod(t *testing.T) {
+	h := newTestReportHandler()
+	w := httptest.NewRecorder()
+	h.ExportOrders(w, httptest.NewRequest(http.MethodGet, "/exports/orders?from=yesterday", nil))
+	if w.Code != http.StatusBadRequest || strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+		t.Errorf("status %d, Content-Type %q, want a 400 JSON error", w.Code, w.Header().Get("Content-Type"))
+	}
+}
+
+func TestExportOrdersEmpty(t *testing.T) {
+	h := newTestReportHandler()
+	w := httptest.NewRecorder()
+	h.ExportOrders(w, httptest.NewRequest(http.MethodGet, "/exports/orders", nil))
+	if got, want := w.Body.String(), strings.Join(ordersExportCSVHeader, ",")+"\n"; got != want {
+		t.Errorf("body = %q, want only the header %q", got, want)
+	}
+}
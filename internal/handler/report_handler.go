@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"hot-coffee/help"
 	"hot-coffee/internal/service"
+	"hot-coffee/models"
 	"log/slog"
 	"net/http"
+	"strconv"
 )
 
 type ReportHandler struct {
@@ -19,24 +21,191 @@ func NewReportHandler(service *service.ReportService) *ReportHandler {
 func (h *ReportHandler) GetTotalSales(w http.ResponseWriter, r *http.Request) {
 	total, err := h.service.GetTotalSales()
 	if err != nil {
-		slog.Error("Failed to calculate total sales", "error", err)
-		help.WriteError(w, http.StatusInternalServerError, "Failed to get total sales")
+		help.WriteServiceError(w, err, "Failed to get total sales")
 		return
 	}
 	slog.Info("Total sales calculated", "amount", total)
+	if help.WantsCSV(r) {
+		writeCSV(w, "total-sales.csv", totalSalesCSVHeader, [][]string{{formatMoney(total)}})
+		return
+	}
 	response := map[string]float64{"total_sales": total}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
 func (h *ReportHandler) GetPopularItems(w http.ResponseWriter, r *http.Request) {
-	items, err := h.service.GetPopularItems()
+	q := r.URL.Query()
+	limit := 0
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			help.WriteError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+	items, err := h.service.GetPopularItems(q.Get("from"), q.Get("to"), q.Get("sort"), q.Get("category"), limit)
 	if err != nil {
-		slog.Error("Failed to generate popular items report", "error", err)
-		help.WriteError(w, http.StatusInternalServerError, "Failed to get popular items")
+		help.WriteServiceError(w, err, "Failed to generate popular items report")
 		return
 	}
 	slog.Info("Popular items report generated", "count", len(items))
+	if help.WantsCSV(r) {
+		writeCSV(w, "popular-items.csv", popularItemsCSVHeader, popularItemsCSV(items))
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(items)
 }
+
+func (h *ReportHandler) GetPaymentsBreakdown(w http.ResponseWriter, r *http.Request) {
+	report, err := h.service.GetPaymentsBreakdown()
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to get payments breakdown")
+		return
+	}
+	slog.Info("Payments report generated", "tenders", len(report.Tenders))
+	if help.WantsCSV(r) {
+		writeCSV(w, "payments.csv", tendersCSVHeader, paymentsCSV(report))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *ReportHandler) GetZReport(w http.ResponseWriter, r *http.Request) {
+	report, err := h.service.GetZReport(r.URL.Query().Get("date"))
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to generate Z report")
+		return
+	}
+	slog.Info("Z report generated", "date", report.Date, "sessions", len(report.Sessions))
+	if help.WantsCSV(r) {
+		writeCSV(w, "z-report-"+report.Date+".csv", zReportCSVHeader, zReportCSV(report))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *ReportHandler) GetSalesReport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	report, err := h.service.GetSalesReport(q.Get("from"), q.Get("to"), q.Get("group_by"))
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to generate sales report")
+		return
+	}
+	slog.Info("Sales report generated", "groupBy", report.GroupBy, "buckets", len(report.Buckets))
+	if help.WantsCSV(r) {
+		writeCSV(w, "sales.csv", salesCSVHeader, salesCSV(report))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *ReportHandler) GetInventoryUsage(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	tolerance := 0.0
+	if v := q.Get("tolerance"); v != "" {
+		t, err := strconv.ParseFloat(v, 64)
+		if err != nil || t < 0 {
+			help.WriteError(w, http.StatusBadRequest, "tolerance must be a non-negative number")
+			return
+		}
+		tolerance = t
+	}
+	report, err := h.service.GetInventoryUsage(q.Get("from"), q.Get("to"), tolerance)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to generate inventory usage report")
+		return
+	}
+	slog.Info("Inventory usage report generated", "ingredients", len(report.Ingredients))
+	if help.WantsCSV(r) {
+		writeCSV(w, "inventory-usage.csv", inventoryUsageCSVHeader, inventoryUsageCSV(report))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *ReportHandler) GetForecast(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var days, weeks int
+	var err error
+	safety := service.DefaultSafetyStock
+	if v := q.Get("days"); v != "" {
+		if days, err = strconv.Atoi(v); err != nil {
+			help.WriteError(w, http.StatusBadRequest, "days must be an integer")
+			return
+		}
+	}
+	if v := q.Get("weeks"); v != "" {
+		if weeks, err = strconv.Atoi(v); err != nil {
+			help.WriteError(w, http.StatusBadRequest, "weeks must be an integer")
+			return
+		}
+	}
+	if v := q.Get("safety"); v != "" {
+		if safety, err = strconv.ParseFloat(v, 64); err != nil {
+			help.WriteError(w, http.StatusBadRequest, "safety must be a number")
+			return
+		}
+	}
+
+	report, err := h.service.GetForecast(days, weeks, safety)
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to generate forecast")
+		return
+	}
+	slog.Info("Forecast generated", "days", len(report.Days), "ingredients", len(report.Ingredients))
+	if help.WantsCSV(r) {
+		if q.Get("section") == "ingredients" {
+			writeCSV(w, "forecast-ingredients.csv", forecastIngredientsCSVHeader, forecastIngredientsCSVRows(report))
+			return
+		}
+		writeCSV(w, "forecast.csv", forecastCSVHeader, forecastCSV(report))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *ReportHandler) ExportOrders(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	export := newCSVStream(w, "orders.csv", ordersExportCSVHeader)
+	err := h.service.ExportOrders(q.Get("from"), q.Get("to"), func(line models.OrderExportLine) error {
+		return export.write(orderExportCSVRow(line))
+	})
+	if export.finish(w, err, "orders") {
+		slog.Info("Orders exported", "rows", export.rows)
+	}
+}
+
+func (h *ReportHandler) ExportJournal(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	export := newCSVStream(w, "journal.csv", journalCSVHeader)
+	err := h.service.ExportJournal(q.Get("from"), q.Get("to"), func(line models.JournalLine) error {
+		return export.write(journalCSVRow(line))
+	})
+	if export.finish(w, err, "journal") {
+		slog.Info("Journal exported", "rows", export.rows)
+	}
+}
+
+func (h *ReportHandler) GetOperationsReport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	report, err := h.service.GetOperationsReport(q.Get("from"), q.Get("to"), q.Get("staff_id"))
+	if err != nil {
+		help.WriteServiceError(w, err, "Failed to generate operations report")
+		return
+	}
+	slog.Info("Operations report generated", "orders", report.OrderCount, "staffID", report.StaffID)
+	if help.WantsCSV(r) {
+		writeCSV(w, "operations.csv", operationsCSVHeader, operationsCSV(report))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"errors"
+	"hot-coffee/help"
+	"hot-coffee/internal/validation"
+	"log/slog"
+	"net/http"
+)
+
+// decodeBody decodes a strict JSON payload and answers 400 when it cannot.
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	err := validation.Decode(r.Body, v)
+	if err == nil {
+		return true
+	}
+	slog.Warn("Invalid request payload", "path", r.URL.Path, "error", err)
+	var errs validation.Errors
+	if errors.As(err, &errs) {
+		help.WriteErrorDetails(w, http.StatusBadRequest, help.CodeInvalidPayload, "Invalid request payload", errs)
+		return false
+	}
+	help.WriteError(w, http.StatusBadRequest, "Invalid request payload")
+	return false
+}
@@ -0,0 +1,39 @@
+package idgen
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	KindSequential = "sequential"
+	KindULID       = "ulid"
+	KindUUIDv7     = "uuidv7"
+)
+
+// Generator produces IDs that sort by creation time. exists reports IDs that
+// are already taken so a generator can skip them.
+type Generator interface {
+	NewID(now time.Time, exists func(id string) bool) string
+}
+
+// Resumer is implemented by generators that need the last ID they produced
+// to carry on after a restart without repeating themselves.
+type Resumer interface {
+	Resume(last string)
+}
+
+// New returns the generator configured by kind. Sequential IDs roll over at
+// midnight in location.
+func New(kind string, location *time.Location) (Generator, error) {
+	switch kind {
+	case KindSequential, "":
+		return NewSequential(location), nil
+	case KindULID:
+		return NewULID(), nil
+	case KindUUIDv7:
+		return NewUUIDv7(), nil
+	default:
+		return nil, fmt.Errorf("unknown ID generator %q: expected %s, %s or %s", kind, KindSequential, KindULID, KindUUIDv7)
+	}
+}
@@ -0,0 +1,138 @@
+package idgen
+
+import (
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+)
+
+func none(string) bool { return false }
+
+func TestSequential(t *testing.T) {
+	g := NewSequential(time.UTC)
+	day := time.Date(2026, 10, 18, 23, 59, 0, 0, time.UTC)
+
+	taken := map[string]bool{"20261018-0002": true}
+	got := []string{
+		g.NewID(day, func(id string) bool { return taken[id] }),
+		g.NewID(day, func(id string) bool { return taken[id] }),
+		g.NewID(day.Add(time.Minute), none),
+	}
+	want := []string{"20261018-0001", "20261018-0003", "20261019-0001"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("NewID() #%d = %s, want %s", i+1, got[i], want[i])
+		}
+	}
+}
+
+func TestSequentialLocation(t *testing.T) {
+	zone := time.FixedZone("UTC+5", 5*60*60)
+	g := NewSequential(zone)
+	if id := g.NewID(time.Date(2026, 10, 18, 20, 0, 0, 0, time.UTC), none); id != "20261019-0001" {
+		t.Errorf("NewID() = %s, want the next day in the shop's time zone", id)
+	}
+}
+
+func TestSequentialResume(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		last []string
+		want string
+	}{
+		{name: "same day", last: []string{"20261018-0041"}, want: "20261018-0042"},
+		{name: "earlier day", last: []string{"20261017-0041"}, want: "20261018-0001"},
+		{name: "never moves back", last: []string{"20261018-0041", "20261018-0007"}, want: "20261018-0042"},
+		{name: "not sequential", last: []string{"01JA2B3C4D5E6F7G8H9J0KMNPQ", "x-y"}, want: "20261018-0001"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewSequential(time.UTC)
+			for _, last := range tt.last {
+				g.Resume(last)
+			}
+			// The freed IDs below the mark do not exist any more, yet must
+			// not be handed out again.
+			if id := g.NewID(now, none); id != tt.want {
+				t.Errorf("NewID() = %s, want %s", id, tt.want)
+			}
+		})
+	}
+}
+
+var ulidPattern = regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{26}$`)
+
+func TestULID(t *testing.T) {
+	g := NewULID()
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	ids := make([]string, 100)
+	for i := range ids {
+		// Half the IDs share a millisecond to exercise the increment path.
+		ids[i] = g.NewID(now.Add(time.Duration(i/2)*time.Millisecond), none)
+		if !ulidPattern.MatchString(ids[i]) {
+			t.Fatalf("NewID() = %q, want 26 Crockford base32 digits", ids[i])
+		}
+	}
+	if !sort.StringsAreSorted(ids) {
+		t.Errorf("IDs do not sort in creation order: %v", ids)
+	}
+	if ids[0][:10] != "01M57E43G0" {
+		t.Errorf("timestamp prefix = %s, want 01M57E43G0", ids[0][:10])
+	}
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if seen[id] {
+			t.Fatalf("duplicate ID %s", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestULIDSkipsTakenIDs(t *testing.T) {
+	g := NewULID()
+	now := time.Now()
+	first := g.NewID(now, none)
+	calls := 0
+	second := g.NewID(now, func(id string) bool {
+		calls++
+		return calls == 1
+	})
+	if calls != 2 || second <= first {
+		t.Errorf("NewID() = %s after %d checks, want a later ID after 2", second, calls)
+	}
+}
+
+var uuidv7Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestUUIDv7(t *testing.T) {
+	g := NewUUIDv7()
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	ids := make([]string, 50)
+	for i := range ids {
+		ids[i] = g.NewID(now.Add(time.Duration(i)*time.Millisecond), none)
+		if !uuidv7Pattern.MatchString(ids[i]) {
+			t.Fatalf("NewID() = %q, want a version 7, variant 10 UUID", ids[i])
+		}
+	}
+	if !sort.StringsAreSorted(ids) {
+		t.Errorf("IDs do not sort in creation order: %v", ids)
+	}
+	if ids[0][:13] != "01a14ee2-0e00" {
+		t.Errorf("timestamp prefix = %s, want 01a14ee2-0e00", ids[0][:13])
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, kind := range []string{"", KindSequential, KindULID, KindUUIDv7} {
+		if _, err := New(kind, time.UTC); err != nil {
+			t.Errorf("New(%q) error = %v", kind, err)
+		}
+	}
+	if _, err := New("snowflake", time.UTC); err == nil {
+		t.Error("New(\"snowflake\") error = nil, want an error")
+	}
+}
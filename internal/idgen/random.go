@@ -0,0 +1,95 @@
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULID produces 26-character IDs: a 48-bit millisecond timestamp followed by
+// 80 random bits, Crockford base32 encoded. IDs created in the same
+// millisecond increment the random part so they still sort in order.
+type ULID struct {
+	mu      sync.Mutex
+	lastMs  uint64
+	lastRnd [10]byte
+}
+
+func NewULID() *ULID {
+	return &ULID{}
+}
+
+func (g *ULID) NewID(now time.Time, exists func(id string) bool) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for {
+		ms := uint64(now.UnixMilli())
+		if ms <= g.lastMs {
+			ms = g.lastMs
+			increment(g.lastRnd[:])
+		} else {
+			rand.Read(g.lastRnd[:])
+		}
+		g.lastMs = ms
+
+		var raw [16]byte
+		raw[0], raw[1], raw[2] = byte(ms>>40), byte(ms>>32), byte(ms>>24)
+		raw[3], raw[4], raw[5] = byte(ms>>16), byte(ms>>8), byte(ms)
+		copy(raw[6:], g.lastRnd[:])
+		if id := encodeCrockford(raw); !exists(id) {
+			return id
+		}
+	}
+}
+
+func increment(b []byte) {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return
+		}
+	}
+}
+
+// encodeCrockford writes 128 bits as 26 base32 digits, most significant first.
+func encodeCrockford(raw [16]byte) string {
+	hi := binary.BigEndian.Uint64(raw[:8])
+	lo := binary.BigEndian.Uint64(raw[8:])
+	out := make([]byte, 26)
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out)
+}
+
+// UUIDv7 produces RFC 9562 version 7 UUIDs, which lead with a millisecond
+// timestamp and therefore sort by creation time.
+type UUIDv7 struct{}
+
+func NewUUIDv7() *UUIDv7 {
+	return &UUIDv7{}
+}
+
+func (g *UUIDv7) NewID(now time.Time, exists func(id string) bool) string {
+	for {
+		var raw [16]byte
+		rand.Read(raw[6:])
+		ms := uint64(now.UnixMilli())
+		raw[0], raw[1], raw[2] = byte(ms>>40), byte(ms>>32), byte(ms>>24)
+		raw[3], raw[4], raw[5] = byte(ms>>16), byte(ms>>8), byte(ms)
+		raw[6] = raw[6]&0x0f | 0x70
+		raw[8] = raw[8]&0x3f | 0x80
+
+		h := hex.EncodeToString(raw[:])
+		if id := h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]; !exists(id) {
+			return id
+		}
+	}
+}
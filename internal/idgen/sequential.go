@@ -0,0 +1,59 @@
+package idgen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sequential numbers IDs per business day, e.g. 20261018-0042. Only exists
+// guards against collisions, so a caller that deletes records must persist the
+// last ID handed out and pass it to Resume after a restart, or the numbers of
+// deleted records come round again.
+type Sequential struct {
+	location *time.Location
+	mu       sync.Mutex
+	day      string
+	next     int
+}
+
+func NewSequential(location *time.Location) *Sequential {
+	return &Sequential{location: location}
+}
+
+// Resume continues numbering after last, a previously generated ID. IDs that
+// do not come from a Sequential are ignored.
+func (g *Sequential) Resume(last string) {
+	day, seq, ok := strings.Cut(last, "-")
+	n, err := strconv.Atoi(seq)
+	if !ok || err != nil || len(day) != len("20060102") {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if day > g.day || day == g.day && n >= g.next {
+		g.day = day
+		g.next = n + 1
+	}
+}
+
+func (g *Sequential) NewID(now time.Time, exists func(id string) bool) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	day := now.In(g.location).Format("20060102")
+	if day != g.day {
+		g.day = day
+		g.next = 1
+	}
+	for {
+		id := fmt.Sprintf("%s-%04d", day, g.next)
+		g.next++
+		if !exists(id) {
+			return id
+		}
+	}
+}
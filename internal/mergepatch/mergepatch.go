@@ -0,0 +1,38 @@
+// Package mergepatch applies JSON Merge Patch documents (RFC 7396).
+package mergepatch
+
+import "encoding/json"
+
+// Apply merges patch into doc. Members set to null in the patch are removed,
+// objects are merged recursively and any other value, arrays included,
+// replaces the original.
+func Apply(doc []byte, patch []byte) ([]byte, error) {
+	var target any
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	var p any
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, err
+	}
+	return json.Marshal(merge(target, p))
+}
+
+func merge(target any, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for name, value := range p {
+		if value == nil {
+			delete(t, name)
+			continue
+		}
+		t[name] = merge(t[name], value)
+	}
+	return t
+}
@@ -0,0 +1,162 @@
+package router
+
+import (
+	"hot-coffee/help"
+	"hot-coffee/internal/handler"
+	"net/http"
+)
+
+type Handlers struct {
+	Inventory   *handler.InventoryHandler
+	Menu        *handler.MenuHandler
+	Order       *handler.OrderHandler
+	Payment     *handler.PaymentHandler
+	Refund      *handler.RefundHandler
+	CashSession *handler.CashSessionHandler
+	Customer    *handler.CustomerHandler
+	Loyalty     *handler.LoyaltyHandler
+	GiftCard    *handler.GiftCardHandler
+	Report      *handler.ReportHandler
+	Aggregate   *handler.AggregateHandler
+	Accounting  *handler.AccountingHandler
+	Idempotency *handler.IdempotencyHandler
+
+	// RequireIfMatch rejects writes to inventory, menu items and orders that
+	// do not send If-Match.
+	RequireIfMatch bool
+}
+
+func New(h Handlers) http.Handler {
+	mux := http.NewServeMux()
+
+	conditional := func(next http.HandlerFunc) http.HandlerFunc {
+		if h.RequireIfMatch {
+			return handler.RequireIfMatch(next)
+		}
+		return next
+	}
+
+	mux.HandleFunc("GET /inventory", h.Inventory.GetAllInventoryItems)
+	mux.HandleFunc("POST /inventory", h.Inventory.AddNewInventoryItem)
+	mux.HandleFunc("GET /inventory/{id}", h.Inventory.GetInventoryItem)
+	mux.HandleFunc("PUT /inventory/{id}", conditional(h.Inventory.UpdateInventoryItem))
+	mux.HandleFunc("PATCH /inventory/{id}", conditional(h.Inventory.PatchInventoryItem))
+	mux.HandleFunc("DELETE /inventory/{id}", conditional(h.Inventory.DeleteInventoryItem))
+	mux.HandleFunc("GET /inventory/{id}/movements", h.Inventory.GetMovements)
+	mux.HandleFunc("POST /inventory/{id}/movements", h.Inventory.AddMovement)
+	mux.HandleFunc("POST /inventory/{id}/count", h.Inventory.RecordCount)
+
+	mux.HandleFunc("GET /menu", h.Menu.GetAllMenuItems)
+	mux.HandleFunc("POST /menu", h.Menu.AddNewMenuItem)
+	mux.HandleFunc("GET /menu/{id}", h.Menu.GetMenuItem)
+	mux.HandleFunc("PUT /menu/{id}", conditional(h.Menu.UpdateMenuItem))
+	mux.HandleFunc("PATCH /menu/{id}", conditional(h.Menu.PatchMenuItem))
+	mux.HandleFunc("DELETE /menu/{id}", conditional(h.Menu.DeleteMenuItem))
+
+	mux.HandleFunc("GET /orders", h.Order.GetAllOrders)
+	mux.HandleFunc("POST /orders", h.Idempotency.Wrap(h.Order.CreateOrder))
+	mux.HandleFunc("GET /orders/{id}", h.Order.GetOrderByID)
+	mux.HandleFunc("PUT /orders/{id}", conditional(h.Order.UpdateOrder))
+	mux.HandleFunc("PATCH /orders/{id}", conditional(h.Order.PatchOrder))
+	mux.HandleFunc("DELETE /orders/{id}", conditional(h.Order.DeleteOrder))
+	mux.HandleFunc("POST /orders/{id}/ready", h.Order.MarkReady)
+	mux.HandleFunc("POST /orders/{id}/close", h.Order.CloseOrder)
+	mux.HandleFunc("POST /orders/{id}/void", h.Refund.VoidOrder)
+	mux.HandleFunc("GET /orders/{id}/refunds", h.Refund.GetOrderRefunds)
+	mux.HandleFunc("POST /orders/{id}/refunds", h.Idempotency.Wrap(h.Refund.RefundOrder))
+	mux.HandleFunc("GET /orders/{id}/refunds/{rid}", h.Refund.GetOrderRefund)
+	mux.HandleFunc("GET /orders/{id}/payments", h.Payment.GetOrderPayments)
+	mux.HandleFunc("POST /orders/{id}/payments", h.Idempotency.Wrap(h.Payment.AddPayment))
+	mux.HandleFunc("GET /orders/{id}/payments/{pid}", h.Payment.GetOrderPayment)
+
+	mux.HandleFunc("GET /customers", h.Customer.GetAllCustomers)
+	mux.HandleFunc("POST /customers", h.Customer.AddCustomer)
+	mux.HandleFunc("GET /customers/{id}", h.Customer.GetCustomer)
+	mux.HandleFunc("PUT /customers/{id}", h.Customer.UpdateCustomer)
+	mux.HandleFunc("DELETE /customers/{id}", h.Customer.DeleteCustomer)
+	mux.HandleFunc("GET /customers/{id}/orders", h.Customer.GetCustomerHistory)
+	mux.HandleFunc("GET /customers/{id}/loyalty", h.Loyalty.GetAccount)
+
+	mux.HandleFunc("GET /loyalty/program", h.Loyalty.GetProgram)
+	mux.HandleFunc("PUT /loyalty/program", h.Loyalty.UpdateProgram)
+
+	mux.HandleFunc("POST /giftcards", h.Idempotency.Wrap(h.GiftCard.IssueGiftCard))
+	mux.HandleFunc("GET /giftcards/{code}", h.GiftCard.GetGiftCard)
+	mux.HandleFunc("POST /giftcards/{code}/reload", h.Idempotency.Wrap(h.GiftCard.ReloadGiftCard))
+	mux.HandleFunc("GET /giftcards/{code}/transactions", h.GiftCard.GetTransactions)
+
+	mux.HandleFunc("GET /cash-sessions", h.CashSession.GetAllSessions)
+	mux.HandleFunc("POST /cash-sessions", h.CashSession.OpenSession)
+	mux.HandleFunc("GET /cash-sessions/{id}", h.CashSession.GetSession)
+	mux.HandleFunc("POST /cash-sessions/{id}/events", h.CashSession.AddEvent)
+	mux.HandleFunc("POST /cash-sessions/{id}/close", h.CashSession.CloseSession)
+
+	mux.HandleFunc("GET /accounting/accounts", h.Accounting.GetChart)
+	mux.HandleFunc("PUT /accounting/accounts", h.Accounting.UpdateChart)
+
+	mux.HandleFunc("POST /payments/gateway-callback", h.Payment.GatewayCallback)
+
+	mux.HandleFunc("GET /reports/total-sales", h.Report.GetTotalSales)
+	mux.HandleFunc("GET /reports/popular-items", h.Report.GetPopularItems)
+	mux.HandleFunc("GET /reports/payments", h.Report.GetPaymentsBreakdown)
+	mux.HandleFunc("GET /reports/z-report", h.Report.GetZReport)
+	mux.HandleFunc("GET /reports/sales", h.Report.GetSalesReport)
+	mux.HandleFunc("GET /reports/inventory-usage", h.Report.GetInventoryUsage)
+	mux.HandleFunc("GET /reports/forecast", h.Report.GetForecast)
+	mux.HandleFunc("GET /reports/operations", h.Report.GetOperationsReport)
+	mux.HandleFunc("GET /reports/aggregates", h.Aggregate.GetStatus)
+	mux.HandleFunc("POST /reports/aggregates/rebuild", h.Aggregate.Rebuild)
+
+	mux.HandleFunc("GET /exports/orders", h.Report.ExportOrders)
+	mux.HandleFunc("GET /exports/journal", h.Report.ExportJournal)
+
+	return withJSONErrors(mux)
+}
+
+// withJSONErrors answers requests that match no route with the same JSON error
+// body as the handlers. The mux still decides between 404 and 405 and sets the
+// Allow header; only its plain-text body is replaced.
+func withJSONErrors(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, pattern := mux.Handler(r)
+		if pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &statusRecorder{header: w.Header()}
+		h.ServeHTTP(rec, r)
+		switch rec.status {
+		case http.StatusMethodNotAllowed:
+			help.WriteError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		case http.StatusNotFound:
+			help.WriteError(w, http.StatusNotFound, "Not Found")
+		default:
+			mux.ServeHTTP(w, r)
+		}
+	})
+}
+
+// statusRecorder captures the status and headers of the mux's fallback
+// handler and discards its body.
+type statusRecorder struct {
+	header http.Header
+	status int
+}
+
+func (r *statusRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return len(b), nil
+}
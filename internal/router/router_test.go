@@ -0,0 +1,204 @@
+package router
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/gateway"
+	"hot-coffee/internal/handler"
+	"hot-coffee/internal/idgen"
+	"hot-coffee/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestRouter wires every handler over JSON files in a temporary
+// directory, the way cmd/main.go does.
+func newTestRouter(t *testing.T) http.Handler {
+	t.Helper()
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	inventoryRepo := dal.NewJSONInventoryManager(path("inventory.json"), path("inventory_movements.json"))
+	menuRepo := dal.NewJSONMenuManager(path("menu_items.json"))
+	orderRepo := dal.NewJSONOrderManager(path("orders.json"), path("order_counters.json"), idgen.NewSequential(time.UTC), time.UTC)
+	paymentRepo := dal.NewJSONPaymentManager(path("payments.json"))
+	refundRepo := dal.NewJSONRefundManager(path("refunds.json"))
+	sessionRepo := dal.NewJSONCashSessionManager(path("cash_sessions.json"))
+	customerRepo := dal.NewJSONCustomerManager(path("customers.json"))
+	giftCardRepo := dal.NewJSONGiftCardManager(path("gift_cards.json"), path("gift_card_transactions.json"))
+	accountingRepo := dal.NewJSONAccountingManager(path("chart_of_accounts.json"))
+	aggregateRepo := dal.NewJSONAggregateManager(path("report_aggregates.json"))
+	idempotencyRepo := dal.NewJSONIdempotencyManager(path("idempotency_keys.json"))
+	loyaltyRepo := dal.NewJSONLoyaltyManager(path("loyalty_program.json"), path("loyalty_ledger.json"))
+	gw := gateway.NewMockProcessor()
+
+	aggregates := service.NewAggregateService(aggregateRepo, orderRepo, menuRepo, refundRepo, time.UTC)
+	loyalty := service.NewLoyaltyService(loyaltyRepo, customerRepo)
+	giftCards := service.NewGiftCardService(giftCardRepo, sessionRepo, gw)
+	return New(Handlers{
+		Inventory:   handler.NewInventoryHandler(service.NewInventoryService(inventoryRepo, menuRepo)),
+		Menu:        handler.NewMenuHandler(service.NewMenuService(menuRepo, orderRepo, inventoryRepo)),
+		Order:       handler.NewOrderHandler(service.NewOrderService(orderRepo, menuRepo, inventoryRepo, paymentRepo, customerRepo, loyalty, aggregates, 0, time.UTC)),
+		Payment:     handler.NewPaymentHandler(service.NewPaymentService(paymentRepo, orderRepo, menuRepo, refundRepo, sessionRepo, loyalty, giftCards, aggregates, gw, "secret")),
+		Refund:      handler.NewRefundHandler(service.NewRefundService(refundRepo, orderRepo, menuRepo, inventoryRepo, paymentRepo, sessionRepo, loyalty, giftCards, aggregates, gw)),
+		CashSession: handler.NewCashSessionHandler(service.NewCashSessionService(sessionRepo, paymentRepo, refundRepo, giftCardRepo)),
+		Customer:    handler.NewCustomerHandler(service.NewCustomerService(customerRepo, orderRepo, menuRepo, refundRepo)),
+		Loyalty:     handler.NewLoyaltyHandler(loyalty),
+		GiftCard:    handler.NewGiftCardHandler(giftCards),
+		Report:      handler.NewReportHandler(service.NewReportService(orderRepo, menuRepo, paymentRepo, refundRepo, sessionRepo, inventoryRepo, aggregateRepo, accountingRepo, giftCardRepo, time.UTC)),
+		Aggregate:   handler.NewAggregateHandler(aggregates),
+		Accounting:  handler.NewAccountingHandler(service.NewAccountingService(accountingRepo)),
+		Idempotency: handler.NewIdempotencyHandler(service.NewIdempotencyService(idempotencyRepo, time.Hour)),
+	})
+}
+
+func serve(h http.Handler, method string, target string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+// mustServe sends a request that sets up a test and fails unless it gets
+// status want.
+func mustServe(t *testing.T, h http.Handler, method string, target string, body string, want int) *httptest.ResponseRecorder {
+	t.Helper()
+	w := serve(h, method, target, body)
+	if w.Code != want {
+		t.Fatalf("%s %s = %d %s, want %d", method, target, w.Code, w.Body, want)
+	}
+	return w
+}
+
+func TestRoutes(t *testing.T) {
+	h := newTestRouter(t)
+	mustServe(t, h, http.MethodPost, "/menu", `{"product_id":"tea","name":"Tea","price":3}`, http.StatusCreated)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		wantStatus int
+		wantAllow  string
+		wantID     string
+	}{
+		{name: "path parameter", method: http.MethodGet, target: "/menu/tea", wantStatus: http.StatusOK, wantID: "tea"},
+		{name: "HEAD on a GET route", method: http.MethodHead, target: "/menu/tea", wantStatus: http.StatusOK},
+		{name: "unknown item", method: http.MethodGet, target: "/menu/cake", wantStatus: http.StatusNotFound},
+		{name: "unknown path", method: http.MethodGet, target: "/coffee", wantStatus: http.StatusNotFound},
+		{name: "trailing slash", method: http.MethodGet, target: "/menu/", wantStatus: http.StatusNotFound},
+		{name: "too deep", method: http.MethodGet, target: "/menu/tea/ingredients", wantStatus: http.StatusNotFound},
+		{name: "unknown nested item", method: http.MethodGet, target: "/orders/o-1/payments/o-1-p1", wantStatus: http.StatusNotFound},
+		{name: "wrong method on a collection", method: http.MethodDelete, target: "/menu", wantStatus: http.StatusMethodNotAllowed, wantAllow: "GET, HEAD, POST"},
+		{name: "wrong method on an item", method: http.MethodPost, target: "/menu/tea", wantStatus: http.StatusMethodNotAllowed, wantAllow: "DELETE, GET, HEAD, PATCH, PUT"},
+		{name: "wrong method on a report", method: http.MethodPost, target: "/reports/sales", wantStatus: http.StatusMethodNotAllowed, wantAllow: "GET, HEAD"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h, tt.method, tt.target, "")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("%s %s = %d, want %d", tt.method, tt.target, w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+			if tt.method == http.MethodHead {
+				return
+			}
+			if got := w.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			var body struct {
+				ID    string `json:"product_id"`
+				Error string `json:"error"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatalf("body is not JSON: %v", err)
+			}
+			if tt.wantStatus >= 400 && body.Error == "" {
+				t.Error("error body has no message")
+			}
+			if body.ID != tt.wantID {
+				t.Errorf("product_id = %q, want %q", body.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestCreateWithTakenID(t *testing.T) {
+	tests := []struct {
+		collection string
+		body       string
+		wantSlug   string
+	}{
+		{collection: "/inventory", body: `{"ingredient_id":"oat-milk","name":"Oat Milk","unit":"l","quantity":2}`, wantSlug: "/inventory/oat-milk-2"},
+		{collection: "/menu", body: `{"product_id":"flat-white","name":"Flat White","price":4}`, wantSlug: "/menu/flat-white-2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.collection, func(t *testing.T) {
+			h := newTestRouter(t)
+			mustServe(t, h, http.MethodPost, tt.collection, tt.body, http.StatusCreated)
+			if w := serve(h, http.MethodPost, tt.collection, tt.body); w.Code != http.StatusConflict {
+				t.Errorf("second POST %s = %d %s, want 409", tt.collection, w.Code, w.Body)
+			}
+
+			// Without an ID the server picks the next free slug of the name.
+			var fields map[string]any
+			json.Unmarshal([]byte(tt.body), &fields)
+			delete(fields, "ingredient_id")
+			delete(fields, "product_id")
+			body, _ := json.Marshal(fields)
+			w := mustServe(t, h, http.MethodPost, tt.collection, string(body), http.StatusCreated)
+			if got := w.Header().Get("Location"); got != tt.wantSlug {
+				t.Errorf("Location = %q, want %q", got, tt.wantSlug)
+			}
+		})
+	}
+}
+
+func TestLocationHeaders(t *testing.T) {
+	h := newTestRouter(t)
+	locations := make(map[string]string)
+	steps := []struct {
+		name       string
+		target     string
+		body       string
+		wantPrefix string
+	}{
+		{name: "inventory", target: "/inventory", body: `{"ingredient_id":"milk","name":"Milk","unit":"l","quantity":10}`, wantPrefix: "/inventory/milk"},
+		{name: "movement", target: "/inventory/milk/movements", body: `{"type":"receive","quantity":2}`, wantPrefix: "/inventory/milk/movements"},
+		{name: "count", target: "/inventory/milk/count", body: `{"counted":11.5}`, wantPrefix: "/inventory/milk/movements"},
+		{name: "menu", target: "/menu", body: `{"name":"Latte","price":3.5,"ingredients":[{"ingredient_id":"milk","quantity":0.2}]}`, wantPrefix: "/menu/latte"},
+		{name: "customer", target: "/customers", body: `{"name":"Ann","email":"ann@example.com"}`, wantPrefix: "/customers/"},
+		{name: "session", target: "/cash-sessions", body: `{"opening_float":50}`, wantPrefix: "/cash-sessions/"},
+		{name: "order", target: "/orders", body: `{"customer_name":"Bob","items":[{"product_id":"latte","quantity":2}]}`, wantPrefix: "/orders/"},
+		{name: "payment", target: "{order}/payments", body: `{"tender":"cash","amount":7}`, wantPrefix: "{order}/payments/"},
+		{name: "refund", target: "{order}/refunds", body: `{"reason":"spilled"}`, wantPrefix: "{order}/refunds/"},
+		{name: "gift card", target: "/giftcards", body: `{"code":"gc 1","amount":10,"tender":"cash"}`, wantPrefix: "/giftcards/GC%201"},
+	}
+	for _, step := range steps {
+		resolve := func(s string) string { return strings.ReplaceAll(s, "{order}", locations["order"]) }
+		w := serve(h, http.MethodPost, resolve(step.target), step.body)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("%s: POST %s = %d %s, want 201", step.name, resolve(step.target), w.Code, w.Body)
+		}
+		location := w.Header().Get("Location")
+		if !strings.HasPrefix(location, resolve(step.wantPrefix)) || strings.HasSuffix(location, "/") {
+			t.Errorf("%s: Location = %q, want %s...", step.name, location, resolve(step.wantPrefix))
+			continue
+		}
+		locations[step.name] = location
+
+		// The Location must name the resource that was just created.
+		if got := serve(h, http.MethodGet, location, ""); got.Code != http.StatusOK {
+			t.Errorf("%s: GET %s = %d %s, want 200", step.name, location, got.Code, got.Body)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"fmt"
+	"hot-coffee/internal/dal"
+	"hot-coffee/models"
+	"strings"
+)
+
+var ErrInvalidAccounting = models.NewError(models.ErrInvalidInput, "invalid accounting configuration")
+
+type AccountingService struct {
+	AccountingRepo dal.AccountingManager
+}
+
+func NewAccountingService(accountingRepo dal.AccountingManager) *AccountingService {
+	return &AccountingService{AccountingRepo: accountingRepo}
+}
+
+func (s *AccountingService) GetChart() (models.ChartOfAccounts, error) {
+	return s.AccountingRepo.GetChart()
+}
+
+func (s *AccountingService) UpdateChart(chart models.ChartOfAccounts) (models.ChartOfAccounts, error) {
+	required := []struct {
+		name    string
+		account *models.Account
+	}{
+		{"revenue", &chart.Revenue},
+		{"discounts", &chart.Discounts},
+		{"refunds", &chart.Refunds},
+		{"sales_tax", &chart.SalesTax},
+		{"tips", &chart.Tips},
+		{"receivable", &chart.Receivable},
+		{"cost_of_goods", &chart.CostOfGoods},
+		{"inventory", &chart.Inventory},
+	}
+	for _, r := range required {
+		r.account.Code = strings.TrimSpace(r.account.Code)
+		if r.account.Code == "" {
+			return models.ChartOfAccounts{}, fmt.Errorf("%w: %s needs an account code", ErrInvalidAccounting, r.name)
+		}
+	}
+	for _, tender := range []string{models.TenderCash, models.TenderCard, models.TenderGiftCard} {
+		if strings.TrimSpace(chart.Tenders[tender].Code) == "" {
+			return models.ChartOfAccounts{}, fmt.Errorf("%w: tender '%s' needs an account code", ErrInvalidAccounting, tender)
+		}
+	}
+	for category, account := range chart.RevenueByCategory {
+		if strings.TrimSpace(account.Code) == "" {
+			return models.ChartOfAccounts{}, fmt.Errorf("%w: revenue for category '%s' needs an account code", ErrInvalidAccounting, category)
+		}
+	}
+
+	if err := s.AccountingRepo.UpdateChart(chart); err != nil {
+		return models.ChartOfAccounts{}, err
+	}
+	return chart, nil
+}
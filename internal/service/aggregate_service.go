@@ -0,0 +1,263 @@
+package service
+
+import (
+	"hot-coffee/internal/dal"
+	"hot-coffee/models"
+	"log/slog"
+	"time"
+)
+
+// AggregateService keeps running per-day and per-item sales totals so reports
+// do not have to rescan every order. Sales are counted on the order's creation
+// day and refunds on the refund's day, matching the raw report calculations.
+type AggregateService struct {
+	AggregateRepo dal.AggregateManager
+	OrderRepo     dal.OrderManager
+	MenuRepo      dal.MenuManager
+	RefundRepo    dal.RefundManager
+	location      *time.Location
+}
+
+func NewAggregateService(aggregateRepo dal.AggregateManager, orderRepo dal.OrderManager, menuRepo dal.MenuManager, refundRepo dal.RefundManager, location *time.Location) *AggregateService {
+	return &AggregateService{
+		AggregateRepo: aggregateRepo,
+		OrderRepo:     orderRepo,
+		MenuRepo:      menuRepo,
+		RefundRepo:    refundRepo,
+		location:      location,
+	}
+}
+
+// aggregatesVersion changes whenever the stored totals gain a field that
+// older files cannot have filled in.
+const aggregatesVersion = 2
+
+// EnsureFresh rebuilds the aggregates when none exist yet, when they were
+// built for a different time zone, since day boundaries would not match, or
+// when they predate aggregatesVersion.
+func (s *AggregateService) EnsureFresh() error {
+	agg, err := s.AggregateRepo.GetAggregates()
+	if err != nil {
+		return err
+	}
+	if agg.RebuiltAt != "" && agg.TimeZone == s.location.String() && agg.Version == aggregatesVersion {
+		return nil
+	}
+	slog.Info("Rebuilding report aggregates", "timeZone", s.location.String())
+	_, err = s.Rebuild()
+	return err
+}
+
+func (s *AggregateService) Rebuild() (models.AggregateStatus, error) {
+	orders, err := s.OrderRepo.GetAllOrders()
+	if err != nil {
+		return models.AggregateStatus{}, err
+	}
+	menuItems, err := s.MenuRepo.GetAllMenuItems()
+	if err != nil {
+		return models.AggregateStatus{}, err
+	}
+	refunds, err := s.RefundRepo.GetAllRefunds()
+	if err != nil {
+		return models.AggregateStatus{}, err
+	}
+
+	agg := models.ReportAggregates{
+		Version:   aggregatesVersion,
+		TimeZone:  s.location.String(),
+		RebuiltAt: time.Now().Format(time.RFC3339),
+		Days:      make(map[string]*models.DailyAggregate),
+		Orders:    make(map[string]string),
+		Refunds:   make(map[string]string),
+	}
+	for _, order := range orders {
+		if isSold(order.Status) {
+			s.applySale(&agg, order, menuItems, 1)
+		}
+	}
+	// Refunds of deleted orders left the totals along with their sale.
+	for _, refund := range fillRefundTax(refunds, orders, menuItems) {
+		if _, sold := agg.Orders[refund.OrderID]; sold {
+			s.applyRefund(&agg, refund, menuItems, 1)
+		}
+	}
+	if err := s.AggregateRepo.ReplaceAggregates(agg); err != nil {
+		return models.AggregateStatus{}, err
+	}
+	return aggregateStatus(agg), nil
+}
+
+func (s *AggregateService) GetStatus() (models.AggregateStatus, error) {
+	agg, err := s.AggregateRepo.GetAggregates()
+	if err != nil {
+		return models.AggregateStatus{}, err
+	}
+	return aggregateStatus(agg), nil
+}
+
+func (s *AggregateService) RecordSale(order models.Order, menuItems []models.MenuItem) error {
+	return s.AggregateRepo.UpdateAggregates(func(agg *models.ReportAggregates) error {
+		s.applySale(agg, order, menuItems, 1)
+		return nil
+	})
+}
+
+// RemoveSale takes an order back out of the totals together with any refunds
+// recorded against it.
+func (s *AggregateService) RemoveSale(order models.Order, menuItems []models.MenuItem) error {
+	refunds, err := s.RefundRepo.GetRefundsByOrder(order.ID)
+	if err != nil {
+		return err
+	}
+	refunds = fillRefundTax(refunds, []models.Order{order}, menuItems)
+	return s.AggregateRepo.UpdateAggregates(func(agg *models.ReportAggregates) error {
+		for _, refund := range refunds {
+			s.applyRefund(agg, refund, menuItems, -1)
+		}
+		s.applySale(agg, order, menuItems, -1)
+		return nil
+	})
+}
+
+func (s *AggregateService) RecordRefund(refund models.Refund, menuItems []models.MenuItem) error {
+	return s.AggregateRepo.UpdateAggregates(func(agg *models.ReportAggregates) error {
+		s.applyRefund(agg, refund, menuItems, 1)
+		return nil
+	})
+}
+
+// closeSale counts the order before close persists it, so a failed update
+// leaves the order open to be closed again instead of closed but missing from
+// the totals. A failed close takes the sale back out.
+func (s *AggregateService) closeSale(order models.Order, menuItems []models.MenuItem, close func(orderID string) error) error {
+	if err := s.RecordSale(order, menuItems); err != nil {
+		return err
+	}
+	if err := close(order.ID); err != nil {
+		if removeErr := s.RemoveSale(order, menuItems); removeErr != nil {
+			slog.Error("Failed to remove sale after close failure", "orderID", order.ID, "error", removeErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// applySale adds (sign 1) or removes (sign -1) an order. Orders are tracked by
+// ID so recording the same close twice does not double count.
+func (s *AggregateService) applySale(agg *models.ReportAggregates, order models.Order, menuItems []models.MenuItem, sign int) {
+	date, counted := agg.Orders[order.ID]
+	if sign > 0 && counted || sign < 0 && !counted {
+		return
+	}
+	if sign > 0 {
+		var ok bool
+		if date, ok = s.dayOf(order.CreatedAt); !ok {
+			return
+		}
+		agg.Orders[order.ID] = date
+	} else {
+		delete(agg.Orders, order.ID)
+	}
+
+	f := float64(sign)
+	day := aggregateDay(agg, date)
+	subtotal := orderSubtotal(order, menuItems)
+	discounts := orderDiscounts(order)
+	day.OrderCount += sign
+	day.Gross = roundMoney(day.Gross + f*subtotal)
+	day.Discounts = roundMoney(day.Discounts + f*discounts)
+	day.Taxes = roundMoney(day.Taxes + f*order.Tax)
+
+	seen := make(map[string]bool)
+	for _, item := range order.Items {
+		line := unitPrice(item, menuItems) * float64(item.Quantity)
+		a := aggregateItem(day, item.ProductID)
+		if a.Name == "" || item.Name != "" {
+			a.Name = itemName(item, menuItems)
+			a.Category = itemCategory(item, menuItems)
+		}
+		if !seen[item.ProductID] {
+			a.OrderCount += sign
+			seen[item.ProductID] = true
+		}
+		a.Quantity += sign * item.Quantity
+		a.Gross = roundMoney(a.Gross + f*line)
+		if subtotal > 0 {
+			a.Discounts = roundMoney(a.Discounts + f*discounts*line/subtotal)
+		}
+		day.ItemsSold += sign * item.Quantity
+	}
+}
+
+// applyRefund adds (sign 1) or removes (sign -1) a refund, tracked by ID like
+// orders in applySale.
+func (s *AggregateService) applyRefund(agg *models.ReportAggregates, refund models.Refund, menuItems []models.MenuItem, sign int) {
+	date, counted := agg.Refunds[refund.ID]
+	if sign > 0 && counted || sign < 0 && !counted {
+		return
+	}
+	if sign > 0 {
+		var ok bool
+		if date, ok = s.dayOf(refund.CreatedAt); !ok {
+			return
+		}
+		agg.Refunds[refund.ID] = date
+	} else {
+		delete(agg.Refunds, refund.ID)
+	}
+
+	f := float64(sign)
+	day := aggregateDay(agg, date)
+	day.Refunds = roundMoney(day.Refunds + f*refund.Amount)
+	day.RefundTaxes = roundMoney(day.RefundTaxes + f*refund.Tax)
+	for _, line := range refund.Lines {
+		a := aggregateItem(day, line.ProductID)
+		if a.Name == "" {
+			item := models.OrderItem{ProductID: line.ProductID}
+			a.Name = itemName(item, menuItems)
+			a.Category = itemCategory(item, menuItems)
+		}
+		a.RefundedQuantity += sign * line.Quantity
+		a.Refunds = roundMoney(a.Refunds + f*line.Amount)
+		a.RefundTaxes = roundMoney(a.RefundTaxes + f*(line.Amount-refundLineNet(refund, line)))
+	}
+}
+
+func (s *AggregateService) dayOf(timestamp string) (string, bool) {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return "", false
+	}
+	return t.In(s.location).Format(time.DateOnly), true
+}
+
+func aggregateDay(agg *models.ReportAggregates, date string) *models.DailyAggregate {
+	day, ok := agg.Days[date]
+	if !ok {
+		day = &models.DailyAggregate{Date: date, Items: make(map[string]*models.ItemAggregate)}
+		agg.Days[date] = day
+	}
+	if day.Items == nil {
+		day.Items = make(map[string]*models.ItemAggregate)
+	}
+	return day
+}
+
+func aggregateItem(day *models.DailyAggregate, productID string) *models.ItemAggregate {
+	item, ok := day.Items[productID]
+	if !ok {
+		item = &models.ItemAggregate{ProductID: productID}
+		day.Items[productID] = item
+	}
+	return item
+}
+
+func aggregateStatus(agg models.ReportAggregates) models.AggregateStatus {
+	return models.AggregateStatus{
+		TimeZone:  agg.TimeZone,
+		RebuiltAt: agg.RebuiltAt,
+		Days:      len(agg.Days),
+		Orders:    len(agg.Orders),
+		Refunds:   len(agg.Refunds),
+	}
+}
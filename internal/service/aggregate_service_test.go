@@ -0,0 +1,174 @@
+package service
+
+import (
+	"errors"
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/idgen"
+	"hot-coffee/models"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newTestAggregateService returns a service over empty JSON files in a
+// temporary directory together with a sold order of two lattes at 5.00 plus
+// 1.00 tax.
+func newTestAggregateService(t *testing.T) (*AggregateService, models.Order) {
+	t.Helper()
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	menuRepo := dal.NewJSONMenuManager(path("menu_items.json"))
+	if _, err := menuRepo.AddNewMenuItem(models.MenuItem{ID: "latte", Name: "Latte", Category: "coffee", Price: 5}); err != nil {
+		t.Fatalf("AddNewMenuItem() error = %v", err)
+	}
+	orderRepo := dal.NewJSONOrderManager(path("orders.json"), path("order_counters.json"), idgen.NewSequential(time.UTC), time.UTC)
+	order, err := orderRepo.CreateOrder(models.Order{
+		CustomerName: "Alice",
+		Items:        []models.OrderItem{{ProductID: "latte", Quantity: 2}},
+		Status:       "open",
+		Tax:          1,
+		CreatedAt:    time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	if err := orderRepo.CloseOrder(order.ID); err != nil {
+		t.Fatalf("CloseOrder() error = %v", err)
+	}
+	order, _ = orderRepo.GetOrderByID(order.ID)
+
+	s := NewAggregateService(
+		dal.NewJSONAggregateManager(path("aggregates.json")),
+		orderRepo,
+		menuRepo,
+		dal.NewJSONRefundManager(path("refunds.json")),
+		time.UTC,
+	)
+	return s, order
+}
+
+// addTestRefund stores and records a refund of one latte, tax included.
+func addTestRefund(t *testing.T, s *AggregateService, order models.Order) models.Refund {
+	t.Helper()
+	refund := models.Refund{
+		ID:        order.ID + "-r1",
+		OrderID:   order.ID,
+		Lines:     []models.RefundLine{{ProductID: "latte", Quantity: 1, Amount: 5.5}},
+		Amount:    5.5,
+		Tax:       0.5,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := s.RefundRepo.AddRefund(refund); err != nil {
+		t.Fatalf("AddRefund() error = %v", err)
+	}
+	if err := s.RecordRefund(refund, nil); err != nil {
+		t.Fatalf("RecordRefund() error = %v", err)
+	}
+	return refund
+}
+
+func aggregateDays(t *testing.T, s *AggregateService) map[string]*models.DailyAggregate {
+	t.Helper()
+	agg, err := s.AggregateRepo.GetAggregates()
+	if err != nil {
+		t.Fatalf("GetAggregates() error = %v", err)
+	}
+	return agg.Days
+}
+
+func TestRecordSaleCountsOnce(t *testing.T) {
+	s, order := newTestAggregateService(t)
+	menuItems, _ := s.MenuRepo.GetAllMenuItems()
+
+	for i := 0; i < 2; i++ {
+		if err := s.RecordSale(order, menuItems); err != nil {
+			t.Fatalf("RecordSale() error = %v", err)
+		}
+	}
+	for _, day := range aggregateDays(t, s) {
+		if day.OrderCount != 1 || day.ItemsSold != 2 || day.Gross != 10 || day.Taxes != 1 {
+			t.Errorf("day = %+v, want one order of two items, 10.00 gross and 1.00 tax", day)
+		}
+	}
+}
+
+func TestIncrementalAggregatesMatchRebuild(t *testing.T) {
+	s, order := newTestAggregateService(t)
+	menuItems, _ := s.MenuRepo.GetAllMenuItems()
+	if err := s.RecordSale(order, menuItems); err != nil {
+		t.Fatalf("RecordSale() error = %v", err)
+	}
+	addTestRefund(t, s, order)
+	incremental := aggregateDays(t, s)
+
+	if _, err := s.Rebuild(); err != nil {
+		t.Fatalf("Rebuild() error = %v", err)
+	}
+	if rebuilt := aggregateDays(t, s); !reflect.DeepEqual(incremental, rebuilt) {
+		t.Errorf("incremental days differ from rebuilt days")
+	}
+	for _, day := range incremental {
+		if day.Refunds != 5.5 || day.RefundTaxes != 0.5 {
+			t.Errorf("refunds = %.2f with %.2f tax, want 5.50 with 0.50", day.Refunds, day.RefundTaxes)
+		}
+	}
+}
+
+func TestRemoveSaleRemovesRefunds(t *testing.T) {
+	s, order := newTestAggregateService(t)
+	menuItems, _ := s.MenuRepo.GetAllMenuItems()
+	if err := s.RecordSale(order, menuItems); err != nil {
+		t.Fatalf("RecordSale() error = %v", err)
+	}
+	addTestRefund(t, s, order)
+
+	if err := s.RemoveSale(order, menuItems); err != nil {
+		t.Fatalf("RemoveSale() error = %v", err)
+	}
+	agg, _ := s.AggregateRepo.GetAggregates()
+	if len(agg.Orders) != 0 || len(agg.Refunds) != 0 {
+		t.Errorf("still tracking %d orders and %d refunds, want none", len(agg.Orders), len(agg.Refunds))
+	}
+	for _, day := range agg.Days {
+		if day.OrderCount != 0 || day.Gross != 0 || day.Refunds != 0 || day.RefundTaxes != 0 {
+			t.Errorf("day = %+v, want all totals back at zero", day)
+		}
+		if item := day.Items["latte"]; item.Quantity != 0 || item.RefundedQuantity != 0 || item.Refunds != 0 {
+			t.Errorf("item = %+v, want all totals back at zero", item)
+		}
+	}
+
+	// A rebuild after the order is gone must agree and skip its refund.
+	if err := s.OrderRepo.DeleteOrder(order.ID, order.Version); err != nil {
+		t.Fatalf("DeleteOrder() error = %v", err)
+	}
+	status, err := s.Rebuild()
+	if err != nil {
+		t.Fatalf("Rebuild() error = %v", err)
+	}
+	if status.Orders != 0 || status.Refunds != 0 {
+		t.Errorf("rebuilt status = %+v, want no orders or refunds", status)
+	}
+}
+
+func TestCloseSaleFailureLeavesTotalsUnchanged(t *testing.T) {
+	s, order := newTestAggregateService(t)
+	menuItems, _ := s.MenuRepo.GetAllMenuItems()
+	errClose := errors.New("disk full")
+
+	err := s.closeSale(order, menuItems, func(string) error { return errClose })
+	if !errors.Is(err, errClose) {
+		t.Fatalf("closeSale() error = %v, want %v", err, errClose)
+	}
+	agg, _ := s.AggregateRepo.GetAggregates()
+	if len(agg.Orders) != 0 {
+		t.Errorf("orders = %v, want the sale taken back out", agg.Orders)
+	}
+	for _, day := range agg.Days {
+		if day.OrderCount != 0 || day.Gross != 0 {
+			t.Errorf("day = %+v, want no sales", day)
+		}
+	}
+}
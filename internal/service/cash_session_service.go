@@ -0,0 +1,231 @@
+package service
+
+import (
+	"fmt"
+	"hot-coffee/internal/dal"
+	"hot-coffee/models"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrInvalidCashSession = models.NewError(models.ErrConflict, "invalid cash session operation")
+
+type CashSessionService struct {
+	SessionRepo  dal.CashSessionManager
+	PaymentRepo  dal.PaymentManager
+	RefundRepo   dal.RefundManager
+	GiftCardRepo dal.GiftCardManager
+
+	// mu serializes opening, changing and closing sessions, which each read
+	// a session and write it back whole.
+	mu sync.Mutex
+}
+
+func NewCashSessionService(sessionRepo dal.CashSessionManager, paymentRepo dal.PaymentManager, refundRepo dal.RefundManager, giftCardRepo dal.GiftCardManager) *CashSessionService {
+	return &CashSessionService{
+		SessionRepo:  sessionRepo,
+		PaymentRepo:  paymentRepo,
+		RefundRepo:   refundRepo,
+		GiftCardRepo: giftCardRepo,
+	}
+}
+
+func (s *CashSessionService) OpenSession(session models.CashSession) (models.CashSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, open, err := s.SessionRepo.GetOpenSession(); err != nil {
+		return models.CashSession{}, err
+	} else if open {
+		return models.CashSession{}, fmt.Errorf("%w: another cash session is already open", ErrInvalidCashSession)
+	}
+	if session.OpeningFloat < 0 {
+		return models.CashSession{}, fmt.Errorf("%w: opening float cannot be negative", ErrInvalidCashSession)
+	}
+
+	sessions, err := s.SessionRepo.GetAllSessions()
+	if err != nil {
+		return models.CashSession{}, err
+	}
+
+	now := time.Now()
+	session.ID = nextSessionID(sessions, now)
+	session.Status = "open"
+	session.OpenedAt = now.Format(time.RFC3339)
+	session.OpeningFloat = roundMoney(session.OpeningFloat)
+	session.Events = []models.CashEvent{}
+	session.ClosedAt = ""
+	session.CountedAmount = 0
+	session.ExpectedAmount = session.OpeningFloat
+	session.OverShort = 0
+	if err := s.SessionRepo.AddSession(session); err != nil {
+		return models.CashSession{}, err
+	}
+	return session, nil
+}
+
+// nextSessionID numbers sessions per day, one past the highest number used
+// that day, so IDs stay unique even if sessions are removed.
+func nextSessionID(sessions []models.CashSession, now time.Time) string {
+	prefix := fmt.Sprintf("cs-%s-", now.Format("20060102"))
+	last := 0
+	for _, session := range sessions {
+		suffix, ok := strings.CutPrefix(session.ID, prefix)
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(suffix); err == nil {
+			last = max(last, n)
+		}
+	}
+	return prefix + strconv.Itoa(last+1)
+}
+
+func (s *CashSessionService) AddEvent(id string, event models.CashEvent) (models.CashSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.SessionRepo.GetSession(id)
+	if err != nil {
+		return models.CashSession{}, err
+	}
+	if session.Status != "open" {
+		return models.CashSession{}, fmt.Errorf("%w: session '%s' is closed", ErrInvalidCashSession, id)
+	}
+	if event.Type != models.CashEventIn && event.Type != models.CashEventOut {
+		return models.CashSession{}, fmt.Errorf("%w: unknown event type '%s'", ErrInvalidCashSession, event.Type)
+	}
+	if event.Amount <= 0 {
+		return models.CashSession{}, fmt.Errorf("%w: amount must be positive", ErrInvalidCashSession)
+	}
+
+	event.Amount = roundMoney(event.Amount)
+	event.At = time.Now().Format(time.RFC3339)
+	session.Events = append(session.Events, event)
+	if err := s.SessionRepo.UpdateSession(session); err != nil {
+		return models.CashSession{}, err
+	}
+	return s.withExpected(session)
+}
+
+func (s *CashSessionService) CloseSession(id string, counted float64) (models.CashSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.SessionRepo.GetSession(id)
+	if err != nil {
+		return models.CashSession{}, err
+	}
+	if session.Status != "open" {
+		return models.CashSession{}, fmt.Errorf("%w: session '%s' is already closed", ErrInvalidCashSession, id)
+	}
+	if counted < 0 {
+		return models.CashSession{}, fmt.Errorf("%w: counted amount cannot be negative", ErrInvalidCashSession)
+	}
+
+	session, err = s.withExpected(session)
+	if err != nil {
+		return models.CashSession{}, err
+	}
+	session.Status = "closed"
+	session.ClosedAt = time.Now().Format(time.RFC3339)
+	session.CountedAmount = roundMoney(counted)
+	session.OverShort = roundMoney(session.CountedAmount - session.ExpectedAmount)
+	if err := s.SessionRepo.UpdateSession(session); err != nil {
+		return models.CashSession{}, err
+	}
+	return session, nil
+}
+
+func (s *CashSessionService) GetSession(id string) (models.CashSession, error) {
+	session, err := s.SessionRepo.GetSession(id)
+	if err != nil {
+		return models.CashSession{}, err
+	}
+	if session.Status != "open" {
+		return session, nil
+	}
+	return s.withExpected(session)
+}
+
+func (s *CashSessionService) GetAllSessions() ([]models.CashSession, error) {
+	return s.SessionRepo.GetAllSessions()
+}
+
+func (s *CashSessionService) withExpected(session models.CashSession) (models.CashSession, error) {
+	payments, err := s.PaymentRepo.GetAllPayments()
+	if err != nil {
+		return models.CashSession{}, err
+	}
+	refunds, err := s.RefundRepo.GetAllRefunds()
+	if err != nil {
+		return models.CashSession{}, err
+	}
+	giftCardTxns, err := s.GiftCardRepo.GetAllTransactions()
+	if err != nil {
+		return models.CashSession{}, err
+	}
+	session.ExpectedAmount = summarizeCashSession(session, payments, refunds, giftCardTxns).Expected
+	return session, nil
+}
+
+func summarizeCashSession(session models.CashSession, payments []models.Payment, refunds []models.Refund, giftCardTxns []models.GiftCardTransaction) models.CashSessionSummary {
+	summary := models.CashSessionSummary{
+		SessionID:    session.ID,
+		Status:       session.Status,
+		OpenedAt:     session.OpenedAt,
+		ClosedAt:     session.ClosedAt,
+		OpeningFloat: session.OpeningFloat,
+		Counted:      session.CountedAmount,
+	}
+
+	var sessionPayments []models.Payment
+	for _, p := range payments {
+		if p.SessionID != session.ID {
+			continue
+		}
+		sessionPayments = append(sessionPayments, p)
+		if p.Tender == models.TenderCash {
+			summary.CashSales += p.Amount + p.Tip
+		}
+	}
+	var sessionRefunds []models.Refund
+	for _, r := range refunds {
+		if r.SessionID != session.ID {
+			continue
+		}
+		sessionRefunds = append(sessionRefunds, r)
+		summary.Refunds += r.Amount
+		if r.Tender == models.TenderCash {
+			summary.CashRefunds += r.Amount
+		}
+	}
+	for _, t := range giftCardTxns {
+		if t.SessionID == session.ID && t.Tender == models.TenderCash {
+			summary.CashGiftCardSales += t.Amount
+		}
+	}
+	for _, e := range session.Events {
+		switch e.Type {
+		case models.CashEventIn:
+			summary.CashIn += e.Amount
+		case models.CashEventOut:
+			summary.CashOut += e.Amount
+		}
+	}
+
+	summary.Tenders = tenderBreakdown(sessionPayments, sessionRefunds)
+	summary.Refunds = roundMoney(summary.Refunds)
+	summary.CashSales = roundMoney(summary.CashSales)
+	summary.CashGiftCardSales = roundMoney(summary.CashGiftCardSales)
+	summary.CashIn = roundMoney(summary.CashIn)
+	summary.CashOut = roundMoney(summary.CashOut)
+	summary.CashRefunds = roundMoney(summary.CashRefunds)
+	summary.Expected = roundMoney(summary.OpeningFloat + summary.CashSales + summary.CashGiftCardSales + summary.CashIn - summary.CashOut - summary.CashRefunds)
+	if session.Status == "closed" {
+		summary.OverShort = roundMoney(summary.Counted - summary.Expected)
+	}
+	return summary
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"errors"
+	"hot-coffee/internal/dal"
+	"hot-coffee/models"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestCashSessionService returns a service over empty JSON files in a
+// temporary directory.
+func newTestCashSessionService(t *testing.T) *CashSessionService {
+	t.Helper()
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+	return NewCashSessionService(
+		dal.NewJSONCashSessionManager(path("cash_sessions.json")),
+		dal.NewJSONPaymentManager(path("payments.json")),
+		dal.NewJSONRefundManager(path("refunds.json")),
+		dal.NewJSONGiftCardManager(path("gift_cards.json"), path("gift_card_transactions.json")),
+	)
+}
+
+func TestCashSessionConcurrentWrites(t *testing.T) {
+	s := newTestCashSessionService(t)
+
+	var wg sync.WaitGroup
+	var opened sync.Map
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if session, err := s.OpenSession(models.CashSession{OpeningFloat: 50}); err == nil {
+				opened.Store(session.ID, true)
+			}
+		}()
+	}
+	wg.Wait()
+	var ids []string
+	opened.Range(func(id, _ any) bool {
+		ids = append(ids, id.(string))
+		return true
+	})
+	if len(ids) != 1 {
+		t.Fatalf("opened %d sessions at once, want 1", len(ids))
+	}
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.AddEvent(ids[0], models.CashEvent{Type: models.CashEventIn, Amount: 1}); err != nil {
+				t.Errorf("AddEvent() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	closed, err := s.CloseSession(ids[0], 70)
+	if err != nil {
+		t.Fatalf("CloseSession() error = %v", err)
+	}
+	if len(closed.Events) != 20 || closed.OverShort != 0 {
+		t.Errorf("closed with %d events, over/short %.2f, want 20 and 0.00", len(closed.Events), closed.OverShort)
+	}
+	// A late event must not write the session back open.
+	if _, err := s.AddEvent(ids[0], models.CashEvent{Type: models.CashEventIn, Amount: 1}); !errors.Is(err, ErrInvalidCashSession) {
+		t.Errorf("AddEvent() after close error = %v, want %v", err, ErrInvalidCashSession)
+	}
+	if session, _ := s.SessionRepo.GetSession(ids[0]); session.Status != "closed" {
+		t.Errorf("status = %s, want closed", session.Status)
+	}
+}
+
+func TestNextSessionID(t *testing.T) {
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.Local)
+	tests := []struct {
+		name     string
+		sessions []string
+		want     string
+	}{
+		{name: "first ever", want: "cs-20261019-1"},
+		{name: "first today", sessions: []string{"cs-20261017-1", "cs-20261018-1", "cs-20261018-2"}, want: "cs-20261019-1"},
+		{name: "second today", sessions: []string{"cs-20261018-1", "cs-20261019-1"}, want: "cs-20261019-2"},
+		{name: "after a gap", sessions: []string{"cs-20261019-3"}, want: "cs-20261019-4"},
+		{name: "not numbered", sessions: []string{"cs-20261019-x", "cs-2026101-9"}, want: "cs-20261019-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sessions []models.CashSession
+			for _, id := range tt.sessions {
+				sessions = append(sessions, models.CashSession{ID: id})
+			}
+			if got := nextSessionID(sessions, now); got != tt.want {
+				t.Errorf("nextSessionID() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestZReportOverShort(t *testing.T) {
+	tests := []struct {
+		name          string
+		cashSales     int
+		cardSales     int
+		cashRefund    bool
+		giftCardCash  float64
+		events        []models.CashEvent
+		counted       float64
+		wantExpected  float64
+		wantOverShort float64
+	}{
+		{name: "float only", counted: 50, wantExpected: 50},
+		{name: "card sales stay out of the drawer", cardSales: 2, counted: 50, wantExpected: 50},
+		{name: "short", cashSales: 2, events: []models.CashEvent{
+			{Type: models.CashEventIn, Amount: 10},
+			{Type: models.CashEventOut, Amount: 4},
+		}, counted: 65, wantExpected: 66, wantOverShort: -1},
+		{name: "over after a refund and a gift card", cashSales: 2, cashRefund: true, giftCardCash: 20, counted: 76, wantExpected: 75, wantOverShort: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shop := newTestShop(t)
+			session, err := shop.Sessions.OpenSession(models.CashSession{OpeningFloat: 50})
+			if err != nil {
+				t.Fatalf("OpenSession() error = %v", err)
+			}
+			var sold []models.Order
+			for i := 0; i < tt.cashSales; i++ {
+				sold = append(sold, shop.sell(t, models.Order{Items: []models.OrderItem{{ProductID: "latte", Quantity: 1}}}, models.TenderCash))
+			}
+			for i := 0; i < tt.cardSales; i++ {
+				shop.sell(t, models.Order{Items: []models.OrderItem{{ProductID: "tea", Quantity: 1}}}, models.TenderCard)
+			}
+			if tt.cashRefund {
+				if _, err := shop.Refunds.RefundOrder(sold[0].ID, models.RefundRequest{}); err != nil {
+					t.Fatalf("RefundOrder() error = %v", err)
+				}
+			}
+			if tt.giftCardCash > 0 {
+				if _, err := shop.GiftCards.IssueGiftCard(models.GiftCardAmountRequest{Amount: tt.giftCardCash, Tender: models.TenderCash}); err != nil {
+					t.Fatalf("IssueGiftCard() error = %v", err)
+				}
+			}
+			for _, event := range tt.events {
+				if _, err := shop.Sessions.AddEvent(session.ID, event); err != nil {
+					t.Fatalf("AddEvent() error = %v", err)
+				}
+			}
+			closed, err := shop.Sessions.CloseSession(session.ID, tt.counted)
+			if err != nil {
+				t.Fatalf("CloseSession() error = %v", err)
+			}
+			if closed.ExpectedAmount != tt.wantExpected || closed.OverShort != tt.wantOverShort {
+				t.Errorf("closed expecting %.2f, over/short %.2f, want %.2f and %.2f", closed.ExpectedAmount, closed.OverShort, tt.wantExpected, tt.wantOverShort)
+			}
+
+			report, err := shop.Reports.GetZReport("")
+			if err != nil {
+				t.Fatalf("GetZReport() error = %v", err)
+			}
+			if len(report.Sessions) != 1 {
+				t.Fatalf("report has %d sessions, want 1", len(report.Sessions))
+			}
+			summary := report.Sessions[0]
+			if summary.Expected != tt.wantExpected || summary.Counted != tt.counted || summary.OverShort != tt.wantOverShort {
+				t.Errorf("report expecting %.2f, counted %.2f, over/short %.2f, want %.2f, %.2f and %.2f",
+					summary.Expected, summary.Counted, summary.OverShort, tt.wantExpected, tt.counted, tt.wantOverShort)
+			}
+			wantNet := float64(tt.cashSales*5 + tt.cardSales*3)
+			if tt.cashRefund {
+				wantNet -= 5
+			}
+			if report.NetSales != wantNet {
+				t.Errorf("net sales = %.2f, want %.2f", report.NetSales, wantNet)
+			}
+		})
+	}
+}
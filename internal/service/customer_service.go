@@ -0,0 +1,202 @@
+package service
+
+import (
+	"fmt"
+	"hot-coffee/internal/dal"
+	"hot-coffee/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCustomer = models.NewError(models.ErrInvalidInput, "invalid customer")
+
+const favoriteItemsLimit = 3
+
+type CustomerService struct {
+	CustomerRepo dal.CustomerManager
+	OrderRepo    dal.OrderManager
+	MenuRepo     dal.MenuManager
+	RefundRepo   dal.RefundManager
+}
+
+func NewCustomerService(customerRepo dal.CustomerManager, orderRepo dal.OrderManager, menuRepo dal.MenuManager, refundRepo dal.RefundManager) *CustomerService {
+	return &CustomerService{
+		CustomerRepo: customerRepo,
+		OrderRepo:    orderRepo,
+		MenuRepo:     menuRepo,
+		RefundRepo:   refundRepo,
+	}
+}
+
+func (s *CustomerService) AddCustomer(customer models.Customer) (models.Customer, error) {
+	customers, err := s.CustomerRepo.GetAllCustomers()
+	if err != nil {
+		return models.Customer{}, err
+	}
+
+	customer = normalizeCustomer(customer)
+	if err := validateCustomer(customer, customers); err != nil {
+		return models.Customer{}, err
+	}
+
+	for n := len(customers) + 1; ; n++ {
+		customer.ID = fmt.Sprintf("cust-%d", n)
+		if _, err := s.CustomerRepo.GetCustomer(customer.ID); err != nil {
+			break
+		}
+	}
+	customer.CreatedAt = time.Now().Format(time.RFC3339)
+	if err := s.CustomerRepo.AddCustomer(customer); err != nil {
+		return models.Customer{}, err
+	}
+	return customer, nil
+}
+
+func (s *CustomerService) GetAllCustomers() ([]models.Customer, error) {
+	return s.CustomerRepo.GetAllCustomers()
+}
+
+func (s *CustomerService) GetCustomer(id string) (models.Customer, error) {
+	return s.CustomerRepo.GetCustomer(id)
+}
+
+func (s *CustomerService) UpdateCustomer(customer models.Customer) (models.Customer, error) {
+	existing, err := s.CustomerRepo.GetCustomer(customer.ID)
+	if err != nil {
+		return models.Customer{}, err
+	}
+	customers, err := s.CustomerRepo.GetAllCustomers()
+	if err != nil {
+		return models.Customer{}, err
+	}
+
+	customer = normalizeCustomer(customer)
+	customer.CreatedAt = existing.CreatedAt
+	if err := validateCustomer(customer, customers); err != nil {
+		return models.Customer{}, err
+	}
+	if err := s.CustomerRepo.UpdateCustomer(customer); err != nil {
+		return models.Customer{}, err
+	}
+	return customer, nil
+}
+
+// DeleteCustomer refuses to remove a customer that orders still refer to, so
+// order history and loyalty never point at a missing record.
+func (s *CustomerService) DeleteCustomer(id string) error {
+	if _, err := s.CustomerRepo.GetCustomer(id); err != nil {
+		return err
+	}
+	orders, err := s.OrderRepo.GetAllOrders()
+	if err != nil {
+		return err
+	}
+	for _, order := range orders {
+		if order.CustomerID == id {
+			return fmt.Errorf("%w: customer '%s' is referenced by order '%s'", models.ErrConflict, id, order.ID)
+		}
+	}
+	return s.CustomerRepo.DeleteCustomer(id)
+}
+
+func (s *CustomerService) GetCustomerHistory(id string) (models.CustomerHistory, error) {
+	customer, err := s.CustomerRepo.GetCustomer(id)
+	if err != nil {
+		return models.CustomerHistory{}, err
+	}
+	orders, err := s.OrderRepo.GetAllOrders()
+	if err != nil {
+		return models.CustomerHistory{}, err
+	}
+	menuItems, err := s.MenuRepo.GetAllMenuItems()
+	if err != nil {
+		return models.CustomerHistory{}, err
+	}
+	refunds, err := s.RefundRepo.GetAllRefunds()
+	if err != nil {
+		return models.CustomerHistory{}, err
+	}
+
+	refunded := make(map[string]float64)
+	for _, r := range refunds {
+		refunded[r.OrderID] += r.Amount
+	}
+
+	history := models.CustomerHistory{
+		Customer:      customer,
+		Orders:        []models.Order{},
+		FavoriteItems: []models.PopularItemReport{},
+	}
+	counts := make(map[string]int)
+	names := make(map[string]string)
+	for _, order := range orders {
+		if order.CustomerID != id {
+			continue
+		}
+		history.Orders = append(history.Orders, order)
+		if !isSold(order.Status) {
+			continue
+		}
+		history.OrderCount++
+		history.LifetimeSpend += orderTotal(order, menuItems) - refunded[order.ID]
+		for _, item := range order.Items {
+			counts[item.ProductID] += item.Quantity
+			if _, ok := names[item.ProductID]; !ok || item.Name != "" {
+				names[item.ProductID] = itemName(item, menuItems)
+			}
+		}
+	}
+	history.LifetimeSpend = roundMoney(history.LifetimeSpend)
+	for productID, count := range counts {
+		history.FavoriteItems = append(history.FavoriteItems, models.PopularItemReport{
+			ProductID: productID,
+			Name:      names[productID],
+			Count:     count,
+		})
+	}
+	sort.Slice(history.FavoriteItems, func(i, j int) bool {
+		a, b := history.FavoriteItems[i], history.FavoriteItems[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.ProductID < b.ProductID
+	})
+	if len(history.FavoriteItems) > favoriteItemsLimit {
+		history.FavoriteItems = history.FavoriteItems[:favoriteItemsLimit]
+	}
+	return history, nil
+}
+
+func normalizeCustomer(customer models.Customer) models.Customer {
+	customer.Name = strings.Join(strings.Fields(customer.Name), " ")
+	customer.Phone = strings.TrimSpace(customer.Phone)
+	customer.Email = strings.ToLower(strings.TrimSpace(customer.Email))
+	return customer
+}
+
+func validateCustomer(customer models.Customer, existing []models.Customer) error {
+	if customer.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCustomer)
+	}
+	for _, c := range existing {
+		if c.ID == customer.ID {
+			continue
+		}
+		if customer.Email != "" && c.Email == customer.Email {
+			return fmt.Errorf("%w: email '%s' is already used by customer '%s'", ErrInvalidCustomer, customer.Email, c.ID)
+		}
+		if customer.Phone != "" && c.Phone == customer.Phone {
+			return fmt.Errorf("%w: phone '%s' is already used by customer '%s'", ErrInvalidCustomer, customer.Phone, c.ID)
+		}
+		// Namesakes are only told apart by their contact details.
+		if strings.EqualFold(c.Name, customer.Name) && (!hasContact(c) || !hasContact(customer)) {
+			return fmt.Errorf("%w: customer '%s' is already named '%s'; add a phone or email to both to tell them apart", ErrInvalidCustomer, c.ID, c.Name)
+		}
+	}
+	return nil
+}
+
+func hasContact(customer models.Customer) bool {
+	return customer.Phone != "" || customer.Email != ""
+}
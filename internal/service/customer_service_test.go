@@ -0,0 +1,107 @@
+package service
+
+import (
+	"errors"
+	"hot-coffee/models"
+	"testing"
+)
+
+func TestAddCustomer(t *testing.T) {
+	tests := []struct {
+		name     string
+		customer models.Customer
+		want     models.Customer
+		wantErr  error
+	}{
+		{name: "normalized", customer: models.Customer{Name: "  Bob   Brown ", Email: " Bob@Example.COM ", Phone: " 555-0100 "},
+			want: models.Customer{Name: "Bob Brown", Email: "bob@example.com", Phone: "555-0100"}},
+		{name: "no name", customer: models.Customer{Name: "   ", Email: "x@example.com"}, wantErr: ErrInvalidCustomer},
+		{name: "email taken", customer: models.Customer{Name: "Eve", Email: "ANN@example.com"}, wantErr: ErrInvalidCustomer},
+		{name: "phone taken", customer: models.Customer{Name: "Eve", Phone: "555-0199"}, wantErr: ErrInvalidCustomer},
+		{name: "namesake without contact", customer: models.Customer{Name: "ann lee"}, wantErr: ErrInvalidCustomer},
+		{name: "namesake with contact", customer: models.Customer{Name: "Ann Lee", Email: "ann2@example.com"},
+			want: models.Customer{Name: "Ann Lee", Email: "ann2@example.com"}},
+		{name: "namesake of a customer without contact", customer: models.Customer{Name: "Max Roe", Email: "max@example.com"}, wantErr: ErrInvalidCustomer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestShop(t).Customers
+			for _, c := range []models.Customer{
+				{Name: "Ann Lee", Email: "ann@example.com", Phone: "555-0199"},
+				{Name: "Max Roe"},
+			} {
+				if _, err := s.AddCustomer(c); err != nil {
+					t.Fatalf("AddCustomer() error = %v", err)
+				}
+			}
+			got, err := s.AddCustomer(tt.customer)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AddCustomer() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.ID == "" || got.CreatedAt == "" {
+				t.Errorf("AddCustomer() = %+v, want an ID and a creation time", got)
+			}
+			if got.Name != tt.want.Name || got.Email != tt.want.Email || got.Phone != tt.want.Phone {
+				t.Errorf("AddCustomer() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetCustomerHistory(t *testing.T) {
+	shop := newTestShop(t)
+	shop.sell(t, models.Order{CustomerID: "c1", Items: []models.OrderItem{{ProductID: "latte", Quantity: 2}}}, models.TenderCash)
+	teas := shop.sell(t, models.Order{CustomerID: "c1", Items: []models.OrderItem{{ProductID: "tea", Quantity: 3}}}, models.TenderCard)
+	shop.sell(t, models.Order{CustomerID: "c2", Items: []models.OrderItem{{ProductID: "tea", Quantity: 5}}}, models.TenderCash)
+	if _, err := shop.Orders.CreateOrder(models.Order{CustomerID: "c1", Items: []models.OrderItem{{ProductID: "latte", Quantity: 9}}}); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	if _, err := shop.Refunds.RefundOrder(teas.ID, models.RefundRequest{Lines: []models.RefundLine{{ProductID: "tea", Quantity: 1}}}); err != nil {
+		t.Fatalf("RefundOrder() error = %v", err)
+	}
+
+	tests := []struct {
+		customerID    string
+		wantOrders    int
+		wantSold      int
+		wantSpend     float64
+		wantFavorites []models.PopularItemReport
+	}{
+		{customerID: "c1", wantOrders: 3, wantSold: 2, wantSpend: 16, wantFavorites: []models.PopularItemReport{
+			{ProductID: "tea", Name: "Tea", Count: 3},
+			{ProductID: "latte", Name: "Latte", Count: 2},
+		}},
+		{customerID: "c2", wantOrders: 1, wantSold: 1, wantSpend: 15, wantFavorites: []models.PopularItemReport{
+			{ProductID: "tea", Name: "Tea", Count: 5},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.customerID, func(t *testing.T) {
+			history, err := shop.Customers.GetCustomerHistory(tt.customerID)
+			if err != nil {
+				t.Fatalf("GetCustomerHistory() error = %v", err)
+			}
+			if len(history.Orders) != tt.wantOrders || history.OrderCount != tt.wantSold {
+				t.Errorf("%d orders, %d sold, want %d and %d", len(history.Orders), history.OrderCount, tt.wantOrders, tt.wantSold)
+			}
+			if history.LifetimeSpend != tt.wantSpend {
+				t.Errorf("lifetime spend = %.2f, want %.2f", history.LifetimeSpend, tt.wantSpend)
+			}
+			if len(history.FavoriteItems) != len(tt.wantFavorites) {
+				t.Fatalf("favorites = %+v, want %+v", history.FavoriteItems, tt.wantFavorites)
+			}
+			for i, want := range tt.wantFavorites {
+				if got := history.FavoriteItems[i]; got.ProductID != want.ProductID || got.Name != want.Name || got.Count != want.Count {
+					t.Errorf("favorite %d = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+
+	if _, err := shop.Customers.GetCustomerHistory("nobody"); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("GetCustomerHistory() error = %v, want %v", err, models.ErrNotFound)
+	}
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"fmt"
+	"hot-coffee/models"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	defaultForecastDays = 7
+	maxForecastDays     = 90
+	defaultHistoryWeeks = 4
+	maxHistoryWeeks     = 52
+	DefaultSafetyStock  = 20.0
+)
+
+// GetForecast predicts per-item demand for the next days starting tomorrow.
+// Each weekday and hour is forecast as a moving average of the same weekday
+// and hour over the last weeks of sales, with recent weeks weighted more
+// heavily. Demand is converted to ingredient needs through current recipes and
+// compared with stock on hand to suggest reorder quantities.
+func (s *ReportService) GetForecast(days int, weeks int, safety float64) (models.ForecastReport, error) {
+	if days == 0 {
+		days = defaultForecastDays
+	}
+	if weeks == 0 {
+		weeks = defaultHistoryWeeks
+	}
+	if days < 1 || days > maxForecastDays {
+		return models.ForecastReport{}, fmt.Errorf("%w: days must be between 1 and %d", ErrInvalidQuery, maxForecastDays)
+	}
+	if weeks < 1 || weeks > maxHistoryWeeks {
+		return models.ForecastReport{}, fmt.Errorf("%w: weeks must be between 1 and %d", ErrInvalidQuery, maxHistoryWeeks)
+	}
+	if safety < 0 {
+		return models.ForecastReport{}, fmt.Errorf("%w: safety stock must not be negative", ErrInvalidQuery)
+	}
+
+	orders, err := s.orderRepo.LoadOrders()
+	if err != nil {
+		return models.ForecastReport{}, err
+	}
+	menuItems, err := s.menuRepo.LoadMenuItems()
+	if err != nil {
+		return models.ForecastReport{}, err
+	}
+	inventory, err := s.inventoryRepo.LoadInventory()
+	if err != nil {
+		return models.ForecastReport{}, err
+	}
+
+	now := time.Now().In(s.location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
+	historyStart := today.AddDate(0, 0, -7*weeks)
+
+	// demand[product][weekday][hour] holds the weighted sum of quantities sold.
+	demand := make(map[string]*[7][24]float64)
+	names := make(map[string]string)
+	for _, order := range orders {
+		if !isSold(order.Status) {
+			continue
+		}
+		createdAt, err := time.Parse(time.RFC3339, order.CreatedAt)
+		if err != nil {
+			continue
+		}
+		createdAt = createdAt.In(s.location)
+		if createdAt.Before(historyStart) || !createdAt.Before(today) {
+			continue
+		}
+		day := time.Date(createdAt.Year(), createdAt.Month(), createdAt.Day(), 0, 0, 0, 0, s.location)
+		weeksAgo := (int(math.Round(today.Sub(day).Hours()/24)) - 1) / 7
+		weight := float64(weeks - weeksAgo)
+		for _, item := range order.Items {
+			d, ok := demand[item.ProductID]
+			if !ok {
+				d = &[7][24]float64{}
+				demand[item.ProductID] = d
+			}
+			d[createdAt.Weekday()][createdAt.Hour()] += weight * float64(item.Quantity)
+			if _, ok := names[item.ProductID]; !ok || item.Name != "" {
+				names[item.ProductID] = itemName(item, menuItems)
+			}
+		}
+	}
+
+	totalWeight := float64(weeks*(weeks+1)) / 2
+	products := make([]string, 0, len(demand))
+	for id := range demand {
+		products = append(products, id)
+	}
+	sort.Strings(products)
+
+	recipes := make(map[string][]models.MenuItemIngredient)
+	for _, item := range menuItems {
+		recipes[item.ID] = item.Ingredients
+	}
+	required := make(map[string]float64)
+
+	report := models.ForecastReport{
+		GeneratedAt:  now.Format(time.RFC3339),
+		TimeZone:     s.location.String(),
+		HistoryWeeks: weeks,
+		SafetyStock:  safety,
+		Days:         []models.ForecastDay{},
+		Ingredients:  []models.IngredientForecast{},
+	}
+	for i := 1; i <= days; i++ {
+		date := today.AddDate(0, 0, i)
+		day := models.ForecastDay{
+			Date:    date.Format(time.DateOnly),
+			Weekday: date.Weekday().String(),
+			Items:   []models.ItemForecast{},
+			Hours:   []models.HourForecast{},
+		}
+		var hours [24]float64
+		for _, id := range products {
+			d := demand[id]
+			forecast := models.ItemForecast{ProductID: id, Name: names[id]}
+			peak := 0.0
+			for hour, sum := range d[date.Weekday()] {
+				expected := sum / totalWeight
+				forecast.Expected += expected
+				hours[hour] += expected
+				if expected > peak {
+					peak = expected
+					forecast.PeakHour = hour
+				}
+			}
+			if forecast.Expected == 0 {
+				continue
+			}
+			for _, ing := range recipes[id] {
+				required[ing.IngredientID] += ing.Quantity * forecast.Expected
+			}
+			forecast.Prep = int(math.Ceil(forecast.Expected))
+			forecast.Expected = roundMoney(forecast.Expected)
+			day.Items = append(day.Items, forecast)
+		}
+		for hour, expected := range hours {
+			if expected > 0 {
+				day.Hours = append(day.Hours, models.HourForecast{Hour: hour, Expected: roundMoney(expected)})
+			}
+		}
+		sort.SliceStable(day.Items, func(a, b int) bool {
+			return day.Items[a].Expected > day.Items[b].Expected
+		})
+		report.Days = append(report.Days, day)
+	}
+
+	stock := make(map[string]models.InventoryItem)
+	for _, item := range inventory {
+		stock[item.IngredientID] = item
+	}
+	for id, quantity := range required {
+		item := stock[id]
+		par := roundQuantity(quantity * (1 + safety/100))
+		report.Ingredients = append(report.Ingredients, models.IngredientForecast{
+			IngredientID:     id,
+			Name:             item.Name,
+			Unit:             item.Unit,
+			Required:         roundQuantity(quantity),
+			ParLevel:         par,
+			OnHand:           item.Quantity,
+			SuggestedReorder: roundQuantity(math.Max(par-item.Quantity, 0)),
+		})
+	}
+	sort.Slice(report.Ingredients, func(i, j int) bool {
+		return report.Ingredients[i].IngredientID < report.Ingredients[j].IngredientID
+	})
+	return report, nil
+}
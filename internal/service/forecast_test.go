@@ -0,0 +1,113 @@
+package service
+
+import (
+	"errors"
+	"hot-coffee/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newTestForecastShop returns a shop whose sales over the last two weeks put
+// 4 lattes at 10:00 and 0.67 teas at 15:00 on tomorrow's weekday.
+func newTestForecastShop(t *testing.T) *testShop {
+	t.Helper()
+	shop := newTestShop(t)
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	at := func(daysAgo int, hour int) string {
+		return today.AddDate(0, 0, -daysAgo).Add(time.Duration(hour) * time.Hour).Format(time.RFC3339)
+	}
+	// Last week counts twice as much as the week before.
+	shop.soldAt(t, at(6, 10), models.OrderItem{ProductID: "latte", Quantity: 3})
+	shop.soldAt(t, at(13, 10), models.OrderItem{ProductID: "latte", Quantity: 6})
+	shop.soldAt(t, at(6, 15), models.OrderItem{ProductID: "tea", Quantity: 1})
+	// Neither today's sales nor those older than the history count.
+	shop.soldAt(t, at(0, 0), models.OrderItem{ProductID: "latte", Quantity: 50})
+	shop.soldAt(t, at(20, 10), models.OrderItem{ProductID: "latte", Quantity: 50})
+	return shop
+}
+
+func TestGetForecast(t *testing.T) {
+	tests := []struct {
+		name        string
+		safety      float64
+		onHand      float64
+		wantPar     float64
+		wantReorder float64
+	}{
+		{name: "enough milk", onHand: 10, wantPar: 0.8},
+		{name: "reorder with safety stock", safety: 25, onHand: 0.5, wantPar: 1, wantReorder: 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shop := newTestForecastShop(t)
+			if _, err := shop.Orders.InventoryRepo.ApplyMovement(models.InventoryMovement{IngredientID: "milk", Type: models.MovementCount, StockAfter: tt.onHand}); err != nil {
+				t.Fatalf("ApplyMovement() error = %v", err)
+			}
+
+			report, err := shop.Reports.GetForecast(3, 2, tt.safety)
+			if err != nil {
+				t.Fatalf("GetForecast() error = %v", err)
+			}
+			if len(report.Days) != 3 {
+				t.Fatalf("forecast covers %d days, want 3", len(report.Days))
+			}
+			tomorrow := report.Days[0]
+			if tomorrow.Date != time.Now().UTC().AddDate(0, 0, 1).Format(time.DateOnly) {
+				t.Errorf("first day = %s, want tomorrow", tomorrow.Date)
+			}
+			wantItems := []models.ItemForecast{
+				{ProductID: "latte", Name: "Latte", Expected: 4, Prep: 4, PeakHour: 10},
+				{ProductID: "tea", Name: "Tea", Expected: 0.67, Prep: 1, PeakHour: 15},
+			}
+			if !reflect.DeepEqual(tomorrow.Items, wantItems) {
+				t.Errorf("items = %+v, want %+v", tomorrow.Items, wantItems)
+			}
+			wantHours := []models.HourForecast{{Hour: 10, Expected: 4}, {Hour: 15, Expected: 0.67}}
+			if !reflect.DeepEqual(tomorrow.Hours, wantHours) {
+				t.Errorf("hours = %+v, want %+v", tomorrow.Hours, wantHours)
+			}
+			for _, day := range report.Days[1:] {
+				if len(day.Items) != 0 {
+					t.Errorf("%s items = %+v, want none", day.Weekday, day.Items)
+				}
+			}
+
+			want := []models.IngredientForecast{{
+				IngredientID:     "milk",
+				Name:             "Milk",
+				Unit:             "l",
+				Required:         0.8,
+				ParLevel:         tt.wantPar,
+				OnHand:           tt.onHand,
+				SuggestedReorder: tt.wantReorder,
+			}}
+			if !reflect.DeepEqual(report.Ingredients, want) {
+				t.Errorf("ingredients = %+v, want %+v", report.Ingredients, want)
+			}
+		})
+	}
+}
+
+func TestGetForecastInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		days   int
+		weeks  int
+		safety float64
+	}{
+		{name: "too many days", days: maxForecastDays + 1},
+		{name: "negative days", days: -1},
+		{name: "too many weeks", weeks: maxHistoryWeeks + 1},
+		{name: "negative safety stock", safety: -1},
+	}
+	shop := newTestShop(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := shop.Reports.GetForecast(tt.days, tt.weeks, tt.safety); !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("GetForecast() error = %v, want %v", err, ErrInvalidQuery)
+			}
+		})
+	}
+}
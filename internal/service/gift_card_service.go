@@ -0,0 +1,200 @@
+package service
+
+import (
+	"crypto/rand"
+	"fmt"
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/gateway"
+	"hot-coffee/models"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+var ErrInvalidGiftCard = models.NewError(models.ErrInvalidInput, "invalid gift card operation")
+
+const giftCardAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+type GiftCardService struct {
+	GiftCardRepo dal.GiftCardManager
+	SessionRepo  dal.CashSessionManager
+	Gateway      gateway.PaymentGateway
+}
+
+func NewGiftCardService(giftCardRepo dal.GiftCardManager, sessionRepo dal.CashSessionManager, gw gateway.PaymentGateway) *GiftCardService {
+	return &GiftCardService{
+		GiftCardRepo: giftCardRepo,
+		SessionRepo:  sessionRepo,
+		Gateway:      gw,
+	}
+}
+
+func (s *GiftCardService) IssueGiftCard(req models.GiftCardAmountRequest) (models.GiftCard, error) {
+	amount := roundMoney(req.Amount)
+	if amount <= 0 {
+		return models.GiftCard{}, fmt.Errorf("%w: amount must be positive", ErrInvalidGiftCard)
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	if code == "" {
+		var err error
+		if code, err = generateGiftCardCode(); err != nil {
+			return models.GiftCard{}, err
+		}
+	}
+	if _, err := s.GiftCardRepo.GetGiftCard(code); err == nil {
+		return models.GiftCard{}, fmt.Errorf("%w: code '%s' is already in use", ErrInvalidGiftCard, code)
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	card := models.GiftCard{
+		Code:         code,
+		Balance:      amount,
+		InitialValue: amount,
+		IssuedAt:     now,
+	}
+	txn := models.GiftCardTransaction{
+		Code:      code,
+		Type:      models.GiftCardIssue,
+		Amount:    amount,
+		CreatedAt: now,
+	}
+	err := s.sell(code, req, txn, func(txn models.GiftCardTransaction) error {
+		return s.GiftCardRepo.AddGiftCard(card, txn)
+	})
+	if err != nil {
+		return models.GiftCard{}, err
+	}
+	return card, nil
+}
+
+func (s *GiftCardService) ReloadGiftCard(code string, req models.GiftCardAmountRequest) (models.GiftCard, error) {
+	req.Amount = roundMoney(req.Amount)
+	if req.Amount <= 0 {
+		return models.GiftCard{}, fmt.Errorf("%w: amount must be positive", ErrInvalidGiftCard)
+	}
+	if _, err := s.GiftCardRepo.GetGiftCard(code); err != nil {
+		return models.GiftCard{}, err
+	}
+
+	var card models.GiftCard
+	txn := models.GiftCardTransaction{
+		Type:      models.GiftCardReload,
+		Amount:    req.Amount,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	err := s.sell(code, req, txn, func(txn models.GiftCardTransaction) error {
+		var err error
+		card, err = s.GiftCardRepo.AdjustBalance(code, txn)
+		return err
+	})
+	if err != nil {
+		return models.GiftCard{}, err
+	}
+	return card, nil
+}
+
+// sell takes payment for value loaded onto a card and records it with txn.
+// Cash lands in the open drawer session. Cards are authorized, recorded and
+// then captured; a failed capture takes the value back off the card.
+func (s *GiftCardService) sell(code string, req models.GiftCardAmountRequest, txn models.GiftCardTransaction, record func(models.GiftCardTransaction) error) error {
+	session, open, err := s.SessionRepo.GetOpenSession()
+	if err != nil {
+		return err
+	}
+	txn.Tender = req.Tender
+	txn.SessionID = session.ID
+
+	switch req.Tender {
+	case models.TenderCash:
+		if !open {
+			return fmt.Errorf("%w: no cash drawer session is open", ErrInvalidGiftCard)
+		}
+		return record(txn)
+	case models.TenderCard:
+		if req.CardToken == "" {
+			return fmt.Errorf("%w: card_token is required for card payments", ErrInvalidGiftCard)
+		}
+	default:
+		return fmt.Errorf("%w: tender must be '%s' or '%s'", ErrInvalidGiftCard, models.TenderCash, models.TenderCard)
+	}
+
+	key := fmt.Sprintf("giftcard:%s:%s:%d", code, txn.Type, time.Now().UnixNano())
+	auth, err := authorizeCard(s.Gateway, key, code, txn.Amount, req.CardToken)
+	if err != nil {
+		return err
+	}
+	txn.GatewayTransactionID = auth.TransactionID
+	if err := record(txn); err != nil {
+		voidAuthorization(s.Gateway, auth.TransactionID, key)
+		return err
+	}
+	if _, err := captureCard(s.Gateway, auth.TransactionID, txn.Amount, key); err != nil {
+		voidAuthorization(s.Gateway, auth.TransactionID, key)
+		_, voidErr := s.GiftCardRepo.AdjustBalance(code, models.GiftCardTransaction{
+			Type:                 models.GiftCardVoid,
+			Amount:               -txn.Amount,
+			Reference:            "capture failed",
+			Tender:               txn.Tender,
+			GatewayTransactionID: auth.TransactionID,
+			CreatedAt:            time.Now().Format(time.RFC3339),
+		})
+		if voidErr != nil {
+			slog.Error("Failed to take back gift card value after capture failure", "code", code, "error", voidErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *GiftCardService) GetGiftCard(code string) (models.GiftCard, error) {
+	return s.GiftCardRepo.GetGiftCard(code)
+}
+
+func (s *GiftCardService) GetTransactions(code string) ([]models.GiftCardTransaction, error) {
+	if _, err := s.GiftCardRepo.GetGiftCard(code); err != nil {
+		return nil, err
+	}
+	txns, err := s.GiftCardRepo.GetTransactions(code)
+	if err != nil {
+		return nil, err
+	}
+	return append([]models.GiftCardTransaction{}, txns...), nil
+}
+
+func (s *GiftCardService) Redeem(code string, amount float64, orderID string, reference string) error {
+	_, err := s.GiftCardRepo.AdjustBalance(code, models.GiftCardTransaction{
+		Type:      models.GiftCardRedeem,
+		Amount:    -roundMoney(amount),
+		OrderID:   orderID,
+		Reference: reference,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	})
+	return err
+}
+
+func (s *GiftCardService) Credit(code string, amount float64, orderID string, reference string) error {
+	_, err := s.GiftCardRepo.AdjustBalance(code, models.GiftCardTransaction{
+		Type:      models.GiftCardRefund,
+		Amount:    roundMoney(amount),
+		OrderID:   orderID,
+		Reference: reference,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	})
+	return err
+}
+
+func generateGiftCardCode() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for i, b := range buf {
+		if i > 0 && i%4 == 0 {
+			sb.WriteByte('-')
+		}
+		sb.WriteByte(giftCardAlphabet[int(b)%len(giftCardAlphabet)])
+	}
+	return sb.String(), nil
+}
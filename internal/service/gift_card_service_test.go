@@ -0,0 +1,170 @@
+package service
+
+import (
+	"errors"
+	"hot-coffee/internal/gateway"
+	"hot-coffee/models"
+	"testing"
+)
+
+func TestIssueGiftCard(t *testing.T) {
+	tests := []struct {
+		name    string
+		open    bool
+		req     models.GiftCardAmountRequest
+		want    string
+		wantErr error
+	}{
+		{name: "cash", open: true, req: models.GiftCardAmountRequest{Code: " gc-new ", Amount: 20, Tender: models.TenderCash}, want: "GC-NEW"},
+		{name: "card", req: models.GiftCardAmountRequest{Code: "GC-NEW", Amount: 20, Tender: models.TenderCard, CardToken: "tok_visa"}, want: "GC-NEW"},
+		{name: "cash without a drawer", req: models.GiftCardAmountRequest{Amount: 20, Tender: models.TenderCash}, wantErr: ErrInvalidGiftCard},
+		{name: "card without a token", open: true, req: models.GiftCardAmountRequest{Amount: 20, Tender: models.TenderCard}, wantErr: ErrInvalidGiftCard},
+		{name: "paid by gift card", open: true, req: models.GiftCardAmountRequest{Amount: 20, Tender: models.TenderGiftCard}, wantErr: ErrInvalidGiftCard},
+		{name: "no amount", open: true, req: models.GiftCardAmountRequest{Tender: models.TenderCash}, wantErr: ErrInvalidGiftCard},
+		{name: "code in use", open: true, req: models.GiftCardAmountRequest{Code: "gc-1", Amount: 20, Tender: models.TenderCash}, wantErr: ErrInvalidGiftCard},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shop := newTestShop(t)
+			if tt.open {
+				if _, err := shop.Sessions.OpenSession(models.CashSession{}); err != nil {
+					t.Fatalf("OpenSession() error = %v", err)
+				}
+			}
+			if _, err := shop.GiftCards.IssueGiftCard(models.GiftCardAmountRequest{Code: "GC-1", Amount: 5, Tender: models.TenderCard, CardToken: "tok_visa"}); err != nil {
+				t.Fatalf("IssueGiftCard() error = %v", err)
+			}
+
+			card, err := shop.GiftCards.IssueGiftCard(tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("IssueGiftCard() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if card.Code != tt.want || card.Balance != tt.req.Amount || card.InitialValue != tt.req.Amount {
+				t.Errorf("IssueGiftCard() = %+v, want code %s with %.2f", card, tt.want, tt.req.Amount)
+			}
+			txns, err := shop.GiftCards.GetTransactions(card.Code)
+			if err != nil {
+				t.Fatalf("GetTransactions() error = %v", err)
+			}
+			if len(txns) != 1 || txns[0].Type != models.GiftCardIssue || txns[0].Tender != tt.req.Tender || txns[0].BalanceAfter != tt.req.Amount {
+				t.Errorf("transactions = %+v, want one %s paid by %s", txns, models.GiftCardIssue, tt.req.Tender)
+			}
+		})
+	}
+}
+
+func TestIssueGiftCardCaptureFailure(t *testing.T) {
+	shop := newTestShop(t)
+	shop.GiftCards.Gateway = &flakyCapture{MockProcessor: shop.Gateway}
+
+	_, err := shop.GiftCards.IssueGiftCard(models.GiftCardAmountRequest{Code: "GC-1", Amount: 20, Tender: models.TenderCard, CardToken: "tok_visa"})
+	if !errors.Is(err, gateway.ErrTimeout) {
+		t.Fatalf("IssueGiftCard() error = %v, want %v", err, gateway.ErrTimeout)
+	}
+	card, err := shop.GiftCards.GetGiftCard("GC-1")
+	if err != nil {
+		t.Fatalf("GetGiftCard() error = %v", err)
+	}
+	if card.Balance != 0 {
+		t.Errorf("balance = %.2f, want the value taken back", card.Balance)
+	}
+	txns, _ := shop.GiftCards.GetTransactions("GC-1")
+	if len(txns) != 2 || txns[1].Type != models.GiftCardVoid {
+		t.Errorf("transactions = %+v, want the issue and its void", txns)
+	}
+}
+
+func TestGiftCardLedger(t *testing.T) {
+	shop := newTestShop(t)
+	if _, err := shop.Sessions.OpenSession(models.CashSession{}); err != nil {
+		t.Fatalf("OpenSession() error = %v", err)
+	}
+	if _, err := shop.GiftCards.IssueGiftCard(models.GiftCardAmountRequest{Code: "GC-1", Amount: 8, Tender: models.TenderCash}); err != nil {
+		t.Fatalf("IssueGiftCard() error = %v", err)
+	}
+	order := func(items ...models.OrderItem) string {
+		t.Helper()
+		created, err := shop.Orders.CreateOrder(models.Order{CustomerName: "Alice", Items: items})
+		if err != nil {
+			t.Fatalf("CreateOrder() error = %v", err)
+		}
+		return created.ID
+	}
+	latte := order(models.OrderItem{ProductID: "latte", Quantity: 1})
+	twoLattes := order(models.OrderItem{ProductID: "latte", Quantity: 2})
+
+	steps := []struct {
+		name        string
+		run         func() error
+		wantErr     error
+		wantBalance float64
+	}{
+		{name: "pay a latte", run: func() error {
+			_, err := shop.Payments.AddPayment(latte, models.Payment{Tender: models.TenderGiftCard, GiftCardCode: "GC-1", Amount: 5})
+			return err
+		}, wantBalance: 3},
+		{name: "not enough for two", run: func() error {
+			_, err := shop.Payments.AddPayment(twoLattes, models.Payment{Tender: models.TenderGiftCard, GiftCardCode: "GC-1", Amount: 10})
+			return err
+		}, wantErr: ErrInvalidPayment, wantBalance: 3},
+		{name: "unknown card", run: func() error {
+			_, err := shop.Payments.AddPayment(twoLattes, models.Payment{Tender: models.TenderGiftCard, GiftCardCode: "GC-2", Amount: 1})
+			return err
+		}, wantErr: ErrInvalidPayment, wantBalance: 3},
+		{name: "reload", run: func() error {
+			_, err := shop.GiftCards.ReloadGiftCard("GC-1", models.GiftCardAmountRequest{Amount: 7, Tender: models.TenderCard, CardToken: "tok_visa"})
+			return err
+		}, wantBalance: 10},
+		{name: "pay two lattes", run: func() error {
+			_, err := shop.Payments.AddPayment(twoLattes, models.Payment{Tender: models.TenderGiftCard, GiftCardCode: "GC-1", Amount: 10})
+			return err
+		}, wantBalance: 0},
+		{name: "refund the latte to the card", run: func() error {
+			_, err := shop.Refunds.RefundOrder(latte, models.RefundRequest{})
+			return err
+		}, wantBalance: 5},
+		{name: "reload an unknown card", run: func() error {
+			_, err := shop.GiftCards.ReloadGiftCard("GC-2", models.GiftCardAmountRequest{Amount: 7, Tender: models.TenderCash})
+			return err
+		}, wantErr: models.ErrNotFound, wantBalance: 5},
+	}
+	for _, step := range steps {
+		if err := step.run(); !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: error = %v, want %v", step.name, err, step.wantErr)
+		}
+		card, err := shop.GiftCards.GetGiftCard("GC-1")
+		if err != nil {
+			t.Fatalf("GetGiftCard() error = %v", err)
+		}
+		if card.Balance != step.wantBalance {
+			t.Errorf("%s: balance = %.2f, want %.2f", step.name, card.Balance, step.wantBalance)
+		}
+	}
+
+	txns, err := shop.GiftCards.GetTransactions("GC-1")
+	if err != nil {
+		t.Fatalf("GetTransactions() error = %v", err)
+	}
+	want := []struct {
+		kind         string
+		amount       float64
+		balanceAfter float64
+	}{
+		{models.GiftCardIssue, 8, 8},
+		{models.GiftCardRedeem, -5, 3},
+		{models.GiftCardReload, 7, 10},
+		{models.GiftCardRedeem, -10, 0},
+		{models.GiftCardRefund, 5, 5},
+	}
+	if len(txns) != len(want) {
+		t.Fatalf("transactions = %+v, want %d", txns, len(want))
+	}
+	for i, w := range want {
+		if txns[i].Type != w.kind || txns[i].Amount != w.amount || txns[i].BalanceAfter != w.balanceAfter {
+			t.Errorf("transaction %d = %s %.2f (%.2f), want %s %.2f (%.2f)", i, txns[i].Type, txns[i].Amount, txns[i].BalanceAfter, w.kind, w.amount, w.balanceAfter)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hot-coffee/internal/dal"
+	"hot-coffee/models"
+	"sync"
+	"time"
+)
+
+// DefaultIdempotencyTTL is how long a stored response is replayed for.
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+const maxIdempotencyKeyLength = 255
+
+var (
+	ErrInvalidIdempotencyKey = models.NewError(models.ErrInvalidInput, "invalid idempotency key")
+	ErrIdempotencyMismatch   = errors.New("idempotency key reused with a different request")
+)
+
+type IdempotencyService struct {
+	Repo     dal.IdempotencyManager
+	TTL      time.Duration
+	mu       sync.Mutex
+	inFlight map[string]bool
+}
+
+func NewIdempotencyService(repo dal.IdempotencyManager, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{Repo: repo, TTL: ttl, inFlight: make(map[string]bool)}
+}
+
+// Begin claims key for a request. It returns the stored record with replay set
+// when the key has already been answered, and an error when the key is in use
+// by a different or still-running request. Callers that are not replaying must
+// call Complete or Release.
+func (s *IdempotencyService) Begin(key string, method string, path string, body []byte) (models.IdempotencyRecord, bool, error) {
+	if key == "" || len(key) > maxIdempotencyKeyLength {
+		return models.IdempotencyRecord{}, false, fmt.Errorf("%w: keys must be 1 to %d characters", ErrInvalidIdempotencyKey, maxIdempotencyKeyLength)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if err := s.Repo.PruneExpired(now); err != nil {
+		return models.IdempotencyRecord{}, false, err
+	}
+	record, ok, err := s.Repo.GetRecord(key)
+	if err != nil {
+		return models.IdempotencyRecord{}, false, err
+	}
+	if ok {
+		if record.Method != method || record.Path != path || record.RequestHash != hashRequest(body) {
+			return models.IdempotencyRecord{}, false, fmt.Errorf("%w: key '%s' was first used for %s %s", ErrIdempotencyMismatch, key, record.Method, record.Path)
+		}
+		return record, true, nil
+	}
+	if s.inFlight[key] {
+		return models.IdempotencyRecord{}, false, fmt.Errorf("%w: a request with idempotency key '%s' is still in progress", models.ErrConflict, key)
+	}
+	s.inFlight[key] = true
+	return models.IdempotencyRecord{}, false, nil
+}
+
+// Complete stores the response for key. Client errors are rejected before
+// anything changes, so they are not stored and a corrected retry with the same
+// key runs again. Server errors are stored like successes: the request may have
+// failed after charging a card or deducting stock, and running it again could
+// repeat that.
+func (s *IdempotencyService) Complete(key string, method string, path string, body []byte, status int, headers map[string]string, response []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.inFlight, key)
+	if status >= 400 && status < 500 {
+		return nil
+	}
+
+	now := time.Now()
+	return s.Repo.SaveRecord(models.IdempotencyRecord{
+		Key:         key,
+		Method:      method,
+		Path:        path,
+		RequestHash: hashRequest(body),
+		Status:      status,
+		Headers:     headers,
+		Body:        string(response),
+		CreatedAt:   now.Format(time.RFC3339),
+		ExpiresAt:   now.Add(s.TTL).Format(time.RFC3339),
+	})
+}
+
+func (s *IdempotencyService) Release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.inFlight, key)
+}
+
+func hashRequest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"hot-coffee/internal/dal"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyCompleteReplays(t *testing.T) {
+	tests := []struct {
+		status int
+		replay bool
+	}{
+		{http.StatusCreated, true},
+		{http.StatusUnprocessableEntity, false},
+		{http.StatusConflict, false},
+		// The charge or stock deduction may already have happened.
+		{http.StatusInternalServerError, true},
+		{http.StatusGatewayTimeout, true},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			repo := dal.NewJSONIdempotencyManager(filepath.Join(t.TempDir(), "idempotency.json"))
+			s := NewIdempotencyService(repo, time.Hour)
+			body := []byte(`{"amount":5}`)
+
+			if _, _, err := s.Begin("k1", http.MethodPost, "/orders/1/payments", body); err != nil {
+				t.Fatalf("Begin() error = %v", err)
+			}
+			if err := s.Complete("k1", http.MethodPost, "/orders/1/payments", body, tt.status, nil, []byte(`{}`)); err != nil {
+				t.Fatalf("Complete() error = %v", err)
+			}
+			record, replay, err := s.Begin("k1", http.MethodPost, "/orders/1/payments", body)
+			if err != nil {
+				t.Fatalf("Begin() retry error = %v", err)
+			}
+			if replay != tt.replay {
+				t.Errorf("retry replay = %v, want %v", replay, tt.replay)
+			}
+			if replay && record.Status != tt.status {
+				t.Errorf("replayed status = %d, want %d", record.Status, tt.status)
+			}
+		})
+	}
+}
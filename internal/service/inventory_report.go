@@ -0,0 +1,177 @@
+package service
+
+import (
+	"fmt"
+	"hot-coffee/models"
+	"math"
+	"sort"
+	"time"
+)
+
+const defaultVarianceTolerance = 5.0
+
+type InventoryRepository interface {
+	LoadInventory() ([]models.InventoryItem, error)
+	LoadMovements() ([]models.InventoryMovement, error)
+}
+
+// GetInventoryUsage compares the ingredients sold orders should have consumed
+// according to their recipes with what stock counts say was used. Actual usage
+// is opening count + received + adjustments - closing count, where the opening
+// count is the last one at or before from and the closing count the last one
+// before to, so counts are expected at period boundaries. Variance is what
+// remains after subtracting theoretical usage and recorded waste.
+func (s *ReportService) GetInventoryUsage(from string, to string, tolerance float64) (models.InventoryUsageReport, error) {
+	if tolerance < 0 {
+		return models.InventoryUsageReport{}, fmt.Errorf("%w: tolerance must not be negative", ErrInvalidQuery)
+	}
+	if tolerance == 0 {
+		tolerance = defaultVarianceTolerance
+	}
+	p, err := s.parsePeriod(from, to)
+	if err != nil {
+		return models.InventoryUsageReport{}, err
+	}
+
+	orders, err := s.orderRepo.LoadOrders()
+	if err != nil {
+		return models.InventoryUsageReport{}, err
+	}
+	menuItems, err := s.menuRepo.LoadMenuItems()
+	if err != nil {
+		return models.InventoryUsageReport{}, err
+	}
+	refunds, err := s.refundRepo.LoadRefunds()
+	if err != nil {
+		return models.InventoryUsageReport{}, err
+	}
+	inventory, err := s.inventoryRepo.LoadInventory()
+	if err != nil {
+		return models.InventoryUsageReport{}, err
+	}
+	movements, err := s.inventoryRepo.LoadMovements()
+	if err != nil {
+		return models.InventoryUsageReport{}, err
+	}
+
+	usage := make(map[string]*models.IngredientUsage)
+	entry := func(id string) *models.IngredientUsage {
+		u, ok := usage[id]
+		if !ok {
+			u = &models.IngredientUsage{IngredientID: id}
+			usage[id] = u
+		}
+		return u
+	}
+	for _, item := range inventory {
+		u := entry(item.IngredientID)
+		u.Name = item.Name
+		u.Unit = item.Unit
+	}
+
+	recipes := make(map[string][]models.MenuItemIngredient)
+	for _, item := range menuItems {
+		recipes[item.ID] = item.Ingredients
+	}
+
+	for _, order := range orders {
+		if !isSold(order.Status) {
+			continue
+		}
+		createdAt, err := time.Parse(time.RFC3339, order.CreatedAt)
+		if err != nil || !p.contains(createdAt) {
+			continue
+		}
+		for _, item := range order.Items {
+			for _, ing := range recipes[item.ProductID] {
+				entry(ing.IngredientID).Theoretical += ing.Quantity * float64(item.Quantity)
+			}
+		}
+	}
+
+	for _, refund := range refunds {
+		createdAt, err := time.Parse(time.RFC3339, refund.CreatedAt)
+		if err != nil || !p.contains(createdAt) {
+			continue
+		}
+		for _, line := range refund.Lines {
+			if !line.Restock {
+				continue
+			}
+			for _, ing := range recipes[line.ProductID] {
+				entry(ing.IngredientID).Theoretical -= ing.Quantity * float64(line.Quantity)
+			}
+		}
+	}
+
+	opening := make(map[string]float64)
+	closing := make(map[string]float64)
+	for _, movement := range movements {
+		createdAt, err := time.Parse(time.RFC3339, movement.CreatedAt)
+		if err != nil {
+			continue
+		}
+		isCount := movement.Type == models.MovementCount || movement.Type == models.MovementInitial
+		if isCount && !p.from.IsZero() && !createdAt.After(p.from) {
+			opening[movement.IngredientID] = movement.StockAfter
+			continue
+		}
+		if !p.contains(createdAt) {
+			continue
+		}
+		u := entry(movement.IngredientID)
+		switch movement.Type {
+		case models.MovementReceive:
+			u.Received += movement.Quantity
+		case models.MovementWaste:
+			u.Wasted -= movement.Quantity
+		case models.MovementAdjust:
+			u.Adjusted += movement.Quantity
+		case models.MovementCount, models.MovementInitial:
+			closing[movement.IngredientID] = movement.StockAfter
+		}
+	}
+
+	report := models.InventoryUsageReport{
+		From:        from,
+		To:          to,
+		TimeZone:    s.location.String(),
+		Tolerance:   tolerance,
+		Ingredients: []models.IngredientUsage{},
+	}
+	for id, u := range usage {
+		u.Theoretical = roundQuantity(u.Theoretical)
+		u.Received = roundQuantity(u.Received)
+		u.Wasted = roundQuantity(u.Wasted)
+		u.Adjusted = roundQuantity(u.Adjusted)
+
+		openingCount, hasOpening := opening[id]
+		closingCount, hasClosing := closing[id]
+		if hasOpening {
+			u.OpeningCount = &openingCount
+		}
+		if hasClosing {
+			u.ClosingCount = &closingCount
+		}
+		if hasOpening && hasClosing {
+			actual := roundQuantity(openingCount + u.Received + u.Adjusted - closingCount)
+			expected := u.Theoretical + u.Wasted
+			variance := roundQuantity(actual - expected)
+			u.ActualUsage = &actual
+			u.Variance = &variance
+			if expected > 0 {
+				percent := math.Round(variance/expected*10000) / 100
+				u.VariancePercent = &percent
+				u.Flagged = math.Abs(percent) > tolerance
+			} else {
+				u.Flagged = variance != 0
+			}
+		}
+		report.Ingredients = append(report.Ingredients, *u)
+	}
+
+	sort.Slice(report.Ingredients, func(i, j int) bool {
+		return report.Ingredients[i].IngredientID < report.Ingredients[j].IngredientID
+	})
+	return report, nil
+}
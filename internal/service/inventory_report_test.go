@@ -0,0 +1,102 @@
+package service
+
+import (
+	"errors"
+	"hot-coffee/models"
+	"testing"
+)
+
+func TestGetInventoryUsage(t *testing.T) {
+	tests := []struct {
+		name         string
+		closing      float64
+		tolerance    float64
+		restock      bool
+		wantTheory   float64
+		wantActual   float64
+		wantVariance float64
+		wantPercent  float64
+		wantFlagged  bool
+	}{
+		{name: "as expected", closing: 13.9, wantTheory: 0.6, wantActual: 1.1},
+		{name: "within tolerance", closing: 13.85, wantTheory: 0.6, wantActual: 1.15, wantVariance: 0.05, wantPercent: 4.55},
+		{name: "missing milk", closing: 13.8, wantTheory: 0.6, wantActual: 1.2, wantVariance: 0.1, wantPercent: 9.09, wantFlagged: true},
+		{name: "wider tolerance", closing: 13.8, tolerance: 10, wantTheory: 0.6, wantActual: 1.2, wantVariance: 0.1, wantPercent: 9.09},
+		{name: "surplus", closing: 14, wantTheory: 0.6, wantActual: 1, wantVariance: -0.1, wantPercent: -9.09, wantFlagged: true},
+		{name: "restocked refund", closing: 14.1, restock: true, wantTheory: 0.4, wantActual: 0.9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shop := newTestSalesShop(t)
+			inventory := shop.Orders.InventoryRepo
+			for _, movement := range []models.InventoryMovement{
+				{IngredientID: "milk", Type: models.MovementCount, StockAfter: 10, CreatedAt: "2026-03-01T00:00:00Z"},
+				{IngredientID: "milk", Type: models.MovementReceive, Quantity: 5, CreatedAt: "2026-03-03T08:00:00Z"},
+				{IngredientID: "milk", Type: models.MovementWaste, Quantity: -0.5, CreatedAt: "2026-03-04T08:00:00Z"},
+				{IngredientID: "milk", Type: models.MovementCount, StockAfter: tt.closing, CreatedAt: "2026-03-09T20:00:00Z"},
+			} {
+				if _, err := inventory.ApplyMovement(movement); err != nil {
+					t.Fatalf("ApplyMovement() error = %v", err)
+				}
+			}
+			if tt.restock {
+				orders, _ := shop.Orders.OrderRepo.GetAllOrders()
+				var lattes models.Order
+				for _, order := range orders {
+					if order.CreatedAt == "2026-03-02T09:15:00Z" {
+						lattes = order
+					}
+				}
+				refund := models.Refund{
+					ID:        lattes.ID + "-r1",
+					OrderID:   lattes.ID,
+					Lines:     []models.RefundLine{{ProductID: "latte", Quantity: 1, Amount: 5, Restock: true}},
+					Amount:    5,
+					CreatedAt: "2026-03-05T10:00:00Z",
+				}
+				if err := shop.Refunds.RefundRepo.AddRefund(refund); err != nil {
+					t.Fatalf("AddRefund() error = %v", err)
+				}
+			}
+
+			report, err := shop.Reports.GetInventoryUsage("2026-03-01", "2026-03-09", tt.tolerance)
+			if err != nil {
+				t.Fatalf("GetInventoryUsage() error = %v", err)
+			}
+			if len(report.Ingredients) != 1 {
+				t.Fatalf("ingredients = %+v, want milk only", report.Ingredients)
+			}
+			milk := report.Ingredients[0]
+			if milk.Theoretical != tt.wantTheory || milk.Received != 5 || milk.Wasted != 0.5 {
+				t.Errorf("theoretical %.2f, received %.2f, wasted %.2f, want %.2f, 5.00 and 0.50", milk.Theoretical, milk.Received, milk.Wasted, tt.wantTheory)
+			}
+			if milk.ActualUsage == nil || milk.Variance == nil || milk.VariancePercent == nil {
+				t.Fatalf("milk = %+v, want actual usage and variance", milk)
+			}
+			if *milk.ActualUsage != tt.wantActual || *milk.Variance != tt.wantVariance || *milk.VariancePercent != tt.wantPercent {
+				t.Errorf("actual %.2f, variance %.2f (%.2f%%), want %.2f, %.2f (%.2f%%)", *milk.ActualUsage, *milk.Variance, *milk.VariancePercent, tt.wantActual, tt.wantVariance, tt.wantPercent)
+			}
+			if milk.Flagged != tt.wantFlagged {
+				t.Errorf("flagged = %v, want %v", milk.Flagged, tt.wantFlagged)
+			}
+		})
+	}
+}
+
+func TestGetInventoryUsageWithoutCounts(t *testing.T) {
+	shop := newTestSalesShop(t)
+	report, err := shop.Reports.GetInventoryUsage("2026-03-01", "2026-03-09", 0)
+	if err != nil {
+		t.Fatalf("GetInventoryUsage() error = %v", err)
+	}
+	milk := report.Ingredients[0]
+	if milk.Theoretical != 0.6 || milk.ActualUsage != nil || milk.Variance != nil || milk.Flagged {
+		t.Errorf("milk = %+v, want 0.60 theoretical and no variance", milk)
+	}
+	if report.Tolerance != defaultVarianceTolerance {
+		t.Errorf("tolerance = %.2f, want %.2f", report.Tolerance, defaultVarianceTolerance)
+	}
+	if _, err := shop.Reports.GetInventoryUsage("", "", -1); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("GetInventoryUsage() error = %v, want %v", err, ErrInvalidQuery)
+	}
+}
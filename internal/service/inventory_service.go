@@ -1,11 +1,18 @@
 package service
 
 import (
+	"cmp"
 	"fmt"
 	"hot-coffee/internal/dal"
+	"hot-coffee/internal/validation"
 	"hot-coffee/models"
+	"math"
+	"strings"
+	"time"
 )
 
+var ErrInvalidInventory = models.NewError(models.ErrInvalidInput, "invalid inventory operation")
+
 type InventoryService struct {
 	InventoryRepo dal.InventoryManager
 	MenuRepo      dal.MenuManager
@@ -22,15 +29,87 @@ func (s *InventoryService) GetAllInventoryItems() ([]models.InventoryItem, error
 	return s.InventoryRepo.GetAllInventoryItems()
 }
 
-func (s *InventoryService) AddNewInventoryItem(item models.InventoryItem) error {
+// InventoryFilter narrows an inventory listing. Query matches part of the
+// name; MaxQuantity, when set, keeps items at or below that stock level.
+type InventoryFilter struct {
+	Query       string
+	Unit        string
+	MaxQuantity *float64
+}
+
+var inventorySorts = map[string]func(a, b models.InventoryItem) int{
+	"ingredient_id": func(a, b models.InventoryItem) int {
+		return cmp.Compare(a.IngredientID, b.IngredientID)
+	},
+	"name": func(a, b models.InventoryItem) int {
+		return compareStrings(a.Name, b.Name)
+	},
+	"quantity": func(a, b models.InventoryItem) int {
+		return cmp.Compare(a.Quantity, b.Quantity)
+	},
+}
+
+func (s *InventoryService) ListInventoryItems(filter InventoryFilter, opts ListOptions) (Page[models.InventoryItem], error) {
+	items, err := s.InventoryRepo.GetAllInventoryItems()
+	if err != nil {
+		return Page[models.InventoryItem]{}, err
+	}
+
+	matched := []models.InventoryItem{}
+	for _, item := range items {
+		if filter.Query != "" && !containsFold(item.Name, filter.Query) {
+			continue
+		}
+		if filter.Unit != "" && !strings.EqualFold(item.Unit, filter.Unit) {
+			continue
+		}
+		if filter.MaxQuantity != nil && item.Quantity > *filter.MaxQuantity {
+			continue
+		}
+		matched = append(matched, item)
+	}
+	return paginate(matched, opts, inventorySorts)
+}
+
+func (s *InventoryService) AddNewInventoryItem(item models.InventoryItem) (models.InventoryItem, error) {
+	if err := validation.NewInventoryItem(item); err != nil {
+		return models.InventoryItem{}, err
+	}
 	return s.InventoryRepo.AddNewInventoryItem(item)
 }
 
-func (s *InventoryService) UpdateInventoryItem(item models.InventoryItem) error {
-	return s.InventoryRepo.UpdateInventoryItem(item)
+func (s *InventoryService) UpdateInventoryItem(item models.InventoryItem) (models.InventoryItem, error) {
+	if err := validation.InventoryItem(item); err != nil {
+		return models.InventoryItem{}, err
+	}
+	if err := s.InventoryRepo.UpdateInventoryItem(item); err != nil {
+		return models.InventoryItem{}, err
+	}
+	return s.InventoryRepo.GetInventoryItem(item.IngredientID)
 }
 
-func (s *InventoryService) DeleteInventoryItem(id string) error {
+// PatchInventoryItem applies a JSON merge patch to the stored item and saves
+// the result as UpdateInventoryItem would. The item must still be at version,
+// or when version is zero at the version the patch was applied to, so a
+// concurrent write is never silently overwritten.
+func (s *InventoryService) PatchInventoryItem(id string, patch []byte, version int) (models.InventoryItem, error) {
+	current, err := s.InventoryRepo.GetInventoryItem(id)
+	if err != nil {
+		return models.InventoryItem{}, err
+	}
+	var item models.InventoryItem
+	if err := applyPatch(current, patch, &item); err != nil {
+		return models.InventoryItem{}, err
+	}
+	item.IngredientID = id
+	if version == 0 {
+		version = current.Version
+	}
+	item.Version = version
+	return s.UpdateInventoryItem(item)
+}
+
+func (s *InventoryService) DeleteInventoryItem(id string, version int) error {
 	menuItems, err := s.MenuRepo.GetAllMenuItems()
 	if err != nil {
 		return fmt.Errorf("failed to load menu items: %w", err)
@@ -39,14 +118,78 @@ func (s *InventoryService) DeleteInventoryItem(id string) error {
 	for _, menuItem := range menuItems {
 		for _, ingredient := range menuItem.Ingredients {
 			if ingredient.IngredientID == id {
-				return fmt.Errorf("cannot delete inventory item '%s': used in menu item '%s'", id, menuItem.Name)
+				return fmt.Errorf("%w: cannot delete inventory item '%s': used in menu item '%s'", models.ErrConflict, id, menuItem.Name)
 			}
 		}
 	}
 
-	return s.InventoryRepo.DeleteInventoryItem(id)
+	return s.InventoryRepo.DeleteInventoryItem(id, version)
 }
 
 func (s *InventoryService) GetInventoryItem(ingredientID string) (models.InventoryItem, error) {
 	return s.InventoryRepo.GetInventoryItem(ingredientID)
 }
+
+func (s *InventoryService) AddMovement(ingredientID string, req models.InventoryMovementRequest) (models.InventoryMovement, error) {
+	if _, err := s.InventoryRepo.GetInventoryItem(ingredientID); err != nil {
+		return models.InventoryMovement{}, err
+	}
+
+	quantity := roundQuantity(req.Quantity)
+	switch req.Type {
+	case models.MovementReceive:
+		if quantity <= 0 {
+			return models.InventoryMovement{}, fmt.Errorf("%w: received quantity must be positive", ErrInvalidInventory)
+		}
+	case models.MovementWaste:
+		if quantity <= 0 {
+			return models.InventoryMovement{}, fmt.Errorf("%w: wasted quantity must be positive", ErrInvalidInventory)
+		}
+		quantity = -quantity
+	case models.MovementAdjust:
+		if quantity == 0 {
+			return models.InventoryMovement{}, fmt.Errorf("%w: adjustment cannot be zero", ErrInvalidInventory)
+		}
+		if req.Reason == "" {
+			return models.InventoryMovement{}, fmt.Errorf("%w: adjustments require a reason", ErrInvalidInventory)
+		}
+	default:
+		return models.InventoryMovement{}, fmt.Errorf("%w: unknown movement type '%s'", ErrInvalidInventory, req.Type)
+	}
+
+	return s.InventoryRepo.ApplyMovement(models.InventoryMovement{
+		IngredientID: ingredientID,
+		Type:         req.Type,
+		Quantity:     quantity,
+		Reason:       req.Reason,
+		CreatedAt:    time.Now().Format(time.RFC3339),
+	})
+}
+
+func (s *InventoryService) RecordCount(ingredientID string, req models.InventoryCountRequest) (models.InventoryMovement, error) {
+	if _, err := s.InventoryRepo.GetInventoryItem(ingredientID); err != nil {
+		return models.InventoryMovement{}, err
+	}
+	if req.Counted < 0 {
+		return models.InventoryMovement{}, fmt.Errorf("%w: counted quantity cannot be negative", ErrInvalidInventory)
+	}
+
+	return s.InventoryRepo.ApplyMovement(models.InventoryMovement{
+		IngredientID: ingredientID,
+		Type:         models.MovementCount,
+		StockAfter:   roundQuantity(req.Counted),
+		Reason:       req.Reason,
+		CreatedAt:    time.Now().Format(time.RFC3339),
+	})
+}
+
+func (s *InventoryService) GetMovements(ingredientID string) ([]models.InventoryMovement, error) {
+	if _, err := s.InventoryRepo.GetInventoryItem(ingredientID); err != nil {
+		return nil, err
+	}
+	return s.InventoryRepo.GetMovements(ingredientID)
+}
+
+func roundQuantity(quantity float64) float64 {
+	return math.Round(quantity*1000) / 1000
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"errors"
+	"hot-coffee/models"
+	"testing"
+)
+
+func TestAddNewInventoryItemID(t *testing.T) {
+	tests := []struct {
+		name    string
+		item    models.InventoryItem
+		want    string
+		wantErr error
+	}{
+		{name: "given", item: models.InventoryItem{IngredientID: "beans", Name: "Beans", Unit: "g", Quantity: 500}, want: "beans"},
+		{name: "generated", item: models.InventoryItem{Name: "Oat Milk", Unit: "l", Quantity: 2}, want: "oat-milk"},
+		{name: "generated next to a namesake", item: models.InventoryItem{Name: "Milk", Unit: "l", Quantity: 2}, want: "milk-2"},
+		{name: "duplicate", item: models.InventoryItem{IngredientID: "milk", Name: "Whole milk", Unit: "l", Quantity: 2}, wantErr: models.ErrConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shop := newTestShop(t)
+			created, err := shop.Inventory.AddNewInventoryItem(tt.item)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AddNewInventoryItem() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if got := milkLeft(t, shop.Orders); got != 10 {
+					t.Errorf("milk = %.2f, want the original 10.00", got)
+				}
+				return
+			}
+			if created.IngredientID != tt.want || created.Version != 1 {
+				t.Errorf("AddNewInventoryItem() = %s at version %d, want %s at version 1", created.IngredientID, created.Version, tt.want)
+			}
+			movements, err := shop.Inventory.InventoryRepo.GetMovements(tt.want)
+			if err != nil || len(movements) != 1 || movements[0].Type != models.MovementInitial || movements[0].StockAfter != tt.item.Quantity {
+				t.Errorf("movements = %+v, %v, want one initial count of %.2f", movements, err, tt.item.Quantity)
+			}
+		})
+	}
+}
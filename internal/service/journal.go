@@ -0,0 +1,252 @@
+package service
+
+import (
+	"fmt"
+	"hot-coffee/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+type ChartRepository interface {
+	LoadChart() (models.ChartOfAccounts, error)
+}
+
+type journalKey struct {
+	code        string
+	description string
+	debit       bool
+}
+
+type journalDay struct {
+	lines map[journalKey]*models.JournalLine
+}
+
+func (d *journalDay) post(account models.Account, description string, debit float64, credit float64) {
+	if debit < 0 {
+		debit, credit = 0, credit-debit
+	}
+	if credit < 0 {
+		debit, credit = debit-credit, 0
+	}
+	if debit == 0 && credit == 0 {
+		return
+	}
+	key := journalKey{code: account.Code, description: description, debit: debit > 0}
+	line, ok := d.lines[key]
+	if !ok {
+		line = &models.JournalLine{Account: account, Description: description}
+		d.lines[key] = line
+	}
+	line.Debit += debit
+	line.Credit += credit
+}
+
+// ExportJournal turns sales, payments, refunds, gift card sales and cost of
+// goods into one balanced journal entry per shop-local day. Sales and cost of
+// goods are booked on the order's creation day, payments and refunds on their
+// own day, and everything runs through the receivable account so each side
+// balances even when an order is paid on a later day. Refunds reverse the
+// sales tax they gave back. Gift card value sold is owed to the card holder,
+// so it is credited to the account mapped for the gift card tender, the same
+// one gift card payments and refunds go through. Cost of goods uses the cost
+// recorded on each order line; orders taken before costs were recorded fall
+// back to today's ingredient costs, so only their lines change when costs do.
+func (s *ReportService) ExportJournal(from string, to string, emit func(models.JournalLine) error) error {
+	p, err := s.parsePeriod(from, to)
+	if err != nil {
+		return err
+	}
+
+	chart, err := s.chartRepo.LoadChart()
+	if err != nil {
+		return err
+	}
+	orders, err := s.orderRepo.LoadOrders()
+	if err != nil {
+		return err
+	}
+	menuItems, err := s.menuRepo.LoadMenuItems()
+	if err != nil {
+		return err
+	}
+	payments, err := s.paymentRepo.LoadPayments()
+	if err != nil {
+		return err
+	}
+	refunds, err := s.refundRepo.LoadRefunds()
+	if err != nil {
+		return err
+	}
+	inventory, err := s.inventoryRepo.LoadInventory()
+	if err != nil {
+		return err
+	}
+	giftCardTxns, err := s.giftCardRepo.LoadGiftCardTransactions()
+	if err != nil {
+		return err
+	}
+
+	productCost := productCosts(menuItems, inventory)
+	orderByID := make(map[string]models.Order, len(orders))
+	for _, order := range orders {
+		orderByID[order.ID] = order
+	}
+	tenderAccount := func(tender string) (models.Account, error) {
+		account, ok := chart.Tenders[tender]
+		if !ok || account.Code == "" {
+			return models.Account{}, fmt.Errorf("no account mapped for tender '%s'", tender)
+		}
+		return account, nil
+	}
+
+	days := make(map[string]*journalDay)
+	dayOf := func(timestamp string) (*journalDay, bool) {
+		t, err := time.Parse(time.RFC3339, timestamp)
+		if err != nil || !p.contains(t) {
+			return nil, false
+		}
+		date := t.In(s.location).Format(time.DateOnly)
+		d, ok := days[date]
+		if !ok {
+			d = &journalDay{lines: make(map[journalKey]*models.JournalLine)}
+			days[date] = d
+		}
+		return d, true
+	}
+
+	for _, order := range orders {
+		if !isSold(order.Status) {
+			continue
+		}
+		d, ok := dayOf(order.CreatedAt)
+		if !ok {
+			continue
+		}
+		var net, cost float64
+		for _, item := range order.Items {
+			line := roundMoney(unitPrice(item, menuItems) * float64(item.Quantity))
+			d.post(revenueAccount(chart, itemCategory(item, menuItems)), "Sales", 0, line)
+			net += line
+			cost += unitCost(item, productCost) * float64(item.Quantity)
+		}
+		discounts := orderDiscounts(order)
+		d.post(chart.Discounts, "Discounts", discounts, 0)
+		d.post(chart.SalesTax, "Sales tax", 0, order.Tax)
+		d.post(chart.Receivable, "Sales on account", net-discounts+order.Tax, 0)
+		cost = roundMoney(cost)
+		d.post(chart.CostOfGoods, "Cost of goods sold", cost, 0)
+		d.post(chart.Inventory, "Inventory consumed", 0, cost)
+	}
+
+	for _, payment := range payments {
+		d, ok := dayOf(payment.CreatedAt)
+		if !ok {
+			continue
+		}
+		account, err := tenderAccount(payment.Tender)
+		if err != nil {
+			return err
+		}
+		d.post(account, "Payments received ("+payment.Tender+")", payment.Amount+payment.Tip, 0)
+		d.post(chart.Receivable, "Payments applied", 0, payment.Amount)
+		d.post(chart.Tips, "Tips collected", 0, payment.Tip)
+	}
+
+	for _, refund := range fillRefundTax(refunds, orders, menuItems) {
+		d, ok := dayOf(refund.CreatedAt)
+		if !ok {
+			continue
+		}
+		account, err := tenderAccount(refund.Tender)
+		if err != nil {
+			return err
+		}
+		d.post(chart.Refunds, "Refunds", refundNet(refund), 0)
+		d.post(chart.SalesTax, "Sales tax refunded", refund.Tax, 0)
+		d.post(account, "Refunds paid ("+refund.Tender+")", 0, refund.Amount)
+		var restocked float64
+		for _, line := range refund.Lines {
+			if line.Restock {
+				restocked += refundedUnitCost(orderByID[refund.OrderID], line.ProductID, productCost) * float64(line.Quantity)
+			}
+		}
+		restocked = roundMoney(restocked)
+		d.post(chart.Inventory, "Restocked returns", restocked, 0)
+		d.post(chart.CostOfGoods, "Restocked returns", 0, restocked)
+	}
+
+	for _, txn := range giftCardTxns {
+		// Only sales carry a tender; redemptions and refunds to a card are
+		// already journaled as payments and refunds.
+		if txn.Tender == "" {
+			continue
+		}
+		d, ok := dayOf(txn.CreatedAt)
+		if !ok {
+			continue
+		}
+		account, err := tenderAccount(txn.Tender)
+		if err != nil {
+			return err
+		}
+		liability, err := tenderAccount(models.TenderGiftCard)
+		if err != nil {
+			return err
+		}
+		d.post(account, "Gift cards sold ("+txn.Tender+")", txn.Amount, 0)
+		d.post(liability, "Gift cards sold", 0, txn.Amount)
+	}
+
+	dates := make([]string, 0, len(days))
+	for date := range days {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+
+	for _, date := range dates {
+		entryID := "JE-" + strings.ReplaceAll(date, "-", "")
+		lines := make([]models.JournalLine, 0, len(days[date].lines))
+		for _, line := range days[date].lines {
+			line.Date = date
+			line.EntryID = entryID
+			line.Debit = roundMoney(line.Debit)
+			line.Credit = roundMoney(line.Credit)
+			lines = append(lines, *line)
+		}
+		sort.Slice(lines, func(i, j int) bool {
+			a, b := lines[i], lines[j]
+			if (a.Debit > 0) != (b.Debit > 0) {
+				return a.Debit > 0
+			}
+			if a.Account.Code != b.Account.Code {
+				return a.Account.Code < b.Account.Code
+			}
+			return a.Description < b.Description
+		})
+		for _, line := range lines {
+			if err := emit(line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// refundedUnitCost finds what a returned product cost when its order was
+// taken.
+func refundedUnitCost(order models.Order, productID string, productCost map[string]float64) float64 {
+	for _, item := range order.Items {
+		if item.ProductID == productID {
+			return unitCost(item, productCost)
+		}
+	}
+	return productCost[productID]
+}
+
+func revenueAccount(chart models.ChartOfAccounts, category string) models.Account {
+	if account, ok := chart.RevenueByCategory[category]; ok && category != "" {
+		return account
+	}
+	return chart.Revenue
+}
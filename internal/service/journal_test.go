@@ -0,0 +1,135 @@
+package service
+
+import (
+	"errors"
+	"hot-coffee/models"
+	"testing"
+	"time"
+)
+
+func testChart() models.ChartOfAccounts {
+	return models.ChartOfAccounts{
+		Revenue:           models.Account{Code: "4000", Name: "Sales"},
+		RevenueByCategory: map[string]models.Account{"coffee": {Code: "4010", Name: "Coffee sales"}},
+		Discounts:         models.Account{Code: "4800", Name: "Discounts"},
+		Refunds:           models.Account{Code: "4900", Name: "Refunds"},
+		SalesTax:          models.Account{Code: "2200", Name: "Sales tax payable"},
+		Tips:              models.Account{Code: "2300", Name: "Tips payable"},
+		Receivable:        models.Account{Code: "1100", Name: "Receivable"},
+		CostOfGoods:       models.Account{Code: "5000", Name: "Cost of goods sold"},
+		Inventory:         models.Account{Code: "1200", Name: "Inventory"},
+		Tenders: map[string]models.Account{
+			models.TenderCash:     {Code: "1000", Name: "Cash"},
+			models.TenderCard:     {Code: "1010", Name: "Card clearing"},
+			models.TenderGiftCard: {Code: "2100", Name: "Gift card liability"},
+		},
+	}
+}
+
+// journalBalances exports the journal and returns each account's debits
+// less credits, failing when an entry does not balance.
+func journalBalances(t *testing.T, s *ReportService, from string, to string) map[string]float64 {
+	t.Helper()
+	balances := make(map[string]float64)
+	entries := make(map[string]float64)
+	err := s.ExportJournal(from, to, func(line models.JournalLine) error {
+		balances[line.Account.Code] = roundMoney(balances[line.Account.Code] + line.Debit - line.Credit)
+		entries[line.EntryID] = roundMoney(entries[line.EntryID] + line.Debit - line.Credit)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportJournal() error = %v", err)
+	}
+	for id, diff := range entries {
+		if diff != 0 {
+			t.Errorf("entry %s is off by %.2f", id, diff)
+		}
+	}
+	return balances
+}
+
+func TestExportJournal(t *testing.T) {
+	shop := newTestShop(t)
+	if _, err := shop.Accounting.UpdateChart(testChart()); err != nil {
+		t.Fatalf("UpdateChart() error = %v", err)
+	}
+	shop.Orders.TaxRate = 0.1
+
+	lattes := shop.sell(t, models.Order{Items: []models.OrderItem{{ProductID: "latte", Quantity: 2}}}, models.TenderCash)
+	tea, err := shop.Orders.CreateOrder(models.Order{CustomerName: "Bob", Items: []models.OrderItem{{ProductID: "tea", Quantity: 1}}})
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	if _, err := shop.Payments.AddPayment(tea.ID, models.Payment{Tender: models.TenderCard, CardToken: "tok_visa", Amount: 3.3, Tip: 1}); err != nil {
+		t.Fatalf("AddPayment() error = %v", err)
+	}
+	if _, err := shop.Refunds.RefundOrder(lattes.ID, models.RefundRequest{Lines: []models.RefundLine{{ProductID: "latte", Quantity: 1, Restock: true}}}); err != nil {
+		t.Fatalf("RefundOrder() error = %v", err)
+	}
+	if _, err := shop.GiftCards.IssueGiftCard(models.GiftCardAmountRequest{Amount: 20, Tender: models.TenderCash}); err != nil {
+		t.Fatalf("IssueGiftCard() error = %v", err)
+	}
+
+	balances := journalBalances(t, shop.Reports, "", "")
+	tests := []struct {
+		account string
+		want    float64
+	}{
+		{account: "1000", want: 25.5}, // 11.00 sale - 5.50 refund + 20.00 gift card
+		{account: "1010", want: 4.3},
+		{account: "1100", want: 0},
+		{account: "1200", want: -0.2},
+		{account: "2100", want: -20},
+		{account: "2200", want: -0.8},
+		{account: "2300", want: -1},
+		{account: "4000", want: -3},
+		{account: "4010", want: -10},
+		{account: "4900", want: 5},
+		{account: "5000", want: 0.2},
+	}
+	for _, tt := range tests {
+		if got := balances[tt.account]; got != tt.want {
+			t.Errorf("account %s balance = %.2f, want %.2f", tt.account, got, tt.want)
+		}
+	}
+	if len(balances) != len(tests) {
+		t.Errorf("balances = %v, want only %d accounts", balances, len(tests))
+	}
+
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format(time.DateOnly)
+	if got := journalBalances(t, shop.Reports, tomorrow, ""); len(got) != 0 {
+		t.Errorf("journal from tomorrow = %v, want nothing", got)
+	}
+}
+
+func TestExportJournalUnmappedTender(t *testing.T) {
+	shop := newTestShop(t)
+	shop.sell(t, models.Order{Items: []models.OrderItem{{ProductID: "tea", Quantity: 1}}}, models.TenderCash)
+	err := shop.Reports.ExportJournal("", "", func(models.JournalLine) error { return nil })
+	if err == nil {
+		t.Error("ExportJournal() error = nil, want an error for the unmapped cash tender")
+	}
+}
+
+func TestUpdateChart(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(*models.ChartOfAccounts)
+		wantErr error
+	}{
+		{name: "complete", edit: func(*models.ChartOfAccounts) {}},
+		{name: "no revenue account", edit: func(c *models.ChartOfAccounts) { c.Revenue.Code = " " }, wantErr: ErrInvalidAccounting},
+		{name: "no gift card account", edit: func(c *models.ChartOfAccounts) { delete(c.Tenders, models.TenderGiftCard) }, wantErr: ErrInvalidAccounting},
+		{name: "blank category account", edit: func(c *models.ChartOfAccounts) { c.RevenueByCategory["tea"] = models.Account{Name: "Tea"} }, wantErr: ErrInvalidAccounting},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestShop(t).Accounting
+			chart := testChart()
+			tt.edit(&chart)
+			if _, err := s.UpdateChart(chart); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateChart() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"hot-coffee/models"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidQuery marks a bad query parameter on a listing or report.
+var ErrInvalidQuery = models.NewError(models.ErrInvalidInput, "invalid query")
+
+const MaxPageLimit = 500
+
+// ListOptions selects one page of a collection. A zero Limit returns every
+// item from Offset on, so lists stay complete for clients that do not page.
+// Sort names a sortable field, prefixed with "-" for descending order; an
+// empty Sort keeps stored order. Fields limits each item to the named JSON
+// fields.
+type ListOptions struct {
+	Limit  int
+	Offset int
+	Sort   string
+	Fields []string
+}
+
+type Page[T any] struct {
+	Items  []T
+	Total  int
+	Limit  int
+	Offset int
+}
+
+// HasNext reports whether items remain after this page.
+func (p Page[T]) HasNext() bool {
+	return p.Offset+len(p.Items) < p.Total
+}
+
+// paginate sorts the already filtered items and cuts out the requested page.
+// Sorting is stable, so equal keys keep their stored order and pages do not
+// overlap between requests.
+func paginate[T any](items []T, opts ListOptions, sorts map[string]func(a, b T) int) (Page[T], error) {
+	if opts.Limit < 0 || opts.Limit > MaxPageLimit {
+		return Page[T]{}, fmt.Errorf("%w: limit must be between 0 and %d", ErrInvalidQuery, MaxPageLimit)
+	}
+	if opts.Offset < 0 {
+		return Page[T]{}, fmt.Errorf("%w: offset cannot be negative", ErrInvalidQuery)
+	}
+	if err := checkFields[T](opts.Fields); err != nil {
+		return Page[T]{}, err
+	}
+
+	if opts.Sort != "" {
+		key, desc := strings.CutPrefix(opts.Sort, "-")
+		compare, ok := sorts[key]
+		if !ok {
+			return Page[T]{}, fmt.Errorf("%w: cannot sort by '%s' (use one of %s)", ErrInvalidQuery, key, strings.Join(sortedKeys(sorts), ", "))
+		}
+		items = slices.Clone(items)
+		if desc {
+			slices.SortStableFunc(items, func(a, b T) int { return compare(b, a) })
+		} else {
+			slices.SortStableFunc(items, compare)
+		}
+	}
+
+	page := Page[T]{Items: []T{}, Total: len(items), Limit: opts.Limit, Offset: opts.Offset}
+	if opts.Offset < len(items) {
+		end := len(items)
+		if opts.Limit > 0 {
+			end = min(opts.Offset+opts.Limit, len(items))
+		}
+		page.Items = items[opts.Offset:end]
+	}
+	return page, nil
+}
+
+// SelectFields projects each item onto the given JSON fields. Fields an item
+// omits from its JSON are left out of its projection.
+func SelectFields[T any](items []T, fields []string) ([]map[string]json.RawMessage, error) {
+	if err := checkFields[T](fields); err != nil {
+		return nil, err
+	}
+	result := make([]map[string]json.RawMessage, 0, len(items))
+	for _, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, err
+		}
+		selected := make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			if v, ok := all[field]; ok {
+				selected[field] = v
+			}
+		}
+		result = append(result, selected)
+	}
+	return result, nil
+}
+
+func checkFields[T any](fields []string) error {
+	known := jsonFields(reflect.TypeFor[T]())
+	for _, field := range fields {
+		if !slices.Contains(known, field) {
+			return fmt.Errorf("%w: unknown field '%s' (use one of %s)", ErrInvalidQuery, field, strings.Join(known, ", "))
+		}
+	}
+	return nil
+}
+
+func jsonFields(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func containsFold(s string, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func compareStrings(a string, b string) int {
+	return cmp.Compare(strings.ToLower(a), strings.ToLower(b))
+}
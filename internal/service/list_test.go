@@ -0,0 +1,95 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hot-coffee/models"
+	"reflect"
+	"testing"
+)
+
+func TestPaginate(t *testing.T) {
+	items := make([]models.MenuItem, 120)
+	for i := range items {
+		items[i] = models.MenuItem{ID: fmt.Sprintf("p%03d", i), Name: fmt.Sprintf("Item %d", i%3), Price: float64(i % 5)}
+	}
+
+	tests := []struct {
+		name      string
+		opts      ListOptions
+		wantFirst string
+		wantLen   int
+		wantNext  bool
+	}{
+		{name: "no limit returns everything", opts: ListOptions{}, wantFirst: "p000", wantLen: 120},
+		{name: "offset without limit returns the rest", opts: ListOptions{Offset: 100}, wantFirst: "p100", wantLen: 20},
+		{name: "first page", opts: ListOptions{Limit: 50}, wantFirst: "p000", wantLen: 50, wantNext: true},
+		{name: "last page", opts: ListOptions{Limit: 50, Offset: 100}, wantFirst: "p100", wantLen: 20},
+		{name: "past the end", opts: ListOptions{Limit: 10, Offset: 200}, wantLen: 0},
+		{name: "descending", opts: ListOptions{Limit: 1, Sort: "-product_id"}, wantFirst: "p119", wantLen: 1, wantNext: true},
+		// Equal prices keep their stored order.
+		{name: "stable sort", opts: ListOptions{Limit: 2, Offset: 1, Sort: "price"}, wantFirst: "p005", wantLen: 2, wantNext: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := paginate(items, tt.opts, menuSorts)
+			if err != nil {
+				t.Fatalf("paginate() error = %v", err)
+			}
+			if len(page.Items) != tt.wantLen || page.Total != len(items) {
+				t.Fatalf("paginate() = %d of %d items, want %d of %d", len(page.Items), page.Total, tt.wantLen, len(items))
+			}
+			if tt.wantLen > 0 && page.Items[0].ID != tt.wantFirst {
+				t.Errorf("first item = %s, want %s", page.Items[0].ID, tt.wantFirst)
+			}
+			if page.HasNext() != tt.wantNext {
+				t.Errorf("HasNext() = %v, want %v", page.HasNext(), tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestPaginatePagesCoverList(t *testing.T) {
+	items := make([]models.MenuItem, 23)
+	for i := range items {
+		items[i] = models.MenuItem{ID: fmt.Sprintf("p%02d", i), Category: []string{"tea", "coffee"}[i%2]}
+	}
+
+	var got []models.MenuItem
+	opts := ListOptions{Limit: 5, Sort: "category"}
+	for {
+		page, err := paginate(items, opts, menuSorts)
+		if err != nil {
+			t.Fatalf("paginate() error = %v", err)
+		}
+		got = append(got, page.Items...)
+		if !page.HasNext() {
+			break
+		}
+		opts.Offset += opts.Limit
+	}
+	all, _ := paginate(items, ListOptions{Sort: "category"}, menuSorts)
+	if !reflect.DeepEqual(got, all.Items) {
+		t.Errorf("pages joined = %v, want %v", got, all.Items)
+	}
+}
+
+func TestPaginateInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opts ListOptions
+	}{
+		{name: "negative limit", opts: ListOptions{Limit: -1}},
+		{name: "limit too large", opts: ListOptions{Limit: MaxPageLimit + 1}},
+		{name: "negative offset", opts: ListOptions{Offset: -1}},
+		{name: "unknown sort", opts: ListOptions{Sort: "colour"}},
+		{name: "unknown field", opts: ListOptions{Fields: []string{"colour"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := paginate([]models.MenuItem{}, tt.opts, menuSorts); !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("paginate() error = %v, want %v", err, ErrInvalidQuery)
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package service
+
+import (
+	"fmt"
+	"hot-coffee/internal/dal"
+	"hot-coffee/models"
+	"math"
+	"time"
+)
+
+var ErrInvalidLoyalty = models.NewError(models.ErrInvalidInput, "invalid loyalty operation")
+
+type LoyaltyService struct {
+	LoyaltyRepo  dal.LoyaltyManager
+	CustomerRepo dal.CustomerManager
+}
+
+func NewLoyaltyService(loyaltyRepo dal.LoyaltyManager, customerRepo dal.CustomerManager) *LoyaltyService {
+	return &LoyaltyService{
+		LoyaltyRepo:  loyaltyRepo,
+		CustomerRepo: customerRepo,
+	}
+}
+
+func (s *LoyaltyService) GetProgram() (models.LoyaltyProgram, error) {
+	return s.LoyaltyRepo.GetProgram()
+}
+
+func (s *LoyaltyService) UpdateProgram(program models.LoyaltyProgram) (models.LoyaltyProgram, error) {
+	if program.PointsPerCurrencyUnit < 0 || program.PointsPerItem < 0 {
+		return models.LoyaltyProgram{}, fmt.Errorf("%w: earn rates cannot be negative", ErrInvalidLoyalty)
+	}
+	seen := make(map[string]bool)
+	for _, reward := range program.Rewards {
+		if reward.ID == "" || reward.ProductID == "" {
+			return models.LoyaltyProgram{}, fmt.Errorf("%w: rewards need a reward_id and product_id", ErrInvalidLoyalty)
+		}
+		if seen[reward.ID] {
+			return models.LoyaltyProgram{}, fmt.Errorf("%w: duplicate reward '%s'", ErrInvalidLoyalty, reward.ID)
+		}
+		if reward.PointsCost <= 0 {
+			return models.LoyaltyProgram{}, fmt.Errorf("%w: reward '%s' must cost at least one point", ErrInvalidLoyalty, reward.ID)
+		}
+		seen[reward.ID] = true
+	}
+	if program.Rewards == nil {
+		program.Rewards = []models.LoyaltyReward{}
+	}
+	if err := s.LoyaltyRepo.UpdateProgram(program); err != nil {
+		return models.LoyaltyProgram{}, err
+	}
+	return program, nil
+}
+
+func (s *LoyaltyService) GetAccount(customerID string) (models.LoyaltyAccount, error) {
+	if _, err := s.CustomerRepo.GetCustomer(customerID); err != nil {
+		return models.LoyaltyAccount{}, err
+	}
+	entries, err := s.LoyaltyRepo.GetEntriesByCustomer(customerID)
+	if err != nil {
+		return models.LoyaltyAccount{}, err
+	}
+
+	account := models.LoyaltyAccount{CustomerID: customerID, Entries: []models.LoyaltyEntry{}}
+	for _, e := range entries {
+		account.Balance += e.Points
+		account.Entries = append(account.Entries, e)
+	}
+	return account, nil
+}
+
+// ApplyReward turns order.RedeemRewardID into a discount line for one unit
+// of the reward product. Points are only deducted by RecordRedemption once
+// the order has been stored; callers hold the customer's lock in between.
+func (s *LoyaltyService) ApplyReward(order *models.Order, menuItems []models.MenuItem) error {
+	if order.RedeemRewardID == "" {
+		return nil
+	}
+	if order.CustomerID == "" {
+		return fmt.Errorf("%w: a customer_id is required to redeem rewards", ErrInvalidLoyalty)
+	}
+
+	program, err := s.LoyaltyRepo.GetProgram()
+	if err != nil {
+		return err
+	}
+	var reward *models.LoyaltyReward
+	for i := range program.Rewards {
+		if program.Rewards[i].ID == order.RedeemRewardID {
+			reward = &program.Rewards[i]
+			break
+		}
+	}
+	if reward == nil {
+		return fmt.Errorf("%w: unknown reward '%s'", ErrInvalidLoyalty, order.RedeemRewardID)
+	}
+
+	account, err := s.GetAccount(order.CustomerID)
+	if err != nil {
+		return err
+	}
+	if account.Balance < reward.PointsCost {
+		return fmt.Errorf("%w: reward '%s' costs %d points, customer has %d", ErrInvalidLoyalty, reward.ID, reward.PointsCost, account.Balance)
+	}
+
+	inOrder := false
+	for _, item := range order.Items {
+		if item.ProductID == reward.ProductID && item.Quantity > 0 {
+			inOrder = true
+			break
+		}
+	}
+	if !inOrder {
+		return fmt.Errorf("%w: reward '%s' requires '%s' in the order", ErrInvalidLoyalty, reward.ID, reward.ProductID)
+	}
+
+	var price float64
+	for _, item := range menuItems {
+		if item.ID == reward.ProductID {
+			price = item.Price
+			break
+		}
+	}
+	order.Discounts = append(order.Discounts, models.OrderDiscount{
+		Type:        "loyalty",
+		RewardID:    reward.ID,
+		ProductID:   reward.ProductID,
+		Description: reward.Name,
+		Amount:      roundMoney(price),
+	})
+	return nil
+}
+
+// KeepRewards carries the loyalty discounts of existing over to order, its
+// edited version. Each discount is priced again from the edited items, and
+// those whose product is no longer ordered are dropped; the result reports
+// whether any were, so the caller can restore the points. An order that
+// redeemed a reward cannot change customer, or the points could never be
+// given back.
+func (s *LoyaltyService) KeepRewards(existing models.Order, order *models.Order) (bool, error) {
+	order.Discounts = nil
+	var program *models.LoyaltyProgram
+	dropped := false
+	for _, d := range existing.Discounts {
+		if d.Type != "loyalty" {
+			order.Discounts = append(order.Discounts, d)
+			continue
+		}
+		if order.CustomerID != existing.CustomerID {
+			return false, fmt.Errorf("%w: order '%s' redeemed reward '%s', so its customer cannot change", models.ErrConflict, existing.ID, d.RewardID)
+		}
+		if d.ProductID == "" {
+			// Orders from before discounts named their product.
+			if program == nil {
+				p, err := s.LoyaltyRepo.GetProgram()
+				if err != nil {
+					return false, err
+				}
+				program = &p
+			}
+			for _, reward := range program.Rewards {
+				if reward.ID == d.RewardID {
+					d.ProductID = reward.ProductID
+				}
+			}
+		}
+		if d.ProductID == "" {
+			order.Discounts = append(order.Discounts, d)
+			continue
+		}
+
+		found := false
+		for _, item := range order.Items {
+			if item.ProductID == d.ProductID && item.Quantity > 0 {
+				d.Amount = roundMoney(item.Price)
+				found = true
+				break
+			}
+		}
+		if !found {
+			dropped = true
+			continue
+		}
+		order.Discounts = append(order.Discounts, d)
+	}
+	return dropped, nil
+}
+
+func (s *LoyaltyService) RecordRedemption(order models.Order) error {
+	program, err := s.LoyaltyRepo.GetProgram()
+	if err != nil {
+		return err
+	}
+	for _, discount := range order.Discounts {
+		if discount.Type != "loyalty" {
+			continue
+		}
+		for _, reward := range program.Rewards {
+			if reward.ID == discount.RewardID {
+				if err := s.addEntry(order, models.LoyaltyRedeem, -reward.PointsCost, reward.Name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *LoyaltyService) EarnForOrder(order models.Order, menuItems []models.MenuItem) error {
+	if order.CustomerID == "" {
+		return nil
+	}
+	entries, err := s.orderEntries(order)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.Type == models.LoyaltyEarn {
+			return nil
+		}
+	}
+
+	program, err := s.LoyaltyRepo.GetProgram()
+	if err != nil {
+		return err
+	}
+	net := orderSubtotal(order, menuItems) - orderDiscounts(order)
+	points := int(math.Floor(net * program.PointsPerCurrencyUnit))
+	for _, item := range order.Items {
+		points += item.Quantity * program.PointsPerItem
+	}
+	if points <= 0 {
+		return nil
+	}
+	return s.addEntry(order, models.LoyaltyEarn, points, "")
+}
+
+// ReverseForRefund takes back the share of earned points that matches the
+// refunded amount, or everything that is left when the order is fully refunded.
+func (s *LoyaltyService) ReverseForRefund(order models.Order, refundAmount float64, total float64, full bool) error {
+	if order.CustomerID == "" {
+		return nil
+	}
+	entries, err := s.orderEntries(order)
+	if err != nil {
+		return err
+	}
+
+	earned, points := earnedPoints(entries)
+	if !full && total > 0 {
+		points = min(points, int(math.Round(float64(earned)*refundAmount/total)))
+	}
+	if points <= 0 {
+		return nil
+	}
+	return s.addEntry(order, models.LoyaltyReverse, -points, "refund")
+}
+
+// ReverseEarned takes back every earned point of the order that has not been
+// reversed yet.
+func (s *LoyaltyService) ReverseEarned(order models.Order, note string) error {
+	if order.CustomerID == "" {
+		return nil
+	}
+	entries, err := s.orderEntries(order)
+	if err != nil {
+		return err
+	}
+	if _, points := earnedPoints(entries); points > 0 {
+		return s.addEntry(order, models.LoyaltyReverse, -points, note)
+	}
+	return nil
+}
+
+// earnedPoints returns the points an order earned and how many of them are
+// still outstanding after earlier reversals.
+func earnedPoints(entries []models.LoyaltyEntry) (earned int, outstanding int) {
+	var reversed int
+	for _, e := range entries {
+		switch e.Type {
+		case models.LoyaltyEarn:
+			earned += e.Points
+		case models.LoyaltyReverse:
+			reversed -= e.Points
+		}
+	}
+	return earned, earned - reversed
+}
+
+func (s *LoyaltyService) RestoreRedemption(order models.Order, note string) error {
+	if order.CustomerID == "" {
+		return nil
+	}
+	entries, err := s.orderEntries(order)
+	if err != nil {
+		return err
+	}
+
+	var redeemed int
+	for _, e := range entries {
+		switch e.Type {
+		case models.LoyaltyRedeem:
+			redeemed -= e.Points
+		case models.LoyaltyRestore:
+			redeemed -= e.Points
+		}
+	}
+	if redeemed <= 0 {
+		return nil
+	}
+	return s.addEntry(order, models.LoyaltyRestore, redeemed, note)
+}
+
+func (s *LoyaltyService) orderEntries(order models.Order) ([]models.LoyaltyEntry, error) {
+	entries, err := s.LoyaltyRepo.GetEntriesByCustomer(order.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	var result []models.LoyaltyEntry
+	for _, e := range entries {
+		if e.OrderID == order.ID {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+func (s *LoyaltyService) addEntry(order models.Order, entryType string, points int, note string) error {
+	return s.LoyaltyRepo.AddEntry(models.LoyaltyEntry{
+		CustomerID: order.CustomerID,
+		OrderID:    order.ID,
+		Type:       entryType,
+		Points:     points,
+		Note:       note,
+		CreatedAt:  time.Now().Format(time.RFC3339),
+	})
+}
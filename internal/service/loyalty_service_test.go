@@ -0,0 +1,186 @@
+package service
+
+import (
+	"errors"
+	"hot-coffee/models"
+	"testing"
+)
+
+// withFreeLatte sets up a program where a latte costs 10 points and gives
+// customer c1 the 10 points to redeem it.
+func withFreeLatte(t *testing.T, s *OrderService) {
+	t.Helper()
+	program := models.LoyaltyProgram{
+		PointsPerCurrencyUnit: 1,
+		Rewards:               []models.LoyaltyReward{{ID: "free-latte", Name: "Free latte", PointsCost: 10, ProductID: "latte"}},
+	}
+	if _, err := s.Loyalty.UpdateProgram(program); err != nil {
+		t.Fatalf("UpdateProgram() error = %v", err)
+	}
+	if err := s.Loyalty.addEntry(models.Order{CustomerID: "c1"}, models.LoyaltyEarn, 10, "opening balance"); err != nil {
+		t.Fatalf("addEntry() error = %v", err)
+	}
+}
+
+func balance(t *testing.T, s *OrderService, customerID string) int {
+	t.Helper()
+	account, err := s.Loyalty.GetAccount(customerID)
+	if err != nil {
+		t.Fatalf("GetAccount() error = %v", err)
+	}
+	return account.Balance
+}
+
+func TestUpdateOrderKeepsRewards(t *testing.T) {
+	tests := []struct {
+		name         string
+		patch        string
+		wantErr      error
+		wantDiscount float64
+		wantBalance  int
+	}{
+		{name: "more lattes", patch: `{"items":[{"product_id":"latte","quantity":2}]}`, wantDiscount: 5},
+		{name: "tea added", patch: `{"items":[{"product_id":"latte","quantity":1},{"product_id":"tea","quantity":1}]}`, wantDiscount: 5},
+		{name: "latte removed", patch: `{"items":[{"product_id":"tea","quantity":1}]}`, wantBalance: 10},
+		{name: "other customer", patch: `{"customer_id":"c2"}`, wantErr: models.ErrConflict, wantDiscount: 5},
+		{name: "customer cleared", patch: `{"customer_id":null,"customer_name":"Walk-in"}`, wantErr: models.ErrConflict, wantDiscount: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestOrderService(t)
+			withFreeLatte(t, s)
+			order, err := s.CreateOrder(models.Order{
+				CustomerID:     "c1",
+				RedeemRewardID: "free-latte",
+				Items:          []models.OrderItem{{ProductID: "latte", Quantity: 1}},
+			})
+			if err != nil {
+				t.Fatalf("CreateOrder() error = %v", err)
+			}
+			if got := balance(t, s, "c1"); got != 0 {
+				t.Fatalf("balance after redeeming = %d, want 0", got)
+			}
+
+			_, err = s.PatchOrder(order.ID, []byte(tt.patch), order.Version)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PatchOrder() error = %v, want %v", err, tt.wantErr)
+			}
+			stored, _ := s.OrderRepo.GetOrderByID(order.ID)
+			if got := orderDiscounts(stored); got != tt.wantDiscount {
+				t.Errorf("discounts = %.2f, want %.2f", got, tt.wantDiscount)
+			}
+			if stored.CustomerID != "c1" {
+				t.Errorf("customer = %q, want c1", stored.CustomerID)
+			}
+			if got := balance(t, s, "c1"); got != tt.wantBalance {
+				t.Errorf("balance = %d, want %d", got, tt.wantBalance)
+			}
+		})
+	}
+}
+
+func TestRedeemReward(t *testing.T) {
+	tests := []struct {
+		name        string
+		order       models.Order
+		wantErr     error
+		wantBalance int
+	}{
+		{name: "redeemed", order: models.Order{CustomerID: "c1", RedeemRewardID: "free-latte", Items: []models.OrderItem{{ProductID: "latte", Quantity: 1}}}},
+		{name: "not enough points", order: models.Order{CustomerID: "c2", RedeemRewardID: "free-latte", Items: []models.OrderItem{{ProductID: "latte", Quantity: 1}}}, wantErr: ErrInvalidLoyalty, wantBalance: 10},
+		{name: "unknown reward", order: models.Order{CustomerID: "c1", RedeemRewardID: "free-cake", Items: []models.OrderItem{{ProductID: "latte", Quantity: 1}}}, wantErr: ErrInvalidLoyalty, wantBalance: 10},
+		{name: "product not ordered", order: models.Order{CustomerID: "c1", RedeemRewardID: "free-latte", Items: []models.OrderItem{{ProductID: "tea", Quantity: 1}}}, wantErr: ErrInvalidLoyalty, wantBalance: 10},
+		{name: "no customer", order: models.Order{CustomerName: "Alice", RedeemRewardID: "free-latte", Items: []models.OrderItem{{ProductID: "latte", Quantity: 1}}}, wantErr: ErrInvalidLoyalty, wantBalance: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestOrderService(t)
+			withFreeLatte(t, s)
+			order, err := s.CreateOrder(tt.order)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateOrder() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (order.Total != 0 || orderDiscounts(order) != 5) {
+				t.Errorf("total %.2f, discounts %.2f, want 0.00 and 5.00", order.Total, orderDiscounts(order))
+			}
+			if got := balance(t, s, "c1"); got != tt.wantBalance {
+				t.Errorf("balance = %d, want %d", got, tt.wantBalance)
+			}
+		})
+	}
+}
+
+func TestLoyaltyEarnAndReverse(t *testing.T) {
+	tests := []struct {
+		name   string
+		redeem bool
+		// refunds lists the lattes taken back by each refund; 0 refunds
+		// whatever is left.
+		refunds     []int
+		wantBalance int
+	}{
+		{name: "earned on close", wantBalance: 20},
+		{name: "partial refund", refunds: []int{1}, wantBalance: 15},
+		{name: "refund in two parts", refunds: []int{1, 1}, wantBalance: 10},
+		{name: "full refund", refunds: []int{0}, wantBalance: 10},
+		{name: "partial then full refund", refunds: []int{1, 0}, wantBalance: 10},
+		{name: "discounted latte earns nothing", redeem: true, wantBalance: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shop := newTestShop(t)
+			withFreeLatte(t, shop.Orders)
+			order := models.Order{CustomerID: "c1", Items: []models.OrderItem{{ProductID: "latte", Quantity: 2}}}
+			if tt.redeem {
+				order.RedeemRewardID = "free-latte"
+			}
+			sold := shop.sell(t, order, models.TenderCash)
+			if sold.Status != "closed" {
+				t.Fatalf("status = %s, want closed", sold.Status)
+			}
+
+			for _, quantity := range tt.refunds {
+				var req models.RefundRequest
+				if quantity > 0 {
+					req.Lines = []models.RefundLine{{ProductID: "latte", Quantity: quantity}}
+				}
+				if _, err := shop.Refunds.RefundOrder(sold.ID, req); err != nil {
+					t.Fatalf("RefundOrder() error = %v", err)
+				}
+			}
+			if got := balance(t, shop.Orders, "c1"); got != tt.wantBalance {
+				t.Errorf("balance = %d, want %d", got, tt.wantBalance)
+			}
+		})
+	}
+}
+
+func TestVoidOrderRestoresRedemption(t *testing.T) {
+	shop := newTestShop(t)
+	withFreeLatte(t, shop.Orders)
+	order, err := shop.Orders.CreateOrder(models.Order{
+		CustomerID:     "c1",
+		RedeemRewardID: "free-latte",
+		Items:          []models.OrderItem{{ProductID: "latte", Quantity: 1}, {ProductID: "tea", Quantity: 1}},
+	})
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	if got := balance(t, shop.Orders, "c1"); got != 0 {
+		t.Fatalf("balance after redeeming = %d, want 0", got)
+	}
+
+	if _, err := shop.Refunds.VoidOrder(order.ID, models.VoidRequest{Reason: "left"}); err != nil {
+		t.Fatalf("VoidOrder() error = %v", err)
+	}
+	if got := balance(t, shop.Orders, "c1"); got != 10 {
+		t.Errorf("balance after void = %d, want 10", got)
+	}
+	// Restoring twice must not hand the points back again.
+	if err := shop.Loyalty.RestoreRedemption(order, "order cancelled"); err != nil {
+		t.Fatalf("RestoreRedemption() error = %v", err)
+	}
+	if got := balance(t, shop.Orders, "c1"); got != 10 {
+		t.Errorf("balance after a second restore = %d, want 10", got)
+	}
+}
@@ -1,24 +1,32 @@
 package service
 
 import (
+	"cmp"
 	"fmt"
 	"hot-coffee/internal/dal"
+	"hot-coffee/internal/validation"
 	"hot-coffee/models"
+	"strings"
 )
 
 type MenuService struct {
-	MenuRepo  dal.MenuManager
-	OrderRepo dal.OrderManager
+	MenuRepo      dal.MenuManager
+	OrderRepo     dal.OrderManager
+	InventoryRepo dal.InventoryManager
 }
 
-func NewMenuService(menuRepo dal.MenuManager, orderRepo dal.OrderManager) *MenuService {
+func NewMenuService(menuRepo dal.MenuManager, orderRepo dal.OrderManager, inventoryRepo dal.InventoryManager) *MenuService {
 	return &MenuService{
-		MenuRepo:  menuRepo,
-		OrderRepo: orderRepo,
+		MenuRepo:      menuRepo,
+		OrderRepo:     orderRepo,
+		InventoryRepo: inventoryRepo,
 	}
 }
 
-func (s *MenuService) AddNewMenuItem(item models.MenuItem) error {
+func (s *MenuService) AddNewMenuItem(item models.MenuItem) (models.MenuItem, error) {
+	if err := s.validate(item, true); err != nil {
+		return models.MenuItem{}, err
+	}
 	return s.MenuRepo.AddNewMenuItem(item)
 }
 
@@ -26,15 +34,95 @@ func (s *MenuService) GetAllMenuItems() ([]models.MenuItem, error) {
 	return s.MenuRepo.GetAllMenuItems()
 }
 
+// MenuFilter narrows a menu listing. Query matches part of the name or
+// description; IngredientID keeps items that use that ingredient.
+type MenuFilter struct {
+	Category     string
+	IngredientID string
+	Query        string
+}
+
+var menuSorts = map[string]func(a, b models.MenuItem) int{
+	"product_id": func(a, b models.MenuItem) int {
+		return cmp.Compare(a.ID, b.ID)
+	},
+	"name": func(a, b models.MenuItem) int {
+		return compareStrings(a.Name, b.Name)
+	},
+	"category": func(a, b models.MenuItem) int {
+		return compareStrings(a.Category, b.Category)
+	},
+	"price": func(a, b models.MenuItem) int {
+		return cmp.Compare(a.Price, b.Price)
+	},
+}
+
+func (s *MenuService) ListMenuItems(filter MenuFilter, opts ListOptions) (Page[models.MenuItem], error) {
+	items, err := s.MenuRepo.GetAllMenuItems()
+	if err != nil {
+		return Page[models.MenuItem]{}, err
+	}
+
+	matched := []models.MenuItem{}
+	for _, item := range items {
+		if filter.Category != "" && !strings.EqualFold(item.Category, filter.Category) {
+			continue
+		}
+		if filter.IngredientID != "" && !usesIngredient(item, filter.IngredientID) {
+			continue
+		}
+		if filter.Query != "" && !containsFold(item.Name, filter.Query) && !containsFold(item.Description, filter.Query) {
+			continue
+		}
+		matched = append(matched, item)
+	}
+	return paginate(matched, opts, menuSorts)
+}
+
+func usesIngredient(item models.MenuItem, ingredientID string) bool {
+	for _, ing := range item.Ingredients {
+		if ing.IngredientID == ingredientID {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *MenuService) GetMenuItem(menuItemID string) (models.MenuItem, error) {
 	return s.MenuRepo.GetMenuItem(menuItemID)
 }
 
-func (s *MenuService) UpdateMenuItem(item models.MenuItem) error {
-	return s.MenuRepo.UpdateMenuItem(item)
+func (s *MenuService) UpdateMenuItem(item models.MenuItem) (models.MenuItem, error) {
+	if err := s.validate(item, false); err != nil {
+		return models.MenuItem{}, err
+	}
+	if err := s.MenuRepo.UpdateMenuItem(item); err != nil {
+		return models.MenuItem{}, err
+	}
+	return s.MenuRepo.GetMenuItem(item.ID)
+}
+
+// PatchMenuItem applies a JSON merge patch to the stored item and saves the
+// result as UpdateMenuItem would. The item must still be at version, or when
+// version is zero at the version the patch was applied to.
+func (s *MenuService) PatchMenuItem(id string, patch []byte, version int) (models.MenuItem, error) {
+	current, err := s.MenuRepo.GetMenuItem(id)
+	if err != nil {
+		return models.MenuItem{}, err
+	}
+	var item models.MenuItem
+	if err := applyPatch(current, patch, &item); err != nil {
+		return models.MenuItem{}, err
+	}
+	item.ID = id
+	if version == 0 {
+		version = current.Version
+	}
+	item.Version = version
+	return s.UpdateMenuItem(item)
 }
 
-func (s *MenuService) DeleteMenuItem(id string) error {
+func (s *MenuService) DeleteMenuItem(id string, version int) error {
 	orders, err := s.OrderRepo.GetAllOrders()
 	if err != nil {
 		return fmt.Errorf("failed to check orders: %w", err)
@@ -44,11 +132,26 @@ func (s *MenuService) DeleteMenuItem(id string) error {
 		if order.Status == "open" {
 			for _, item := range order.Items {
 				if item.ProductID == id {
-					return fmt.Errorf("cannot delete menu item '%s': it is used in open order '%s'", id, order.ID)
+					return fmt.Errorf("%w: cannot delete menu item '%s': it is used in open order '%s'", models.ErrConflict, id, order.ID)
 				}
 			}
 		}
 	}
 
-	return s.MenuRepo.DeleteMenuItem(id)
+	return s.MenuRepo.DeleteMenuItem(id, version)
+}
+
+func (s *MenuService) validate(item models.MenuItem, create bool) error {
+	inventory, err := s.InventoryRepo.GetAllInventoryItems()
+	if err != nil {
+		return err
+	}
+	ingredients := make(map[string]bool)
+	for _, inv := range inventory {
+		ingredients[inv.IngredientID] = true
+	}
+	if create {
+		return validation.NewMenuItem(item, ingredients)
+	}
+	return validation.MenuItem(item, ingredients)
 }
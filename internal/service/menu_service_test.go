@@ -0,0 +1,42 @@
+package service
+
+import (
+	"errors"
+	"hot-coffee/models"
+	"testing"
+)
+
+func TestAddNewMenuItemID(t *testing.T) {
+	tests := []struct {
+		name    string
+		item    models.MenuItem
+		want    string
+		wantErr error
+	}{
+		{name: "given", item: models.MenuItem{ID: "mocha", Name: "Mocha", Price: 4}, want: "mocha"},
+		{name: "generated", item: models.MenuItem{Name: "Flat White", Price: 4}, want: "flat-white"},
+		{name: "generated next to a namesake", item: models.MenuItem{Name: "Latte", Price: 4}, want: "latte-2"},
+		{name: "duplicate", item: models.MenuItem{ID: "latte", Name: "Another latte", Price: 4}, wantErr: models.ErrConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestShop(t).Menu
+			created, err := s.AddNewMenuItem(tt.item)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AddNewMenuItem() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if stored, _ := s.MenuRepo.GetMenuItem(tt.item.ID); stored.Name != "Latte" {
+					t.Errorf("stored item = %+v, want the original latte", stored)
+				}
+				return
+			}
+			if created.ID != tt.want || created.Version != 1 {
+				t.Errorf("AddNewMenuItem() = %s at version %d, want %s at version 1", created.ID, created.Version, tt.want)
+			}
+			if stored, err := s.MenuRepo.GetMenuItem(tt.want); err != nil || stored.Name != tt.item.Name {
+				t.Errorf("GetMenuItem(%s) = %+v, %v, want %s", tt.want, stored, err, tt.item.Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"hot-coffee/models"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+type orderTimings struct {
+	values  []float64
+	toReady []float64
+	toClose []float64
+}
+
+func (t *orderTimings) add(order models.Order, value float64) {
+	t.values = append(t.values, value)
+	created, err := time.Parse(time.RFC3339, order.CreatedAt)
+	if err != nil {
+		return
+	}
+	if at, ok := statusTime(order, "ready"); ok {
+		t.toReady = append(t.toReady, at.Sub(created).Seconds())
+	}
+	if at, ok := statusTime(order, "closed"); ok {
+		t.toClose = append(t.toClose, at.Sub(created).Seconds())
+	}
+}
+
+// GetOperationsReport measures sold orders created in the period: order value,
+// basket size, hourly throughput and how long orders took from creation to
+// ready and to closed. Open hours are the time a cash drawer session was open
+// within the period, and orders per hour is averaged over them. A staff member
+// is credited with every order they created or moved along its status history.
+// Orders recorded before status history existed only count towards the value
+// and throughput figures.
+func (s *ReportService) GetOperationsReport(from string, to string, staffID string) (models.OperationsReport, error) {
+	p, err := s.parsePeriod(from, to)
+	if err != nil {
+		return models.OperationsReport{}, err
+	}
+	staffID = strings.TrimSpace(staffID)
+
+	orders, err := s.orderRepo.LoadOrders()
+	if err != nil {
+		return models.OperationsReport{}, err
+	}
+	menuItems, err := s.menuRepo.LoadMenuItems()
+	if err != nil {
+		return models.OperationsReport{}, err
+	}
+	sessions, err := s.sessionRepo.LoadSessions()
+	if err != nil {
+		return models.OperationsReport{}, err
+	}
+
+	report := models.OperationsReport{
+		From:     from,
+		To:       to,
+		TimeZone: s.location.String(),
+		StaffID:  staffID,
+		Hourly:   []models.HourlyThroughput{},
+		ByStaff:  []models.StaffMetrics{},
+	}
+
+	var all orderTimings
+	staff := make(map[string]*orderTimings)
+	hourly := make(map[string]*models.HourlyThroughput)
+	for _, order := range orders {
+		if !isSold(order.Status) {
+			continue
+		}
+		staffIDs := orderStaff(order)
+		if staffID != "" && !slices.Contains(staffIDs, staffID) {
+			continue
+		}
+		createdAt, err := time.Parse(time.RFC3339, order.CreatedAt)
+		if err != nil || !p.contains(createdAt) {
+			continue
+		}
+
+		items := 0
+		for _, item := range order.Items {
+			items += item.Quantity
+		}
+		value := orderTotal(order, menuItems)
+		report.OrderCount++
+		report.ItemCount += items
+		all.add(order, value)
+
+		for _, id := range staffIDs {
+			if staffID != "" && id != staffID {
+				continue
+			}
+			t, ok := staff[id]
+			if !ok {
+				t = &orderTimings{}
+				staff[id] = t
+			}
+			t.add(order, value)
+		}
+
+		key := s.bucketKey(createdAt, GroupByHour)
+		h, ok := hourly[key]
+		if !ok {
+			h = &models.HourlyThroughput{Hour: key}
+			hourly[key] = h
+		}
+		h.Orders++
+		h.Items += items
+	}
+
+	openHours := openHours(sessions, p, time.Now())
+	report.OpenHours = math.Round(openHours*100) / 100
+	if report.OrderCount > 0 {
+		report.AverageOrderValue = roundMoney(average(all.values))
+		report.ItemsPerOrder = math.Round(float64(report.ItemCount)/float64(report.OrderCount)*100) / 100
+	}
+	if openHours > 0 {
+		report.OrdersPerHour = math.Round(float64(report.OrderCount)/openHours*100) / 100
+	}
+	report.TimeToReady = durationStats(all.toReady)
+	report.TimeToClose = durationStats(all.toClose)
+
+	for _, h := range hourly {
+		report.Hourly = append(report.Hourly, *h)
+	}
+	sort.Slice(report.Hourly, func(i, j int) bool {
+		return report.Hourly[i].Hour < report.Hourly[j].Hour
+	})
+
+	for id, t := range staff {
+		report.ByStaff = append(report.ByStaff, models.StaffMetrics{
+			StaffID:           id,
+			OrderCount:        len(t.values),
+			AverageOrderValue: roundMoney(average(t.values)),
+			TimeToReady:       durationStats(t.toReady),
+			TimeToClose:       durationStats(t.toClose),
+		})
+	}
+	sort.Slice(report.ByStaff, func(i, j int) bool {
+		return report.ByStaff[i].StaffID < report.ByStaff[j].StaffID
+	})
+	return report, nil
+}
+
+// orderStaff lists the staff who created the order or changed its status,
+// each once.
+func orderStaff(order models.Order) []string {
+	var ids []string
+	if order.StaffID != "" {
+		ids = append(ids, order.StaffID)
+	}
+	for _, change := range order.History {
+		if change.StaffID != "" && !slices.Contains(ids, change.StaffID) {
+			ids = append(ids, change.StaffID)
+		}
+	}
+	return ids
+}
+
+// openHours adds up how long cash drawer sessions were open within the
+// period. A session that is still open counts up to now.
+func openHours(sessions []models.CashSession, p period, now time.Time) float64 {
+	var total time.Duration
+	for _, session := range sessions {
+		start, err := time.Parse(time.RFC3339, session.OpenedAt)
+		if err != nil {
+			continue
+		}
+		end := now
+		if session.ClosedAt != "" {
+			if end, err = time.Parse(time.RFC3339, session.ClosedAt); err != nil {
+				continue
+			}
+		}
+		if !p.from.IsZero() && start.Before(p.from) {
+			start = p.from
+		}
+		if !p.to.IsZero() && end.After(p.to) {
+			end = p.to
+		}
+		if end.After(start) {
+			total += end.Sub(start)
+		}
+	}
+	return total.Hours()
+}
+
+func statusTime(order models.Order, status string) (time.Time, bool) {
+	for _, change := range order.History {
+		if change.Status != status {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, change.At)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+func average(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func durationStats(seconds []float64) models.DurationStats {
+	stats := models.DurationStats{Count: len(seconds)}
+	if len(seconds) == 0 {
+		return stats
+	}
+	sorted := append([]float64{}, seconds...)
+	sort.Float64s(sorted)
+	stats.AverageSeconds = math.Round(average(sorted))
+	stats.P50Seconds = percentile(sorted, 50)
+	stats.P90Seconds = percentile(sorted, 90)
+	stats.P95Seconds = percentile(sorted, 95)
+	stats.MaxSeconds = sorted[len(sorted)-1]
+	return stats
+}
+
+// percentile uses the nearest-rank method on already sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
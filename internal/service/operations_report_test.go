@@ -0,0 +1,120 @@
+package service
+
+import (
+	"hot-coffee/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type stubOrders []models.Order
+
+func (s stubOrders) LoadOrders() ([]models.Order, error) { return s, nil }
+
+type stubMenu []models.MenuItem
+
+func (s stubMenu) LoadMenuItems() ([]models.MenuItem, error) { return s, nil }
+
+type stubSessions []models.CashSession
+
+func (s stubSessions) LoadSessions() ([]models.CashSession, error) { return s, nil }
+
+// served returns a closed order created by staffID at created that became
+// ready and closed the given number of seconds later.
+func served(id string, staffID string, created string, ready int, readyBy string, closed int, items ...models.OrderItem) models.Order {
+	at, _ := time.Parse(time.RFC3339, created)
+	after := func(seconds int) string { return at.Add(time.Duration(seconds) * time.Second).Format(time.RFC3339) }
+	return models.Order{ID: id, StaffID: staffID, Status: "closed", CreatedAt: created, Items: items, History: []models.StatusChange{
+		{Status: "ready", At: after(ready), StaffID: readyBy},
+		{Status: "closed", At: after(closed), StaffID: staffID},
+	}}
+}
+
+func TestGetOperationsReport(t *testing.T) {
+	latte := func(n int) models.OrderItem { return models.OrderItem{ProductID: "latte", Quantity: n} }
+	tea := func(n int) models.OrderItem { return models.OrderItem{ProductID: "tea", Quantity: n} }
+	orders := stubOrders{
+		served("o1", "ann", "2026-03-01T09:00:00Z", 120, "ben", 300, latte(2)),
+		served("o2", "ben", "2026-03-01T09:30:00Z", 240, "ben", 600, tea(1)),
+		served("o3", "ann", "2026-03-01T10:15:00Z", 60, "ann", 300, latte(1), tea(1)),
+		// Recorded before staff and status history existed.
+		{ID: "o4", Status: "closed", CreatedAt: "2026-03-01T10:30:00Z", Items: []models.OrderItem{tea(2)}},
+		{ID: "o5", Status: "open", StaffID: "ann", CreatedAt: "2026-03-01T11:00:00Z", Items: []models.OrderItem{latte(1)}},
+		served("o6", "ann", "2026-03-02T09:00:00Z", 60, "ann", 120, latte(1)),
+	}
+	menu := stubMenu{{ID: "latte", Price: 5}, {ID: "tea", Price: 3}}
+	sessions := stubSessions{
+		{OpenedAt: "2026-02-28T22:00:00Z", ClosedAt: "2026-03-01T01:00:00Z"},
+		{OpenedAt: "2026-03-01T08:00:00Z", ClosedAt: "2026-03-01T12:00:00Z"},
+	}
+	s := NewReportService(orders, menu, nil, nil, sessions, nil, nil, nil, nil, time.UTC)
+
+	ann := models.StaffMetrics{
+		StaffID: "ann", OrderCount: 2, AverageOrderValue: 9,
+		TimeToReady: models.DurationStats{Count: 2, AverageSeconds: 90, P50Seconds: 60, P90Seconds: 120, P95Seconds: 120, MaxSeconds: 120},
+		TimeToClose: models.DurationStats{Count: 2, AverageSeconds: 300, P50Seconds: 300, P90Seconds: 300, P95Seconds: 300, MaxSeconds: 300},
+	}
+	ben := models.StaffMetrics{
+		StaffID: "ben", OrderCount: 2, AverageOrderValue: 6.5,
+		TimeToReady: models.DurationStats{Count: 2, AverageSeconds: 180, P50Seconds: 120, P90Seconds: 240, P95Seconds: 240, MaxSeconds: 240},
+		TimeToClose: models.DurationStats{Count: 2, AverageSeconds: 450, P50Seconds: 300, P90Seconds: 600, P95Seconds: 600, MaxSeconds: 600},
+	}
+	tests := []struct {
+		name    string
+		staffID string
+		want    models.OperationsReport
+	}{
+		{name: "everyone", want: models.OperationsReport{
+			OrderCount: 4, ItemCount: 7, AverageOrderValue: 6.75, ItemsPerOrder: 1.75, OpenHours: 5, OrdersPerHour: 0.8,
+			TimeToReady: models.DurationStats{Count: 3, AverageSeconds: 140, P50Seconds: 120, P90Seconds: 240, P95Seconds: 240, MaxSeconds: 240},
+			TimeToClose: models.DurationStats{Count: 3, AverageSeconds: 400, P50Seconds: 300, P90Seconds: 600, P95Seconds: 600, MaxSeconds: 600},
+			Hourly:      []models.HourlyThroughput{{Hour: "2026-03-01T09:00", Orders: 2, Items: 3}, {Hour: "2026-03-01T10:00", Orders: 2, Items: 4}},
+			ByStaff:     []models.StaffMetrics{ann, ben},
+		}},
+		{name: "one member of staff", staffID: " ben ", want: models.OperationsReport{
+			StaffID:    "ben",
+			OrderCount: 2, ItemCount: 3, AverageOrderValue: 6.5, ItemsPerOrder: 1.5, OpenHours: 5, OrdersPerHour: 0.4,
+			TimeToReady: ben.TimeToReady,
+			TimeToClose: ben.TimeToClose,
+			Hourly:      []models.HourlyThroughput{{Hour: "2026-03-01T09:00", Orders: 2, Items: 3}},
+			ByStaff:     []models.StaffMetrics{ben},
+		}},
+		{name: "unknown staff", staffID: "cat", want: models.OperationsReport{
+			StaffID:   "cat",
+			OpenHours: 5,
+			Hourly:    []models.HourlyThroughput{},
+			ByStaff:   []models.StaffMetrics{},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetOperationsReport("2026-03-01", "2026-03-01", tt.staffID)
+			if err != nil {
+				t.Fatalf("GetOperationsReport() error = %v", err)
+			}
+			tt.want.From, tt.want.To, tt.want.TimeZone = "2026-03-01", "2026-03-01", "UTC"
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetOperationsReport() = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []float64{10, 20, 30, 40, 50, 60, 70, 80, 90, 100}
+	tests := []struct {
+		p    float64
+		want float64
+	}{
+		{p: 0, want: 10},
+		{p: 50, want: 50},
+		{p: 90, want: 90},
+		{p: 95, want: 100},
+		{p: 100, want: 100},
+	}
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"hot-coffee/models"
+	"sort"
+	"time"
+)
+
+// ExportOrders validates the period and then passes every line item of the
+// sold orders created in it to emit, oldest order first, so callers can stream the
+// result without holding it in memory.
+func (s *ReportService) ExportOrders(from string, to string, emit func(models.OrderExportLine) error) error {
+	p, err := s.parsePeriod(from, to)
+	if err != nil {
+		return err
+	}
+
+	orders, err := s.orderRepo.LoadOrders()
+	if err != nil {
+		return err
+	}
+	menuItems, err := s.menuRepo.LoadMenuItems()
+	if err != nil {
+		return err
+	}
+
+	type datedOrder struct {
+		order     models.Order
+		createdAt time.Time
+	}
+	var selected []datedOrder
+	for _, order := range orders {
+		if !isSold(order.Status) {
+			continue
+		}
+		createdAt, err := time.Parse(time.RFC3339, order.CreatedAt)
+		if err != nil || !p.contains(createdAt) {
+			continue
+		}
+		selected = append(selected, datedOrder{order: order, createdAt: createdAt})
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		return selected[i].createdAt.Before(selected[j].createdAt)
+	})
+
+	for _, d := range selected {
+		order := d.order
+		subtotal := orderSubtotal(order, menuItems)
+		discounts := orderDiscounts(order)
+		total := orderTotal(order, menuItems)
+		for _, item := range order.Items {
+			price := unitPrice(item, menuItems)
+			line := models.OrderExportLine{
+				OrderID:      order.ID,
+				CreatedAt:    order.CreatedAt,
+				Status:       order.Status,
+				CustomerID:   order.CustomerID,
+				CustomerName: order.CustomerName,
+				ProductID:    item.ProductID,
+				Name:         itemName(item, menuItems),
+				Category:     itemCategory(item, menuItems),
+				Quantity:     item.Quantity,
+				UnitPrice:    price,
+				LineTotal:    roundMoney(price * float64(item.Quantity)),
+				Subtotal:     subtotal,
+				Discounts:    discounts,
+				Tax:          order.Tax,
+				Total:        total,
+			}
+			if err := emit(line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
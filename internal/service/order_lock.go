@@ -0,0 +1,50 @@
+package service
+
+import "sync"
+
+// orderLocks serializes work that reads an order's balance and then writes
+// against it, such as payments, refunds, voids and closing. It is shared by
+// every service so the check and the write cannot interleave between them.
+var orderLocks = newKeyedMutex()
+
+// customerLocks serializes spending a customer's loyalty points, from the
+// balance check to the ledger entry.
+var customerLocks = newKeyedMutex()
+
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*refMutex
+}
+
+type refMutex struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{locks: make(map[string]*refMutex)}
+}
+
+// Lock blocks until key is free and returns the function that releases it.
+// Entries are dropped once nobody holds or waits for them.
+func (k *keyedMutex) Lock(key string) (unlock func()) {
+	k.mu.Lock()
+	l, ok := k.locks[key]
+	if !ok {
+		l = &refMutex{}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		k.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
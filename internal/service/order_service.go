@@ -1,9 +1,14 @@
 package service
 
 import (
-	"errors"
+	"cmp"
+	"fmt"
 	"hot-coffee/internal/dal"
+	"hot-coffee/internal/validation"
 	"hot-coffee/models"
+	"log/slog"
+	"math"
+	"strings"
 	"time"
 )
 
@@ -11,20 +16,40 @@ type OrderService struct {
 	OrderRepo     dal.OrderManager
 	MenuRepo      dal.MenuManager
 	InventoryRepo dal.InventoryManager
+	PaymentRepo   dal.PaymentManager
+	CustomerRepo  dal.CustomerManager
+	Loyalty       *LoyaltyService
+	Aggregates    *AggregateService
+	TaxRate       float64
+	Location      *time.Location
 }
 
-func NewOrderService(orderRepo dal.OrderManager, menuRepo dal.MenuManager, inventoryRepo dal.InventoryManager) *OrderService {
+func NewOrderService(orderRepo dal.OrderManager, menuRepo dal.MenuManager, inventoryRepo dal.InventoryManager, paymentRepo dal.PaymentManager, customerRepo dal.CustomerManager, loyalty *LoyaltyService, aggregates *AggregateService, taxRate float64, location *time.Location) *OrderService {
 	return &OrderService{
 		OrderRepo:     orderRepo,
 		MenuRepo:      menuRepo,
 		InventoryRepo: inventoryRepo,
+		PaymentRepo:   paymentRepo,
+		CustomerRepo:  customerRepo,
+		Loyalty:       loyalty,
+		Aggregates:    aggregates,
+		TaxRate:       taxRate,
+		Location:      location,
 	}
 }
 
-func (s *OrderService) CreateOrder(order models.Order) error {
+func (s *OrderService) CreateOrder(order models.Order) (models.Order, error) {
+	if err := s.resolveCustomer(&order); err != nil {
+		return models.Order{}, err
+	}
+
 	menuItems, err := s.MenuRepo.GetAllMenuItems()
 	if err != nil {
-		return err
+		return models.Order{}, err
+	}
+
+	if err := validation.NewOrder(order, productIDs(menuItems)); err != nil {
+		return models.Order{}, err
 	}
 
 	menuMap := make(map[string]models.MenuItem)
@@ -36,7 +61,7 @@ func (s *OrderService) CreateOrder(order models.Order) error {
 	for _, orderItem := range order.Items {
 		menuItem, ok := menuMap[orderItem.ProductID]
 		if !ok {
-			return errors.New("invalid product ID: " + orderItem.ProductID)
+			return models.Order{}, fmt.Errorf("%w: menu item '%s'", models.ErrNotFound, orderItem.ProductID)
 		}
 		for _, ing := range menuItem.Ingredients {
 			ingredientsList = append(ingredientsList, models.MenuItemIngredient{
@@ -46,27 +71,287 @@ func (s *OrderService) CreateOrder(order models.Order) error {
 		}
 	}
 
+	inventory, err := s.InventoryRepo.GetAllInventoryItems()
+	if err != nil {
+		return models.Order{}, err
+	}
+	order.Items = snapshotItems(order.Items, menuItems, inventory)
+	order.Discounts = nil
+	if order.CustomerID != "" {
+		// Hold the customer until the redemption is on the ledger, so two
+		// orders cannot spend the same points.
+		unlock := customerLocks.Lock(order.CustomerID)
+		defer unlock()
+	}
+	if err := s.Loyalty.ApplyReward(&order, menuItems); err != nil {
+		return models.Order{}, err
+	}
+
 	if err := s.InventoryRepo.CheckSufficientIngredients(ingredientsList); err != nil {
-		return err
+		return models.Order{}, err
 	}
 	if err := s.InventoryRepo.DeductIngredients(ingredientsList); err != nil {
-		return err
+		return models.Order{}, err
 	}
 
 	order.Status = "open"
+	s.price(&order, menuItems)
 	order.CreatedAt = time.Now().Format(time.RFC3339)
-	return s.OrderRepo.CreateOrder(order)
+	order.History = []models.StatusChange{{Status: "open", At: order.CreatedAt, StaffID: order.StaffID}}
+	created, err := s.OrderRepo.CreateOrder(order)
+	if err != nil {
+		s.restoreStock(ingredientsList)
+		return models.Order{}, err
+	}
+	if err := s.Loyalty.RecordRedemption(created); err != nil {
+		// A reward nobody paid points for must not stay on the books, so the
+		// order is taken back as if it had never been placed.
+		if delErr := s.OrderRepo.DeleteOrder(created.ID, created.Version); delErr != nil {
+			slog.Error("Failed to remove order after redemption failure", "orderID", created.ID, "error", delErr)
+		}
+		s.restoreStock(ingredientsList)
+		return models.Order{}, err
+	}
+	return created, nil
+}
+
+// restoreStock puts back ingredients deducted for an order that could not be
+// completed.
+func (s *OrderService) restoreStock(ingredients []models.MenuItemIngredient) {
+	if err := s.InventoryRepo.RestoreIngredients(ingredients); err != nil {
+		slog.Error("Failed to restore ingredients", "error", err)
+	}
 }
 
 func (s *OrderService) GetAllOrders() ([]models.Order, error) {
-	return s.OrderRepo.GetAllOrders()
+	orders, err := s.OrderRepo.GetAllOrders()
+	if err != nil {
+		return nil, err
+	}
+	menuItems, err := s.MenuRepo.GetAllMenuItems()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]models.Order, len(orders))
+	for i, order := range orders {
+		result[i] = fillTotals(order, menuItems)
+	}
+	return result, nil
+}
+
+// OrderFilter narrows an order listing. Customer matches part of the
+// customer name; From and To bound created_at like report periods do.
+type OrderFilter struct {
+	Status     string
+	CustomerID string
+	Customer   string
+	ProductID  string
+	From       string
+	To         string
+}
+
+var orderSorts = map[string]func(a, b models.Order) int{
+	"created_at": func(a, b models.Order) int {
+		return createdAt(a).Compare(createdAt(b))
+	},
+	"total": func(a, b models.Order) int {
+		return cmp.Compare(a.Total, b.Total)
+	},
+	"customer_name": func(a, b models.Order) int {
+		return compareStrings(a.CustomerName, b.CustomerName)
+	},
+}
+
+// ListOrders returns one page of matching orders, oldest first unless
+// opts.Sort says otherwise.
+func (s *OrderService) ListOrders(filter OrderFilter, opts ListOptions) (Page[models.Order], error) {
+	if opts.Sort == "" {
+		opts.Sort = "created_at"
+	}
+	p, err := parsePeriod(filter.From, filter.To, s.Location)
+	if err != nil {
+		return Page[models.Order]{}, err
+	}
+	orders, err := s.GetAllOrders()
+	if err != nil {
+		return Page[models.Order]{}, err
+	}
+
+	matched := []models.Order{}
+	for _, order := range orders {
+		if filter.Status != "" && order.Status != filter.Status {
+			continue
+		}
+		if filter.CustomerID != "" && order.CustomerID != filter.CustomerID {
+			continue
+		}
+		if filter.Customer != "" && !containsFold(order.CustomerName, filter.Customer) {
+			continue
+		}
+		if filter.ProductID != "" && !hasProduct(order, filter.ProductID) {
+			continue
+		}
+		if filter.From != "" || filter.To != "" {
+			if t := createdAt(order); t.IsZero() || !p.contains(t) {
+				continue
+			}
+		}
+		matched = append(matched, order)
+	}
+	return paginate(matched, opts, orderSorts)
+}
+
+func hasProduct(order models.Order, productID string) bool {
+	for _, item := range order.Items {
+		if item.ProductID == productID {
+			return true
+		}
+	}
+	return false
 }
 
-func (s *OrderService) UpdateOrder(order models.Order) error {
-	return s.OrderRepo.UpdateOrder(order)
+// createdAt returns the zero time for orders with an unreadable timestamp,
+// which sorts them first.
+func createdAt(order models.Order) time.Time {
+	t, err := time.Parse(time.RFC3339, order.CreatedAt)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
 }
 
-func (s *OrderService) DeleteOrder(orderID string) error {
+func (s *OrderService) UpdateOrder(order models.Order) (models.Order, error) {
+	unlock := orderLocks.Lock(order.ID)
+	defer unlock()
+
+	payments, err := s.PaymentRepo.GetPaymentsByOrder(order.ID)
+	if err != nil {
+		return models.Order{}, err
+	}
+	if len(payments) > 0 {
+		return models.Order{}, fmt.Errorf("%w: cannot update order '%s': payments have already been recorded", models.ErrConflict, order.ID)
+	}
+
+	if err := s.resolveCustomer(&order); err != nil {
+		return models.Order{}, err
+	}
+
+	existing, err := s.OrderRepo.GetOrderByID(order.ID)
+	if err != nil {
+		return models.Order{}, err
+	}
+	menuItems, err := s.MenuRepo.GetAllMenuItems()
+	if err != nil {
+		return models.Order{}, err
+	}
+	if err := validation.Order(order, productIDs(menuItems)); err != nil {
+		return models.Order{}, err
+	}
+	inventory, err := s.InventoryRepo.GetAllInventoryItems()
+	if err != nil {
+		return models.Order{}, err
+	}
+	// Check before touching stock so a stale or late edit leaves inventory
+	// alone.
+	if err := models.CheckVersion("order", order.ID, order.Version, existing.Version); err != nil {
+		return models.Order{}, err
+	}
+	if existing.Status != "open" {
+		return models.Order{}, fmt.Errorf("%w: cannot update a %s order (ID: %s)", models.ErrInvalidTransition, existing.Status, order.ID)
+	}
+	order.Items = snapshotItems(order.Items, menuItems, inventory)
+	rewardDropped, err := s.Loyalty.KeepRewards(existing, &order)
+	if err != nil {
+		return models.Order{}, err
+	}
+	s.price(&order, menuItems)
+
+	more, less := ingredientChange(existing.Items, order.Items, menuItems)
+	if err := s.InventoryRepo.CheckSufficientIngredients(more); err != nil {
+		return models.Order{}, err
+	}
+	if err := s.InventoryRepo.DeductIngredients(more); err != nil {
+		return models.Order{}, err
+	}
+	if err := s.InventoryRepo.RestoreIngredients(less); err != nil {
+		s.restoreStock(more)
+		return models.Order{}, err
+	}
+	if err := s.OrderRepo.UpdateOrder(order); err != nil {
+		s.restoreStock(more)
+		if deductErr := s.InventoryRepo.DeductIngredients(less); deductErr != nil {
+			slog.Error("Failed to take back restored ingredients", "orderID", order.ID, "error", deductErr)
+		}
+		return models.Order{}, err
+	}
+	if rewardDropped {
+		if err := s.Loyalty.RestoreRedemption(existing, "reward removed"); err != nil {
+			return models.Order{}, err
+		}
+	}
+	return s.OrderRepo.GetOrderByID(order.ID)
+}
+
+// ingredientChange compares the recipes of an order's items before and after
+// an edit. It returns the extra ingredients the new items need and those the
+// old items used that are no longer needed.
+func ingredientChange(before []models.OrderItem, after []models.OrderItem, menuItems []models.MenuItem) ([]models.MenuItemIngredient, []models.MenuItemIngredient) {
+	delta := make(map[string]float64)
+	var ids []string
+	add := func(ingredients []models.MenuItemIngredient, sign float64) {
+		for _, ing := range ingredients {
+			if _, ok := delta[ing.IngredientID]; !ok {
+				ids = append(ids, ing.IngredientID)
+			}
+			delta[ing.IngredientID] += sign * ing.Quantity
+		}
+	}
+	add(expandIngredients(after, menuItems), 1)
+	add(expandIngredients(before, menuItems), -1)
+
+	var more, less []models.MenuItemIngredient
+	for _, id := range ids {
+		// Recipes that cancel out can leave float noise rather than zero.
+		switch q := delta[id]; {
+		case q > 1e-9:
+			more = append(more, models.MenuItemIngredient{IngredientID: id, Quantity: q})
+		case q < -1e-9:
+			less = append(less, models.MenuItemIngredient{IngredientID: id, Quantity: -q})
+		}
+	}
+	return more, less
+}
+
+// PatchOrder applies a JSON merge patch to an open order. Only the fields
+// UpdateOrder accepts take effect; totals are recalculated from the result.
+// The order must still be at version, or when version is zero at the version
+// the patch was applied to.
+func (s *OrderService) PatchOrder(id string, patch []byte, version int) (models.Order, error) {
+	current, err := s.OrderRepo.GetOrderByID(id)
+	if err != nil {
+		return models.Order{}, err
+	}
+	if current.Status != "open" {
+		return models.Order{}, fmt.Errorf("%w: cannot update a %s order (ID: %s)", models.ErrInvalidTransition, current.Status, id)
+	}
+	var order models.Order
+	if err := applyPatch(current, patch, &order); err != nil {
+		return models.Order{}, err
+	}
+	order.ID = id
+	if version == 0 {
+		version = current.Version
+	}
+	order.Version = version
+	return s.UpdateOrder(order)
+}
+
+// DeleteOrder removes an order that never took money. Orders with payments
+// are part of the sales record and must be voided or refunded instead.
+func (s *OrderService) DeleteOrder(orderID string, version int) error {
+	unlock := orderLocks.Lock(orderID)
+	defer unlock()
+
 	orders, err := s.OrderRepo.GetAllOrders()
 	if err != nil {
 		return err
@@ -80,7 +365,18 @@ func (s *OrderService) DeleteOrder(orderID string) error {
 		}
 	}
 	if targetOrder == nil {
-		return errors.New("order not found")
+		return fmt.Errorf("%w: order '%s'", models.ErrNotFound, orderID)
+	}
+	// Check before restoring stock so a stale delete leaves inventory alone.
+	if err := models.CheckVersion("order", orderID, version, targetOrder.Version); err != nil {
+		return err
+	}
+	payments, err := s.PaymentRepo.GetPaymentsByOrder(orderID)
+	if err != nil {
+		return err
+	}
+	if len(payments) > 0 {
+		return fmt.Errorf("%w: cannot delete order '%s': it has payments, void or refund it instead", models.ErrConflict, orderID)
 	}
 
 	if targetOrder.Status == "open" {
@@ -110,15 +406,126 @@ func (s *OrderService) DeleteOrder(orderID string) error {
 		if err := s.InventoryRepo.RestoreIngredients(ingredientsToRestore); err != nil {
 			return err
 		}
+		if err := s.Loyalty.RestoreRedemption(*targetOrder, "order cancelled"); err != nil {
+			return err
+		}
 	}
 
-	return s.OrderRepo.DeleteOrder(orderID)
+	if err := s.OrderRepo.DeleteOrder(orderID, version); err != nil {
+		return err
+	}
+	if !isSold(targetOrder.Status) {
+		return nil
+	}
+	// A sold order without payments was fully covered by discounts; undo its
+	// loyalty activity and its place in the sales totals.
+	if err := s.Loyalty.RestoreRedemption(*targetOrder, "order cancelled"); err != nil {
+		return err
+	}
+	if err := s.Loyalty.ReverseEarned(*targetOrder, "order deleted"); err != nil {
+		return err
+	}
+	menuItems, err := s.MenuRepo.GetAllMenuItems()
+	if err != nil {
+		return err
+	}
+	return s.Aggregates.RemoveSale(*targetOrder, menuItems)
 }
 
-func (s *OrderService) CloseOrder(orderID string) error {
-	return s.OrderRepo.CloseOrder(orderID)
+func (s *OrderService) CloseOrder(orderID string) (models.Order, error) {
+	unlock := orderLocks.Lock(orderID)
+	defer unlock()
+
+	order, err := s.OrderRepo.GetOrderByID(orderID)
+	if err != nil {
+		return models.Order{}, err
+	}
+	if order.Status != "open" {
+		return models.Order{}, fmt.Errorf("%w: cannot close a %s order (ID: %s)", models.ErrInvalidTransition, order.Status, orderID)
+	}
+
+	menuItems, err := s.MenuRepo.GetAllMenuItems()
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	payments, err := s.PaymentRepo.GetPaymentsByOrder(orderID)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	var paid float64
+	for _, p := range payments {
+		paid += p.Amount
+	}
+	if due := roundMoney(orderTotal(order, menuItems) - paid); due > 0 {
+		return models.Order{}, fmt.Errorf("%w: order '%s' has a balance due of %.2f", models.ErrConflict, orderID, due)
+	}
+
+	if err := s.Aggregates.closeSale(order, menuItems, s.OrderRepo.CloseOrder); err != nil {
+		return models.Order{}, err
+	}
+	if err := s.Loyalty.EarnForOrder(order, menuItems); err != nil {
+		return models.Order{}, err
+	}
+	closed, err := s.OrderRepo.GetOrderByID(orderID)
+	if err != nil {
+		return models.Order{}, err
+	}
+	return fillTotals(closed, menuItems), nil
+}
+
+func (s *OrderService) MarkReady(orderID string, staffID string) (models.Order, error) {
+	if _, err := s.OrderRepo.GetOrderByID(orderID); err != nil {
+		return models.Order{}, err
+	}
+	order, err := s.OrderRepo.MarkReady(orderID, strings.TrimSpace(staffID))
+	if err != nil {
+		return models.Order{}, err
+	}
+	return s.withTotals(order)
 }
 
 func (s *OrderService) GetOrderByID(orderID string) (models.Order, error) {
-	return s.OrderRepo.GetOrderByID(orderID)
+	order, err := s.OrderRepo.GetOrderByID(orderID)
+	if err != nil {
+		return models.Order{}, err
+	}
+	return s.withTotals(order)
+}
+
+func (s *OrderService) withTotals(order models.Order) (models.Order, error) {
+	menuItems, err := s.MenuRepo.GetAllMenuItems()
+	if err != nil {
+		return models.Order{}, err
+	}
+	return fillTotals(order, menuItems), nil
+}
+
+func (s *OrderService) resolveCustomer(order *models.Order) error {
+	if order.CustomerID == "" {
+		return nil
+	}
+	customer, err := s.CustomerRepo.GetCustomer(order.CustomerID)
+	if err != nil {
+		return fmt.Errorf("%w: unknown customer ID '%s'", ErrInvalidCustomer, order.CustomerID)
+	}
+	order.CustomerName = customer.Name
+	return nil
+}
+
+// price sets the tax, subtotal and total stored with the order.
+func (s *OrderService) price(order *models.Order, menuItems []models.MenuItem) {
+	order.Subtotal = orderSubtotal(*order, menuItems)
+	taxable := math.Max(order.Subtotal-orderDiscounts(*order), 0)
+	order.Tax = roundMoney(taxable * s.TaxRate)
+	order.Total = orderTotal(*order, menuItems)
+}
+
+func productIDs(menuItems []models.MenuItem) map[string]bool {
+	ids := make(map[string]bool, len(menuItems))
+	for _, item := range menuItems {
+		ids[item.ID] = true
+	}
+	return ids
 }
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"errors"
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/gateway"
+	"hot-coffee/internal/idgen"
+	"hot-coffee/models"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testShop wires every service over JSON files in one temporary directory.
+type testShop struct {
+	Orders     *OrderService
+	Menu       *MenuService
+	Inventory  *InventoryService
+	Customers  *CustomerService
+	Payments   *PaymentService
+	Refunds    *RefundService
+	Sessions   *CashSessionService
+	GiftCards  *GiftCardService
+	Loyalty    *LoyaltyService
+	Reports    *ReportService
+	Accounting *AccountingService
+	Gateway    *gateway.MockProcessor
+}
+
+// newTestShop returns a shop without tax, stocked with 10 units of milk, a
+// latte at 5.00 using 0.2 milk, a tea at 3.00 and the customers "c1" and
+// "c2".
+func newTestShop(t *testing.T) *testShop {
+	t.Helper()
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	inventoryRepo := dal.NewJSONInventoryManager(path("inventory.json"), path("inventory_movements.json"))
+	if _, err := inventoryRepo.AddNewInventoryItem(models.InventoryItem{IngredientID: "milk", Name: "Milk", Quantity: 10, Unit: "l", UnitCost: 1}); err != nil {
+		t.Fatalf("AddNewInventoryItem() error = %v", err)
+	}
+	menuRepo := dal.NewJSONMenuManager(path("menu_items.json"))
+	for _, item := range []models.MenuItem{
+		{ID: "latte", Name: "Latte", Category: "coffee", Price: 5, Ingredients: []models.MenuItemIngredient{{IngredientID: "milk", Quantity: 0.2}}},
+		{ID: "tea", Name: "Tea", Category: "tea", Price: 3},
+	} {
+		if _, err := menuRepo.AddNewMenuItem(item); err != nil {
+			t.Fatalf("AddNewMenuItem() error = %v", err)
+		}
+	}
+	customerRepo := dal.NewJSONCustomerManager(path("customers.json"))
+	for _, id := range []string{"c1", "c2"} {
+		if err := customerRepo.AddCustomer(models.Customer{ID: id, Name: "Customer " + id}); err != nil {
+			t.Fatalf("AddCustomer() error = %v", err)
+		}
+	}
+	orderRepo := dal.NewJSONOrderManager(path("orders.json"), path("order_counters.json"), idgen.NewSequential(time.UTC), time.UTC)
+	refundRepo := dal.NewJSONRefundManager(path("refunds.json"))
+	paymentRepo := dal.NewJSONPaymentManager(path("payments.json"))
+	sessionRepo := dal.NewJSONCashSessionManager(path("cash_sessions.json"))
+	giftCardRepo := dal.NewJSONGiftCardManager(path("gift_cards.json"), path("gift_card_transactions.json"))
+	aggregateRepo := dal.NewJSONAggregateManager(path("aggregates.json"))
+	chartRepo := dal.NewJSONAccountingManager(path("chart_of_accounts.json"))
+	gw := gateway.NewMockProcessor()
+
+	loyalty := NewLoyaltyService(dal.NewJSONLoyaltyManager(path("loyalty_program.json"), path("loyalty_ledger.json")), customerRepo)
+	aggregates := NewAggregateService(aggregateRepo, orderRepo, menuRepo, refundRepo, time.UTC)
+	giftCards := NewGiftCardService(giftCardRepo, sessionRepo, gw)
+	return &testShop{
+		Orders:     NewOrderService(orderRepo, menuRepo, inventoryRepo, paymentRepo, customerRepo, loyalty, aggregates, 0, time.UTC),
+		Menu:       NewMenuService(menuRepo, orderRepo, inventoryRepo),
+		Inventory:  NewInventoryService(inventoryRepo, menuRepo),
+		Customers:  NewCustomerService(customerRepo, orderRepo, menuRepo, refundRepo),
+		Payments:   NewPaymentService(paymentRepo, orderRepo, menuRepo, refundRepo, sessionRepo, loyalty, giftCards, aggregates, gw, testSecret),
+		Refunds:    NewRefundService(refundRepo, orderRepo, menuRepo, inventoryRepo, paymentRepo, sessionRepo, loyalty, giftCards, aggregates, gw),
+		Sessions:   NewCashSessionService(sessionRepo, paymentRepo, refundRepo, giftCardRepo),
+		GiftCards:  giftCards,
+		Loyalty:    loyalty,
+		Reports:    NewReportService(orderRepo, menuRepo, paymentRepo, refundRepo, sessionRepo, inventoryRepo, aggregateRepo, chartRepo, giftCardRepo, time.UTC),
+		Accounting: NewAccountingService(chartRepo),
+		Gateway:    gw,
+	}
+}
+
+// sell opens a cash drawer if none is open, then places order
+// and pays it in full with tender, which closes it.
+func (shop *testShop) sell(t *testing.T, order models.Order, tender string) models.Order {
+	t.Helper()
+	if _, open, _ := shop.Sessions.SessionRepo.GetOpenSession(); !open {
+		if _, err := shop.Sessions.OpenSession(models.CashSession{OpeningFloat: 50}); err != nil {
+			t.Fatalf("OpenSession() error = %v", err)
+		}
+	}
+	if order.CustomerID == "" && order.CustomerName == "" {
+		order.CustomerName = "Alice"
+	}
+	created, err := shop.Orders.CreateOrder(order)
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	if created.Total > 0 {
+		payment := models.Payment{Tender: tender, Amount: created.Total}
+		if tender == models.TenderCard {
+			payment.CardToken = "tok_visa"
+		}
+		if _, err := shop.Payments.AddPayment(created.ID, payment); err != nil {
+			t.Fatalf("AddPayment() error = %v", err)
+		}
+		sold, _ := shop.Orders.OrderRepo.GetOrderByID(created.ID)
+		return sold
+	}
+	sold, err := shop.Orders.CloseOrder(created.ID)
+	if err != nil {
+		t.Fatalf("CloseOrder() error = %v", err)
+	}
+	return sold
+}
+
+// newTestOrderService returns the order service of a new test shop.
+func newTestOrderService(t *testing.T) *OrderService {
+	t.Helper()
+	return newTestShop(t).Orders
+}
+
+func milkLeft(t *testing.T, s *OrderService) float64 {
+	t.Helper()
+	item, err := s.InventoryRepo.GetInventoryItem("milk")
+	if err != nil {
+		t.Fatalf("GetInventoryItem() error = %v", err)
+	}
+	return item.Quantity
+}
+
+func TestPatchOrderAdjustsStock(t *testing.T) {
+	tests := []struct {
+		name     string
+		patch    string
+		wantMilk float64
+		wantErr  error
+	}{
+		{name: "more lattes", patch: `{"items":[{"product_id":"latte","quantity":3}]}`, wantMilk: 9.4},
+		{name: "fewer lattes", patch: `{"items":[{"product_id":"latte","quantity":1}]}`, wantMilk: 9.8},
+		{name: "swap for tea", patch: `{"items":[{"product_id":"tea","quantity":1}]}`, wantMilk: 10},
+		{name: "same recipe", patch: `{"customer_name":"Bob"}`, wantMilk: 9.6},
+		{name: "not enough milk", patch: `{"items":[{"product_id":"latte","quantity":60}]}`, wantMilk: 9.6, wantErr: models.ErrInsufficientStock},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestOrderService(t)
+			order, err := s.CreateOrder(models.Order{CustomerName: "Alice", Items: []models.OrderItem{{ProductID: "latte", Quantity: 2}}})
+			if err != nil {
+				t.Fatalf("CreateOrder() error = %v", err)
+			}
+
+			_, err = s.PatchOrder(order.ID, []byte(tt.patch), order.Version)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PatchOrder() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := milkLeft(t, s); !moneyEqual(got, tt.wantMilk) {
+				t.Errorf("milk = %.2f, want %.2f", got, tt.wantMilk)
+			}
+
+			// Deleting the edited order must put back exactly what it holds.
+			current, _ := s.OrderRepo.GetOrderByID(order.ID)
+			if err := s.DeleteOrder(order.ID, current.Version); err != nil {
+				t.Fatalf("DeleteOrder() error = %v", err)
+			}
+			if got := milkLeft(t, s); !moneyEqual(got, 10) {
+				t.Errorf("milk after delete = %.2f, want 10.00", got)
+			}
+		})
+	}
+}
+
+func TestPatchOrderStaleVersionKeepsStock(t *testing.T) {
+	s := newTestOrderService(t)
+	order, err := s.CreateOrder(models.Order{CustomerName: "Alice", Items: []models.OrderItem{{ProductID: "latte", Quantity: 2}}})
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+
+	_, err = s.PatchOrder(order.ID, []byte(`{"items":[{"product_id":"latte","quantity":5}]}`), order.Version+1)
+	if !errors.Is(err, models.ErrVersionMismatch) {
+		t.Fatalf("PatchOrder() error = %v, want %v", err, models.ErrVersionMismatch)
+	}
+	if got := milkLeft(t, s); !moneyEqual(got, 9.6) {
+		t.Errorf("milk = %.2f, want 9.60", got)
+	}
+}
+
+func moneyEqual(a float64, b float64) bool {
+	return roundMoney(a) == roundMoney(b)
+}
+
+func TestCreateOrderTax(t *testing.T) {
+	tests := []struct {
+		name      string
+		rate      float64
+		order     models.Order
+		wantTax   float64
+		wantTotal float64
+	}{
+		{name: "no tax", order: models.Order{Items: []models.OrderItem{{ProductID: "latte", Quantity: 1}}}, wantTotal: 5},
+		{name: "ten percent", rate: 0.1, order: models.Order{Items: []models.OrderItem{{ProductID: "latte", Quantity: 1}}}, wantTax: 0.5, wantTotal: 5.5},
+		{name: "rounded", rate: 0.0825, order: models.Order{Items: []models.OrderItem{{ProductID: "tea", Quantity: 1}}}, wantTax: 0.25, wantTotal: 3.25},
+		{name: "after discounts", rate: 0.1, order: models.Order{
+			CustomerID:     "c1",
+			RedeemRewardID: "free-latte",
+			Items:          []models.OrderItem{{ProductID: "latte", Quantity: 1}, {ProductID: "tea", Quantity: 1}},
+		}, wantTax: 0.3, wantTotal: 3.3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestOrderService(t)
+			withFreeLatte(t, s)
+			s.TaxRate = tt.rate
+			if tt.order.CustomerID == "" {
+				tt.order.CustomerName = "Alice"
+			}
+			order, err := s.CreateOrder(tt.order)
+			if err != nil {
+				t.Fatalf("CreateOrder() error = %v", err)
+			}
+			if order.Tax != tt.wantTax || order.Total != tt.wantTotal {
+				t.Errorf("tax %.2f, total %.2f, want %.2f and %.2f", order.Tax, order.Total, tt.wantTax, tt.wantTotal)
+			}
+		})
+	}
+}
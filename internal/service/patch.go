@@ -0,0 +1,33 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hot-coffee/internal/mergepatch"
+	"hot-coffee/internal/validation"
+	"hot-coffee/models"
+)
+
+var ErrInvalidPatch = models.NewError(models.ErrInvalidInput, "invalid merge patch")
+
+// applyPatch merges patch into the JSON form of current and decodes the result
+// into target with the same strict rules as a full request body.
+func applyPatch(current any, patch []byte, target any) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &fields); err != nil || fields == nil {
+		return fmt.Errorf("%w: %w", ErrInvalidPatch, validation.Errors{{Code: validation.CodeMalformed, Message: "merge patch must be a JSON object"}})
+	}
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	merged, err := mergepatch.Apply(doc, patch)
+	if err != nil {
+		return err
+	}
+	if err := validation.Decode(bytes.NewReader(merged), target); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidPatch, err)
+	}
+	return nil
+}
@@ -0,0 +1,344 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/gateway"
+	"hot-coffee/models"
+	"log/slog"
+	"math"
+	"slices"
+	"time"
+)
+
+var (
+	ErrInvalidPayment  = models.NewError(models.ErrInvalidInput, "invalid payment")
+	ErrPaymentDeclined = models.NewError(models.ErrDeclined, "payment declined")
+	ErrInvalidCallback = models.NewError(models.ErrInvalidInput, "invalid gateway callback")
+)
+
+type PaymentService struct {
+	PaymentRepo dal.PaymentManager
+	OrderRepo   dal.OrderManager
+	MenuRepo    dal.MenuManager
+	RefundRepo  dal.RefundManager
+	SessionRepo dal.CashSessionManager
+	Loyalty     *LoyaltyService
+	Aggregates  *AggregateService
+	GiftCards   *GiftCardService
+	Gateway     gateway.PaymentGateway
+	// CallbackSecret verifies the signature on gateway callbacks.
+	CallbackSecret string
+}
+
+func NewPaymentService(paymentRepo dal.PaymentManager, orderRepo dal.OrderManager, menuRepo dal.MenuManager, refundRepo dal.RefundManager, sessionRepo dal.CashSessionManager, loyalty *LoyaltyService, giftCards *GiftCardService, aggregates *AggregateService, gw gateway.PaymentGateway, callbackSecret string) *PaymentService {
+	return &PaymentService{
+		PaymentRepo: paymentRepo,
+		OrderRepo:   orderRepo,
+		MenuRepo:    menuRepo,
+		RefundRepo:  refundRepo,
+		SessionRepo: sessionRepo,
+		Loyalty:     loyalty,
+		GiftCards:   giftCards,
+		Aggregates:  aggregates,
+		Gateway:     gw,
+
+		CallbackSecret: callbackSecret,
+	}
+}
+
+func (s *PaymentService) AddPayment(orderID string, payment models.Payment) (models.OrderPayments, error) {
+	unlock := orderLocks.Lock(orderID)
+	defer unlock()
+
+	summary, err := s.GetOrderPayments(orderID)
+	if err != nil {
+		return models.OrderPayments{}, err
+	}
+	if summary.Status != "open" {
+		return models.OrderPayments{}, fmt.Errorf("%w: order '%s' is %s", models.ErrInvalidTransition, orderID, summary.Status)
+	}
+
+	payment.Amount = roundMoney(payment.Amount)
+	payment.Tip = roundMoney(payment.Tip)
+	payment.Tendered = roundMoney(payment.Tendered)
+
+	switch payment.Tender {
+	case models.TenderCash, models.TenderCard, models.TenderGiftCard:
+	default:
+		return models.OrderPayments{}, fmt.Errorf("%w: unknown tender '%s'", ErrInvalidPayment, payment.Tender)
+	}
+	if payment.Amount <= 0 {
+		return models.OrderPayments{}, fmt.Errorf("%w: amount must be positive", ErrInvalidPayment)
+	}
+	if payment.Tip < 0 {
+		return models.OrderPayments{}, fmt.Errorf("%w: tip cannot be negative", ErrInvalidPayment)
+	}
+	if payment.Amount > summary.BalanceDue {
+		return models.OrderPayments{}, fmt.Errorf("%w: amount %.2f exceeds balance due %.2f", ErrInvalidPayment, payment.Amount, summary.BalanceDue)
+	}
+
+	payment.ChangeGiven = 0
+	if payment.Tender == models.TenderCash {
+		if payment.Tendered == 0 {
+			payment.Tendered = payment.Amount + payment.Tip
+		}
+		if payment.Tendered < payment.Amount+payment.Tip {
+			return models.OrderPayments{}, fmt.Errorf("%w: tendered %.2f is less than amount plus tip", ErrInvalidPayment, payment.Tendered)
+		}
+		payment.ChangeGiven = roundMoney(payment.Tendered - payment.Amount - payment.Tip)
+	} else {
+		payment.Tendered = 0
+	}
+	if payment.Tender != models.TenderGiftCard {
+		payment.GiftCardCode = ""
+	}
+
+	session, open, err := s.SessionRepo.GetOpenSession()
+	if err != nil {
+		return models.OrderPayments{}, err
+	}
+	if payment.Tender == models.TenderCash && !open {
+		return models.OrderPayments{}, fmt.Errorf("%w: no cash drawer session is open", ErrInvalidPayment)
+	}
+	payment.SessionID = session.ID
+
+	payment.ID = fmt.Sprintf("%s-p%d", orderID, len(summary.Payments)+1)
+	payment.OrderID = orderID
+	payment.CreatedAt = time.Now().Format(time.RFC3339)
+	switch payment.Tender {
+	case models.TenderCard:
+		err = s.chargeCard(&payment)
+	case models.TenderGiftCard:
+		if payment.GiftCardCode == "" {
+			return models.OrderPayments{}, fmt.Errorf("%w: gift_card_code is required for gift card payments", ErrInvalidPayment)
+		}
+		if err := s.GiftCards.Redeem(payment.GiftCardCode, payment.Amount+payment.Tip, orderID, payment.ID); err != nil {
+			return models.OrderPayments{}, fmt.Errorf("%w: %v", ErrInvalidPayment, err)
+		}
+		if err = s.PaymentRepo.AddPayment(payment); err != nil {
+			// Give the value back rather than leave it spent on nothing.
+			if creditErr := s.GiftCards.Credit(payment.GiftCardCode, payment.Amount+payment.Tip, orderID, payment.ID); creditErr != nil {
+				slog.Error("Failed to credit gift card after payment failure", "code", payment.GiftCardCode, "paymentID", payment.ID, "error", creditErr)
+			}
+		}
+	default:
+		err = s.PaymentRepo.AddPayment(payment)
+	}
+	if err != nil {
+		return models.OrderPayments{}, err
+	}
+
+	summary.Payments = append(summary.Payments, payment)
+	summary.Paid = roundMoney(summary.Paid + payment.Amount)
+	summary.Tips = roundMoney(summary.Tips + payment.Tip)
+	summary.BalanceDue = roundMoney(summary.Total - summary.Paid)
+
+	if summary.BalanceDue == 0 {
+		if err := s.closeOrder(orderID); err != nil {
+			return models.OrderPayments{}, err
+		}
+		summary.Status = "closed"
+	}
+	return summary, nil
+}
+
+func (s *PaymentService) GetOrderPayments(orderID string) (models.OrderPayments, error) {
+	order, err := s.OrderRepo.GetOrderByID(orderID)
+	if err != nil {
+		return models.OrderPayments{}, err
+	}
+
+	menuItems, err := s.MenuRepo.GetAllMenuItems()
+	if err != nil {
+		return models.OrderPayments{}, err
+	}
+
+	payments, err := s.PaymentRepo.GetPaymentsByOrder(orderID)
+	if err != nil {
+		return models.OrderPayments{}, err
+	}
+
+	refunds, err := s.RefundRepo.GetRefundsByOrder(orderID)
+	if err != nil {
+		return models.OrderPayments{}, err
+	}
+
+	summary := models.OrderPayments{
+		OrderID:  order.ID,
+		Status:   order.Status,
+		Total:    orderTotal(order, menuItems),
+		Payments: append([]models.Payment{}, payments...),
+	}
+	for _, p := range payments {
+		summary.Paid += p.Amount
+		summary.Tips += p.Tip
+	}
+	for _, r := range refunds {
+		summary.Refunded += r.Amount
+	}
+	summary.Paid = roundMoney(summary.Paid)
+	summary.Tips = roundMoney(summary.Tips)
+	summary.Refunded = roundMoney(summary.Refunded)
+	summary.BalanceDue = roundMoney(math.Max(summary.Total-summary.Paid, 0))
+	return summary, nil
+}
+
+func (s *PaymentService) GetOrderPayment(orderID string, paymentID string) (models.Payment, error) {
+	if _, err := s.OrderRepo.GetOrderByID(orderID); err != nil {
+		return models.Payment{}, err
+	}
+	payments, err := s.PaymentRepo.GetPaymentsByOrder(orderID)
+	if err != nil {
+		return models.Payment{}, err
+	}
+	for _, p := range payments {
+		if p.ID == paymentID {
+			return p, nil
+		}
+	}
+	return models.Payment{}, fmt.Errorf("%w: payment '%s' on order '%s'", models.ErrNotFound, paymentID, orderID)
+}
+
+func (s *PaymentService) closeOrder(orderID string) error {
+	order, err := s.OrderRepo.GetOrderByID(orderID)
+	if err != nil {
+		return err
+	}
+	menuItems, err := s.MenuRepo.GetAllMenuItems()
+	if err != nil {
+		return err
+	}
+	if err := s.Aggregates.closeSale(order, menuItems, s.OrderRepo.CloseOrder); err != nil {
+		return err
+	}
+	return s.Loyalty.EarnForOrder(order, menuItems)
+}
+
+// chargeCard authorizes the card, records the payment and only then captures,
+// so money is never taken for a payment the shop has no record of. A failed
+// capture voids the authorization and removes the record again. Each attempt
+// uses its own gateway idempotency key: payment IDs are reused once a failed
+// payment is removed, and the gateway would otherwise replay the failure.
+func (s *PaymentService) chargeCard(payment *models.Payment) error {
+	if payment.CardToken == "" {
+		return fmt.Errorf("%w: card_token is required for card payments", ErrInvalidPayment)
+	}
+	token := payment.CardToken
+	key := fmt.Sprintf("%s:%d", payment.ID, time.Now().UnixNano())
+	amount := roundMoney(payment.Amount + payment.Tip)
+	payment.CardToken = ""
+
+	auth, err := authorizeCard(s.Gateway, key, payment.OrderID, amount, token)
+	if err != nil {
+		return err
+	}
+
+	payment.GatewayTransactionID = auth.TransactionID
+	payment.GatewayStatus = auth.Status
+	if err := s.PaymentRepo.AddPayment(*payment); err != nil {
+		voidAuthorization(s.Gateway, auth.TransactionID, key)
+		return err
+	}
+
+	capture, err := captureCard(s.Gateway, auth.TransactionID, amount, key)
+	if err != nil {
+		voidAuthorization(s.Gateway, auth.TransactionID, key)
+		if delErr := s.PaymentRepo.DeletePayment(payment.ID); delErr != nil {
+			slog.Error("Failed to remove payment after capture failure", "paymentID", payment.ID, "error", delErr)
+		}
+		return err
+	}
+
+	payment.GatewayTransactionID = capture.TransactionID
+	payment.GatewayStatus = capture.Status
+	if err := s.PaymentRepo.UpdatePayment(*payment); err != nil {
+		// The payment is on record either way; the gateway's capture
+		// callback brings its status up to date.
+		slog.Error("Failed to record capture", "paymentID", payment.ID, "transactionID", capture.TransactionID, "error", err)
+	}
+	return nil
+}
+
+// authorizeCard places a hold for amount on the card behind token.
+func authorizeCard(gw gateway.PaymentGateway, key string, reference string, amount float64, token string) (gateway.Result, error) {
+	auth, err := gw.Authorize(gateway.AuthorizeRequest{
+		IdempotencyKey: key,
+		OrderID:        reference,
+		Amount:         amount,
+		CardToken:      token,
+	})
+	if errors.Is(err, gateway.ErrDeclined) {
+		return auth, fmt.Errorf("%w: %s", ErrPaymentDeclined, auth.Message)
+	}
+	return auth, err
+}
+
+// captureCard takes an authorized amount. Callers release the hold with
+// voidAuthorization when it fails.
+func captureCard(gw gateway.PaymentGateway, transactionID string, amount float64, key string) (gateway.Result, error) {
+	capture, err := gw.Capture(transactionID, amount, key)
+	if err != nil {
+		return gateway.Result{}, err
+	}
+	return capture, nil
+}
+
+func voidAuthorization(gw gateway.PaymentGateway, transactionID string, key string) {
+	if _, err := gw.Void(transactionID, key); err != nil {
+		slog.Error("Failed to void authorization", "transactionID", transactionID, "error", err)
+	}
+}
+
+// HandleGatewayCallback applies a signed status update from the gateway.
+// Each event is applied at most once, and a status never moves backwards, so
+// redelivered and out-of-order callbacks are harmless.
+func (s *PaymentService) HandleGatewayCallback(body []byte, signature string) (gateway.Callback, error) {
+	if err := gateway.VerifySignature(s.CallbackSecret, body, signature); err != nil {
+		return gateway.Callback{}, err
+	}
+	var callback gateway.Callback
+	if err := json.Unmarshal(body, &callback); err != nil {
+		return gateway.Callback{}, fmt.Errorf("%w: %v", ErrInvalidCallback, err)
+	}
+	if callback.EventID == "" || callback.TransactionID == "" {
+		return callback, fmt.Errorf("%w: event_id and transaction_id are required", ErrInvalidCallback)
+	}
+
+	payment, err := s.paymentByTransaction(callback.TransactionID)
+	if err != nil {
+		return callback, err
+	}
+	unlock := orderLocks.Lock(payment.OrderID)
+	defer unlock()
+	if payment, err = s.paymentByTransaction(callback.TransactionID); err != nil {
+		return callback, err
+	}
+
+	if slices.Contains(payment.GatewayEvents, callback.EventID) {
+		slog.Info("Ignoring duplicate gateway callback", "eventID", callback.EventID, "transactionID", callback.TransactionID)
+		return callback, nil
+	}
+	payment.GatewayEvents = append(payment.GatewayEvents, callback.EventID)
+	if gateway.StatusRank(callback.Status) > gateway.StatusRank(payment.GatewayStatus) {
+		payment.GatewayStatus = callback.Status
+	} else {
+		slog.Info("Ignoring stale gateway callback", "eventID", callback.EventID, "transactionID", callback.TransactionID, "status", callback.Status)
+	}
+	return callback, s.PaymentRepo.UpdatePayment(payment)
+}
+
+func (s *PaymentService) paymentByTransaction(transactionID string) (models.Payment, error) {
+	payments, err := s.PaymentRepo.GetAllPayments()
+	if err != nil {
+		return models.Payment{}, err
+	}
+	for _, p := range payments {
+		if p.GatewayTransactionID == transactionID {
+			return p, nil
+		}
+	}
+	return models.Payment{}, fmt.Errorf("%w: payment for gateway transaction '%s'", models.ErrNotFound, transactionID)
+}
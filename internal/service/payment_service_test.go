@@ -0,0 +1,288 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/gateway"
+	"hot-coffee/internal/idgen"
+	"hot-coffee/models"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testSecret = "test-secret"
+
+// failingCapture approves authorizations but fails every capture.
+type failingCapture struct {
+	*gateway.MockProcessor
+	voided []string
+}
+
+func (g *failingCapture) Capture(transactionID string, amount float64, idempotencyKey string) (gateway.Result, error) {
+	return gateway.Result{}, gateway.ErrUnavailable
+}
+
+func (g *failingCapture) Void(transactionID string, idempotencyKey string) (gateway.Result, error) {
+	g.voided = append(g.voided, transactionID)
+	return g.MockProcessor.Void(transactionID, idempotencyKey)
+}
+
+// flakyCapture fails the first capture and behaves normally after that.
+type flakyCapture struct {
+	*gateway.MockProcessor
+	failed bool
+}
+
+func (g *flakyCapture) Capture(transactionID string, amount float64, idempotencyKey string) (gateway.Result, error) {
+	if !g.failed {
+		g.failed = true
+		return gateway.Result{}, gateway.ErrTimeout
+	}
+	return g.MockProcessor.Capture(transactionID, amount, idempotencyKey)
+}
+
+// newTestPaymentService returns a service over empty JSON files in a
+// temporary directory and the ID of an open order totalling 5.00.
+func newTestPaymentService(t *testing.T, gw gateway.PaymentGateway) (*PaymentService, string) {
+	t.Helper()
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	menuRepo := dal.NewJSONMenuManager(path("menu_items.json"))
+	if _, err := menuRepo.AddNewMenuItem(models.MenuItem{ID: "latte", Name: "Latte", Price: 5}); err != nil {
+		t.Fatalf("AddNewMenuItem() error = %v", err)
+	}
+	orderRepo := dal.NewJSONOrderManager(path("orders.json"), path("order_counters.json"), idgen.NewSequential(time.UTC), time.UTC)
+	order, err := orderRepo.CreateOrder(models.Order{
+		CustomerName: "Alice",
+		Items:        []models.OrderItem{{ProductID: "latte", Quantity: 1}},
+		Status:       "open",
+		CreatedAt:    time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+
+	s := NewPaymentService(
+		dal.NewJSONPaymentManager(path("payments.json")),
+		orderRepo,
+		menuRepo,
+		dal.NewJSONRefundManager(path("refunds.json")),
+		dal.NewJSONCashSessionManager(path("cash_sessions.json")),
+		nil, nil, nil,
+		gw,
+		testSecret,
+	)
+	return s, order.ID
+}
+
+func cardPayment(amount float64, token string) models.Payment {
+	return models.Payment{Tender: models.TenderCard, Amount: amount, CardToken: token}
+}
+
+func TestAddPaymentDeclined(t *testing.T) {
+	s, orderID := newTestPaymentService(t, gateway.NewMockProcessor())
+
+	_, err := s.AddPayment(orderID, cardPayment(2, gateway.TokenDecline))
+	if !errors.Is(err, ErrPaymentDeclined) {
+		t.Fatalf("AddPayment() error = %v, want ErrPaymentDeclined", err)
+	}
+	summary, err := s.GetOrderPayments(orderID)
+	if err != nil {
+		t.Fatalf("GetOrderPayments() error = %v", err)
+	}
+	if len(summary.Payments) != 0 || summary.BalanceDue != 5 {
+		t.Errorf("payments = %d, balance due = %.2f, want none and 5.00", len(summary.Payments), summary.BalanceDue)
+	}
+}
+
+func TestAddPaymentCaptured(t *testing.T) {
+	s, orderID := newTestPaymentService(t, gateway.NewMockProcessor())
+
+	summary, err := s.AddPayment(orderID, cardPayment(2, "tok_visa"))
+	if err != nil {
+		t.Fatalf("AddPayment() error = %v", err)
+	}
+	stored, err := s.PaymentRepo.GetPaymentsByOrder(orderID)
+	if err != nil {
+		t.Fatalf("GetPaymentsByOrder() error = %v", err)
+	}
+	if len(stored) != 1 || stored[0].GatewayStatus != gateway.StatusCaptured || stored[0].CardToken != "" {
+		t.Errorf("stored payments = %+v, want one captured payment without its card token", stored)
+	}
+	if summary.BalanceDue != 3 {
+		t.Errorf("balance due = %.2f, want 3.00", summary.BalanceDue)
+	}
+}
+
+func TestAddPaymentCaptureFailure(t *testing.T) {
+	gw := &failingCapture{MockProcessor: gateway.NewMockProcessor()}
+	s, orderID := newTestPaymentService(t, gw)
+
+	_, err := s.AddPayment(orderID, cardPayment(2, "tok_visa"))
+	if !errors.Is(err, gateway.ErrUnavailable) {
+		t.Fatalf("AddPayment() error = %v, want ErrUnavailable", err)
+	}
+	stored, err := s.PaymentRepo.GetPaymentsByOrder(orderID)
+	if err != nil {
+		t.Fatalf("GetPaymentsByOrder() error = %v", err)
+	}
+	if len(stored) != 0 {
+		t.Errorf("stored payments = %+v, want none after a failed capture", stored)
+	}
+	if len(gw.voided) != 1 {
+		t.Errorf("voided %d authorizations, want 1", len(gw.voided))
+	}
+}
+
+func TestAddPaymentRetryAfterCaptureFailure(t *testing.T) {
+	gw := &flakyCapture{MockProcessor: gateway.NewMockProcessor()}
+	s, orderID := newTestPaymentService(t, gw)
+
+	if _, err := s.AddPayment(orderID, cardPayment(2, "tok_visa")); !errors.Is(err, gateway.ErrTimeout) {
+		t.Fatalf("AddPayment() error = %v, want ErrTimeout", err)
+	}
+	// The retry reuses the payment ID, but must reach the gateway afresh
+	// rather than replay the failed attempt.
+	summary, err := s.AddPayment(orderID, cardPayment(2, "tok_visa"))
+	if err != nil {
+		t.Fatalf("AddPayment() retry error = %v", err)
+	}
+	if len(summary.Payments) != 1 || summary.Payments[0].GatewayStatus != gateway.StatusCaptured {
+		t.Fatalf("payments = %+v, want one captured payment", summary.Payments)
+	}
+	// The mock reports a transaction's status when refusing to capture it.
+	first, err := gw.Capture("txn_000001", 2, "check")
+	if err == nil || first.Status != gateway.StatusVoided {
+		t.Errorf("first authorization = %s, want it voided", first.Status)
+	}
+}
+
+func signedCallback(t *testing.T, callback gateway.Callback) ([]byte, string) {
+	t.Helper()
+	body, err := json.Marshal(callback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body, gateway.Sign(testSecret, body)
+}
+
+func TestHandleGatewayCallback(t *testing.T) {
+	s, orderID := newTestPaymentService(t, gateway.NewMockProcessor())
+	if _, err := s.AddPayment(orderID, cardPayment(2, "tok_visa")); err != nil {
+		t.Fatalf("AddPayment() error = %v", err)
+	}
+	stored, _ := s.PaymentRepo.GetPaymentsByOrder(orderID)
+	txID := stored[0].GatewayTransactionID
+
+	refunded := gateway.Callback{EventID: "evt_1", TransactionID: txID, Status: gateway.StatusRefunded}
+	stale := gateway.Callback{EventID: "evt_2", TransactionID: txID, Status: gateway.StatusAuthorized}
+
+	steps := []struct {
+		name       string
+		callback   gateway.Callback
+		signature  string
+		wantErr    error
+		wantStatus string
+		wantEvents int
+	}{
+		{name: "unsigned", callback: refunded, signature: "sha256=00", wantErr: gateway.ErrInvalidSignature, wantStatus: gateway.StatusCaptured},
+		{name: "applied", callback: refunded, wantStatus: gateway.StatusRefunded, wantEvents: 1},
+		{name: "duplicate", callback: refunded, wantStatus: gateway.StatusRefunded, wantEvents: 1},
+		{name: "stale", callback: stale, wantStatus: gateway.StatusRefunded, wantEvents: 2},
+		{name: "missing event ID", callback: gateway.Callback{TransactionID: txID, Status: gateway.StatusVoided}, wantErr: ErrInvalidCallback, wantStatus: gateway.StatusRefunded, wantEvents: 2},
+		{name: "unknown transaction", callback: gateway.Callback{EventID: "evt_3", TransactionID: "txn_x", Status: gateway.StatusVoided}, wantErr: models.ErrNotFound, wantStatus: gateway.StatusRefunded, wantEvents: 2},
+	}
+	for _, step := range steps {
+		body, signature := signedCallback(t, step.callback)
+		if step.signature != "" {
+			signature = step.signature
+		}
+		_, err := s.HandleGatewayCallback(body, signature)
+		if !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: HandleGatewayCallback() error = %v, want %v", step.name, err, step.wantErr)
+		}
+		stored, _ := s.PaymentRepo.GetPaymentsByOrder(orderID)
+		if stored[0].GatewayStatus != step.wantStatus || len(stored[0].GatewayEvents) != step.wantEvents {
+			t.Errorf("%s: status = %q with %d events, want %q with %d", step.name, stored[0].GatewayStatus, len(stored[0].GatewayEvents), step.wantStatus, step.wantEvents)
+		}
+	}
+}
+
+func TestAddPaymentSplit(t *testing.T) {
+	cash := func(amount, tip, tendered float64) models.Payment {
+		return models.Payment{Tender: models.TenderCash, Amount: amount, Tip: tip, Tendered: tendered}
+	}
+	tests := []struct {
+		name        string
+		payments    []models.Payment
+		wantErr     error
+		wantBalance float64
+		wantTips    float64
+		wantChange  float64
+		wantStatus  string
+	}{
+		{name: "exact cash", payments: []models.Payment{cash(10, 0, 0)}, wantStatus: "closed"},
+		{name: "cash with change", payments: []models.Payment{cash(10, 1, 20)}, wantTips: 1, wantChange: 9, wantStatus: "closed"},
+		{name: "split cash and card", payments: []models.Payment{cash(4, 0, 0), cardPayment(6, "tok_visa")}, wantStatus: "closed"},
+		{name: "partly paid", payments: []models.Payment{cash(4, 0.5, 0)}, wantBalance: 6, wantTips: 0.5, wantStatus: "open"},
+		{name: "overpaid", payments: []models.Payment{cash(11, 0, 0)}, wantErr: ErrInvalidPayment, wantBalance: 10, wantStatus: "open"},
+		{name: "short tendered", payments: []models.Payment{cash(10, 0, 5)}, wantErr: ErrInvalidPayment, wantBalance: 10, wantStatus: "open"},
+		{name: "negative tip", payments: []models.Payment{cash(10, -1, 0)}, wantErr: ErrInvalidPayment, wantBalance: 10, wantStatus: "open"},
+		{name: "unknown tender", payments: []models.Payment{{Tender: "cheque", Amount: 10}}, wantErr: ErrInvalidPayment, wantBalance: 10, wantStatus: "open"},
+		{name: "paid order", payments: []models.Payment{cash(10, 0, 0), cash(1, 0, 0)}, wantErr: models.ErrInvalidTransition, wantStatus: "closed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shop := newTestShop(t)
+			if _, err := shop.Sessions.OpenSession(models.CashSession{OpeningFloat: 50}); err != nil {
+				t.Fatalf("OpenSession() error = %v", err)
+			}
+			order, err := shop.Orders.CreateOrder(models.Order{CustomerName: "Alice", Items: []models.OrderItem{{ProductID: "latte", Quantity: 2}}})
+			if err != nil {
+				t.Fatalf("CreateOrder() error = %v", err)
+			}
+
+			for i, payment := range tt.payments {
+				_, err = shop.Payments.AddPayment(order.ID, payment)
+				if i < len(tt.payments)-1 && err != nil {
+					t.Fatalf("AddPayment() #%d error = %v", i+1, err)
+				}
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AddPayment() error = %v, want %v", err, tt.wantErr)
+			}
+			summary, _ := shop.Payments.GetOrderPayments(order.ID)
+			if summary.BalanceDue != tt.wantBalance || summary.Tips != tt.wantTips || summary.Status != tt.wantStatus {
+				t.Errorf("balance %.2f, tips %.2f, %s, want %.2f, %.2f, %s", summary.BalanceDue, summary.Tips, summary.Status, tt.wantBalance, tt.wantTips, tt.wantStatus)
+			}
+			if tt.wantChange != 0 && summary.Payments[0].ChangeGiven != tt.wantChange {
+				t.Errorf("change = %.2f, want %.2f", summary.Payments[0].ChangeGiven, tt.wantChange)
+			}
+		})
+	}
+}
+
+func TestPaymentsBreakdown(t *testing.T) {
+	shop := newTestShop(t)
+	shop.sell(t, models.Order{Items: []models.OrderItem{{ProductID: "latte", Quantity: 1}}}, models.TenderCash)
+	shop.sell(t, models.Order{Items: []models.OrderItem{{ProductID: "tea", Quantity: 1}}}, models.TenderCard)
+	shop.sell(t, models.Order{Items: []models.OrderItem{{ProductID: "latte", Quantity: 2}}}, models.TenderCard)
+
+	report, err := shop.Reports.GetPaymentsBreakdown()
+	if err != nil {
+		t.Fatalf("GetPaymentsBreakdown() error = %v", err)
+	}
+	want := map[string]float64{models.TenderCash: 5, models.TenderCard: 13}
+	for _, tender := range report.Tenders {
+		if tender.Amount != want[tender.Tender] {
+			t.Errorf("%s = %.2f, want %.2f", tender.Tender, tender.Amount, want[tender.Tender])
+		}
+		delete(want, tender.Tender)
+	}
+	if len(want) != 0 || report.TotalAmount != 18 {
+		t.Errorf("tenders = %+v, total %.2f, want cash and card totalling 18.00", report.Tenders, report.TotalAmount)
+	}
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"hot-coffee/models"
+	"math"
+)
+
+// snapshotItems copies the name, price, category and ingredient cost of each
+// product onto the order lines so reports keep sale-time values after the
+// menu or ingredient costs change.
+func snapshotItems(items []models.OrderItem, menuItems []models.MenuItem, inventory []models.InventoryItem) []models.OrderItem {
+	menuMap := make(map[string]models.MenuItem)
+	for _, item := range menuItems {
+		menuMap[item.ID] = item
+	}
+	costs := productCosts(menuItems, inventory)
+
+	result := make([]models.OrderItem, len(items))
+	for i, item := range items {
+		if menuItem, ok := menuMap[item.ProductID]; ok {
+			item.Name = menuItem.Name
+			item.Price = menuItem.Price
+			item.Category = menuItem.Category
+			cost := costs[item.ProductID]
+			item.UnitCost = &cost
+		}
+		result[i] = item
+	}
+	return result
+}
+
+// productCosts prices each menu item's recipe at the current ingredient costs.
+func productCosts(menuItems []models.MenuItem, inventory []models.InventoryItem) map[string]float64 {
+	costs := make(map[string]float64)
+	for _, item := range inventory {
+		costs[item.IngredientID] = item.UnitCost
+	}
+	productCost := make(map[string]float64)
+	for _, item := range menuItems {
+		for _, ing := range item.Ingredients {
+			productCost[item.ID] += ing.Quantity * costs[ing.IngredientID]
+		}
+	}
+	return productCost
+}
+
+// unitCost prefers the sale-time snapshot and falls back to current costs for
+// orders recorded before costs were snapshotted.
+func unitCost(item models.OrderItem, productCost map[string]float64) float64 {
+	if item.UnitCost != nil {
+		return *item.UnitCost
+	}
+	return productCost[item.ProductID]
+}
+
+// unitPrice prefers the sale-time snapshot and falls back to the current menu
+// for orders recorded before snapshots existed.
+func unitPrice(item models.OrderItem, menuItems []models.MenuItem) float64 {
+	if item.Name != "" {
+		return item.Price
+	}
+	for _, menuItem := range menuItems {
+		if menuItem.ID == item.ProductID {
+			return menuItem.Price
+		}
+	}
+	return 0
+}
+
+func itemName(item models.OrderItem, menuItems []models.MenuItem) string {
+	if item.Name != "" {
+		return item.Name
+	}
+	for _, menuItem := range menuItems {
+		if menuItem.ID == item.ProductID {
+			return menuItem.Name
+		}
+	}
+	return ""
+}
+
+func itemCategory(item models.OrderItem, menuItems []models.MenuItem) string {
+	if item.Name != "" {
+		return item.Category
+	}
+	for _, menuItem := range menuItems {
+		if menuItem.ID == item.ProductID {
+			return menuItem.Category
+		}
+	}
+	return ""
+}
+
+func orderSubtotal(order models.Order, menuItems []models.MenuItem) float64 {
+	var subtotal float64
+	for _, item := range order.Items {
+		subtotal += unitPrice(item, menuItems) * float64(item.Quantity)
+	}
+	return roundMoney(subtotal)
+}
+
+func orderDiscounts(order models.Order) float64 {
+	var discounts float64
+	for _, d := range order.Discounts {
+		discounts += d.Amount
+	}
+	return roundMoney(discounts)
+}
+
+func orderTotal(order models.Order, menuItems []models.MenuItem) float64 {
+	return roundMoney(orderSubtotal(order, menuItems) - orderDiscounts(order) + order.Tax)
+}
+
+// fillTotals sets the stored subtotal and total on orders recorded before
+// totals were persisted.
+func fillTotals(order models.Order, menuItems []models.MenuItem) models.Order {
+	if order.Total == 0 && len(order.Items) > 0 {
+		order.Subtotal = orderSubtotal(order, menuItems)
+		order.Total = orderTotal(order, menuItems)
+	}
+	return order
+}
+
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// refundTax is the share of a refund of amount that gives back the order's
+// sales tax.
+func refundTax(amount float64, order models.Order, menuItems []models.MenuItem) float64 {
+	total := orderTotal(order, menuItems)
+	if total <= 0 {
+		return 0
+	}
+	return roundMoney(amount * order.Tax / total)
+}
+
+// fillRefundTax sets the tax share on refunds recorded before it was stored.
+func fillRefundTax(refunds []models.Refund, orders []models.Order, menuItems []models.MenuItem) []models.Refund {
+	byID := make(map[string]models.Order, len(orders))
+	for _, order := range orders {
+		byID[order.ID] = order
+	}
+	filled := make([]models.Refund, len(refunds))
+	for i, refund := range refunds {
+		if order, ok := byID[refund.OrderID]; ok && refund.Tax == 0 && order.Tax > 0 {
+			refund.Tax = refundTax(refund.Amount, order, menuItems)
+		}
+		filled[i] = refund
+	}
+	return filled
+}
+
+// refundNet is the part of a refund that takes back sales rather than tax.
+func refundNet(refund models.Refund) float64 {
+	return roundMoney(refund.Amount - refund.Tax)
+}
+
+// refundLineNet is a refund line's share of refundNet.
+func refundLineNet(refund models.Refund, line models.RefundLine) float64 {
+	if refund.Amount <= 0 {
+		return line.Amount
+	}
+	return line.Amount * refundNet(refund) / refund.Amount
+}
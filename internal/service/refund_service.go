@@ -0,0 +1,415 @@
+package service
+
+import (
+	"fmt"
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/gateway"
+	"hot-coffee/models"
+	"math"
+	"time"
+)
+
+var ErrInvalidRefund = models.NewError(models.ErrConflict, "invalid refund")
+
+type RefundService struct {
+	RefundRepo    dal.RefundManager
+	OrderRepo     dal.OrderManager
+	MenuRepo      dal.MenuManager
+	InventoryRepo dal.InventoryManager
+	PaymentRepo   dal.PaymentManager
+	SessionRepo   dal.CashSessionManager
+	Loyalty       *LoyaltyService
+	Aggregates    *AggregateService
+	GiftCards     *GiftCardService
+	Gateway       gateway.PaymentGateway
+}
+
+func NewRefundService(refundRepo dal.RefundManager, orderRepo dal.OrderManager, menuRepo dal.MenuManager, inventoryRepo dal.InventoryManager, paymentRepo dal.PaymentManager, sessionRepo dal.CashSessionManager, loyalty *LoyaltyService, giftCards *GiftCardService, aggregates *AggregateService, gw gateway.PaymentGateway) *RefundService {
+	return &RefundService{
+		RefundRepo:    refundRepo,
+		OrderRepo:     orderRepo,
+		MenuRepo:      menuRepo,
+		InventoryRepo: inventoryRepo,
+		PaymentRepo:   paymentRepo,
+		SessionRepo:   sessionRepo,
+		Loyalty:       loyalty,
+		GiftCards:     giftCards,
+		Aggregates:    aggregates,
+		Gateway:       gw,
+	}
+}
+
+func (s *RefundService) VoidOrder(orderID string, req models.VoidRequest) (models.Order, error) {
+	unlock := orderLocks.Lock(orderID)
+	defer unlock()
+
+	order, err := s.OrderRepo.GetOrderByID(orderID)
+	if err != nil {
+		return models.Order{}, err
+	}
+	if order.Status != "open" {
+		return models.Order{}, fmt.Errorf("%w: only open orders can be voided, order '%s' is %s", models.ErrInvalidTransition, orderID, order.Status)
+	}
+
+	payments, err := s.PaymentRepo.GetPaymentsByOrder(orderID)
+	if err != nil {
+		return models.Order{}, err
+	}
+	if len(payments) > 0 {
+		return models.Order{}, fmt.Errorf("%w: order '%s' has payments, refund it instead", ErrInvalidRefund, orderID)
+	}
+
+	if req.RestoreInventory {
+		menuItems, err := s.MenuRepo.GetAllMenuItems()
+		if err != nil {
+			return models.Order{}, err
+		}
+		if err := s.InventoryRepo.RestoreIngredients(expandIngredients(order.Items, menuItems)); err != nil {
+			return models.Order{}, err
+		}
+	}
+
+	if err := s.OrderRepo.VoidOrder(orderID, req.Reason); err != nil {
+		return models.Order{}, err
+	}
+	if err := s.Loyalty.RestoreRedemption(order, "order cancelled"); err != nil {
+		return models.Order{}, err
+	}
+	voided, err := s.OrderRepo.GetOrderByID(orderID)
+	if err != nil {
+		return models.Order{}, err
+	}
+	menuItems, err := s.MenuRepo.GetAllMenuItems()
+	if err != nil {
+		return models.Order{}, err
+	}
+	return fillTotals(voided, menuItems), nil
+}
+
+func (s *RefundService) RefundOrder(orderID string, req models.RefundRequest) (models.Refund, error) {
+	unlock := orderLocks.Lock(orderID)
+	defer unlock()
+
+	order, err := s.OrderRepo.GetOrderByID(orderID)
+	if err != nil {
+		return models.Refund{}, err
+	}
+	if order.Status != "closed" {
+		return models.Refund{}, fmt.Errorf("%w: only closed orders can be refunded, order '%s' is %s", models.ErrInvalidTransition, orderID, order.Status)
+	}
+
+	menuItems, err := s.MenuRepo.GetAllMenuItems()
+	if err != nil {
+		return models.Refund{}, err
+	}
+	payments, err := s.PaymentRepo.GetPaymentsByOrder(orderID)
+	if err != nil {
+		return models.Refund{}, err
+	}
+	previous, err := s.RefundRepo.GetRefundsByOrder(orderID)
+	if err != nil {
+		return models.Refund{}, err
+	}
+
+	remaining := make(map[string]int)
+	for _, item := range order.Items {
+		remaining[item.ProductID] += item.Quantity
+	}
+	var alreadyRefunded float64
+	for _, r := range previous {
+		alreadyRefunded += r.Amount
+		for _, line := range r.Lines {
+			remaining[line.ProductID] -= line.Quantity
+		}
+	}
+
+	lines := req.Lines
+	if len(lines) == 0 {
+		for _, item := range order.Items {
+			if remaining[item.ProductID] > 0 {
+				lines = append(lines, models.RefundLine{ProductID: item.ProductID, Quantity: remaining[item.ProductID]})
+				remaining[item.ProductID] = 0
+			}
+		}
+		if len(lines) == 0 {
+			return models.Refund{}, fmt.Errorf("%w: order '%s' has already been fully refunded", ErrInvalidRefund, orderID)
+		}
+	} else {
+		for _, line := range lines {
+			if line.Quantity <= 0 {
+				return models.Refund{}, fmt.Errorf("%w: quantity for '%s' must be positive", ErrInvalidRefund, line.ProductID)
+			}
+			if line.Quantity > remaining[line.ProductID] {
+				return models.Refund{}, fmt.Errorf("%w: cannot refund %d of '%s', only %d refundable", ErrInvalidRefund, line.Quantity, line.ProductID, remaining[line.ProductID])
+			}
+			remaining[line.ProductID] -= line.Quantity
+		}
+	}
+
+	prices := make(map[string]float64)
+	for _, item := range order.Items {
+		prices[item.ProductID] = unitPrice(item, menuItems)
+	}
+
+	refund := models.Refund{
+		OrderID: orderID,
+		Reason:  req.Reason,
+		Tender:  req.Tender,
+	}
+	// Line amounts carry their share of discounts and tax.
+	ratio := 1.0
+	if subtotal := orderSubtotal(order, menuItems); subtotal > 0 {
+		ratio = orderTotal(order, menuItems) / subtotal
+	}
+
+	var restock []models.OrderItem
+	for _, line := range lines {
+		line.Amount = roundMoney(prices[line.ProductID] * float64(line.Quantity) * ratio)
+		refund.Amount += line.Amount
+		refund.Lines = append(refund.Lines, line)
+		if line.Restock {
+			restock = append(restock, models.OrderItem{ProductID: line.ProductID, Quantity: line.Quantity})
+		}
+	}
+
+	refundable := orderTotal(order, menuItems)
+	if len(payments) > 0 {
+		refundable = 0
+		for _, p := range payments {
+			refundable += p.Amount
+		}
+		if refund.Tender == "" {
+			refund.Tender = payments[0].Tender
+		}
+	}
+	if err := checkRefundTender(refund.Tender, payments); err != nil {
+		return models.Refund{}, err
+	}
+	refundable = roundMoney(refundable - alreadyRefunded)
+	refund.Amount = roundMoney(refund.Amount)
+	if refund.Amount > refundable {
+		refund.Lines = scaleLines(refund.Lines, refund.Amount, refundable)
+		refund.Amount = refundable
+	}
+	refund.Tax = refundTax(refund.Amount, order, menuItems)
+
+	session, open, err := s.SessionRepo.GetOpenSession()
+	if err != nil {
+		return models.Refund{}, err
+	}
+	if refund.Tender == models.TenderCash && !open {
+		return models.Refund{}, fmt.Errorf("%w: no cash drawer session is open", ErrInvalidRefund)
+	}
+	refund.SessionID = session.ID
+
+	refund.ID = fmt.Sprintf("%s-r%d", orderID, len(previous)+1)
+	if refund.Tender == models.TenderCard && refund.Amount > 0 {
+		gatewayRefunds, err := s.refundCard(refund, payments, previous)
+		if err != nil {
+			return models.Refund{}, err
+		}
+		refund.GatewayRefunds = gatewayRefunds
+	}
+	if refund.Tender == models.TenderGiftCard && refund.Amount > 0 {
+		credits, err := s.creditGiftCards(refund, payments, previous)
+		if err != nil {
+			return models.Refund{}, err
+		}
+		refund.GiftCardCredits = credits
+	}
+
+	if len(restock) > 0 {
+		if err := s.InventoryRepo.RestoreIngredients(expandIngredients(restock, menuItems)); err != nil {
+			return models.Refund{}, err
+		}
+	}
+
+	refund.CreatedAt = time.Now().Format(time.RFC3339)
+	if err := s.RefundRepo.AddRefund(refund); err != nil {
+		return models.Refund{}, err
+	}
+
+	fullyRefunded := true
+	for _, qty := range remaining {
+		if qty > 0 {
+			fullyRefunded = false
+			break
+		}
+	}
+	if fullyRefunded {
+		if err := s.OrderRepo.SetOrderStatus(orderID, "refunded"); err != nil {
+			return models.Refund{}, err
+		}
+	}
+	if err := s.Aggregates.RecordRefund(refund, menuItems); err != nil {
+		return models.Refund{}, err
+	}
+	if err := s.Loyalty.ReverseForRefund(order, refund.Amount, orderTotal(order, menuItems), fullyRefunded); err != nil {
+		return models.Refund{}, err
+	}
+	return refund, nil
+}
+
+// checkRefundTender makes sure money goes back the way it came in: the tender
+// must be one the order was paid with. Orders without payments were covered
+// by discounts and take no tender.
+func checkRefundTender(tender string, payments []models.Payment) error {
+	switch tender {
+	case "":
+		return nil
+	case models.TenderCash, models.TenderCard, models.TenderGiftCard:
+	default:
+		return fmt.Errorf("%w: unknown tender '%s'", ErrInvalidRefund, tender)
+	}
+	for _, p := range payments {
+		if p.Tender == tender {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: the order was not paid by %s", ErrInvalidRefund, tender)
+}
+
+// scaleLines shrinks line amounts that add up to from so they add up to to.
+// The last line absorbs the rounding difference.
+func scaleLines(lines []models.RefundLine, from float64, to float64) []models.RefundLine {
+	scaled := make([]models.RefundLine, len(lines))
+	var sum float64
+	for i, line := range lines {
+		if from > 0 {
+			line.Amount = roundMoney(line.Amount * to / from)
+		}
+		sum += line.Amount
+		scaled[i] = line
+	}
+	if n := len(scaled); n > 0 {
+		scaled[n-1].Amount = roundMoney(scaled[n-1].Amount + to - sum)
+	}
+	return scaled
+}
+
+func (s *RefundService) refundCard(refund models.Refund, payments []models.Payment, previous []models.Refund) ([]models.GatewayRefund, error) {
+	refunded := make(map[string]float64)
+	for _, r := range previous {
+		for _, gr := range r.GatewayRefunds {
+			refunded[gr.TransactionID] += gr.Amount
+		}
+	}
+
+	var plan []models.GatewayRefund
+	left := refund.Amount
+	for _, p := range payments {
+		if left <= 0 {
+			break
+		}
+		if p.Tender != models.TenderCard || p.GatewayTransactionID == "" {
+			continue
+		}
+		available := roundMoney(p.Amount + p.Tip - refunded[p.GatewayTransactionID])
+		if available <= 0 {
+			continue
+		}
+		amount := math.Min(available, left)
+		plan = append(plan, models.GatewayRefund{TransactionID: p.GatewayTransactionID, Amount: amount})
+		left = roundMoney(left - amount)
+	}
+	if left > 0 {
+		return nil, fmt.Errorf("%w: card payments cannot cover a refund of %.2f", ErrInvalidRefund, refund.Amount)
+	}
+
+	for _, gr := range plan {
+		if _, err := s.Gateway.Refund(gr.TransactionID, gr.Amount, refund.ID+":"+gr.TransactionID); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// creditGiftCards spreads a refund over the order's gift card payments in the
+// order they were made, never crediting a card more than was paid with it.
+func (s *RefundService) creditGiftCards(refund models.Refund, payments []models.Payment, previous []models.Refund) ([]models.GiftCardCredit, error) {
+	credited := make(map[string]float64)
+	for _, r := range previous {
+		for _, c := range r.GiftCardCredits {
+			credited[c.Code] += c.Amount
+		}
+	}
+
+	paid := make(map[string]float64)
+	var codes []string
+	for _, p := range payments {
+		if p.Tender != models.TenderGiftCard || p.GiftCardCode == "" {
+			continue
+		}
+		if _, ok := paid[p.GiftCardCode]; !ok {
+			codes = append(codes, p.GiftCardCode)
+		}
+		paid[p.GiftCardCode] += p.Amount + p.Tip
+	}
+
+	var plan []models.GiftCardCredit
+	left := refund.Amount
+	for _, code := range codes {
+		if left <= 0 {
+			break
+		}
+		available := roundMoney(paid[code] - credited[code])
+		if available <= 0 {
+			continue
+		}
+		amount := math.Min(available, left)
+		plan = append(plan, models.GiftCardCredit{Code: code, Amount: amount})
+		left = roundMoney(left - amount)
+	}
+	if left > 0 {
+		return nil, fmt.Errorf("%w: gift card payments cannot cover a refund of %.2f", ErrInvalidRefund, refund.Amount)
+	}
+
+	for _, c := range plan {
+		if err := s.GiftCards.Credit(c.Code, c.Amount, refund.OrderID, refund.ID); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+func (s *RefundService) GetOrderRefunds(orderID string) ([]models.Refund, error) {
+	if _, err := s.OrderRepo.GetOrderByID(orderID); err != nil {
+		return nil, err
+	}
+	return s.RefundRepo.GetRefundsByOrder(orderID)
+}
+
+func (s *RefundService) GetOrderRefund(orderID string, refundID string) (models.Refund, error) {
+	refunds, err := s.GetOrderRefunds(orderID)
+	if err != nil {
+		return models.Refund{}, err
+	}
+	for _, r := range refunds {
+		if r.ID == refundID {
+			return r, nil
+		}
+	}
+	return models.Refund{}, fmt.Errorf("%w: refund '%s' on order '%s'", models.ErrNotFound, refundID, orderID)
+}
+
+func expandIngredients(items []models.OrderItem, menuItems []models.MenuItem) []models.MenuItemIngredient {
+	menuMap := make(map[string]models.MenuItem)
+	for _, item := range menuItems {
+		menuMap[item.ID] = item
+	}
+
+	var ingredients []models.MenuItemIngredient
+	for _, orderItem := range items {
+		menuItem, ok := menuMap[orderItem.ProductID]
+		if !ok {
+			continue
+		}
+		for _, ing := range menuItem.Ingredients {
+			ingredients = append(ingredients, models.MenuItemIngredient{
+				IngredientID: ing.IngredientID,
+				Quantity:     ing.Quantity * float64(orderItem.Quantity),
+			})
+		}
+	}
+	return ingredients
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"errors"
+	"hot-coffee/models"
+	"testing"
+)
+
+func TestRefundTender(t *testing.T) {
+	tests := []struct {
+		name       string
+		paidWith   string
+		tender     string
+		wantErr    error
+		wantTender string
+	}{
+		{name: "defaults to the payment", paidWith: models.TenderCash, wantTender: models.TenderCash},
+		{name: "same tender", paidWith: models.TenderCard, tender: models.TenderCard, wantTender: models.TenderCard},
+		{name: "unknown tender", paidWith: models.TenderCash, tender: "bitcoin", wantErr: ErrInvalidRefund},
+		{name: "tender not paid with", paidWith: models.TenderCash, tender: models.TenderCard, wantErr: ErrInvalidRefund},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shop := newTestShop(t)
+			order := shop.sell(t, models.Order{Items: []models.OrderItem{{ProductID: "latte", Quantity: 1}}}, tt.paidWith)
+
+			refund, err := shop.Refunds.RefundOrder(order.ID, models.RefundRequest{Tender: tt.tender})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RefundOrder() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if refunds, _ := shop.Refunds.RefundRepo.GetRefundsByOrder(order.ID); len(refunds) != 0 {
+					t.Errorf("recorded %d refunds, want none", len(refunds))
+				}
+				return
+			}
+			if refund.Tender != tt.wantTender || refund.Amount != 5 {
+				t.Errorf("refund = %.2f by %s, want 5.00 by %s", refund.Amount, refund.Tender, tt.wantTender)
+			}
+		})
+	}
+}
+
+func TestRefundOrderPartial(t *testing.T) {
+	line := func(productID string, quantity int, restock bool) models.RefundLine {
+		return models.RefundLine{ProductID: productID, Quantity: quantity, Restock: restock}
+	}
+	type step struct {
+		lines      []models.RefundLine
+		wantAmount float64
+		wantErr    error
+	}
+	tests := []struct {
+		name       string
+		steps      []step
+		wantMilk   float64
+		wantStatus string
+	}{
+		{name: "one with restock", steps: []step{{lines: []models.RefundLine{line("latte", 1, true)}, wantAmount: 5}}, wantMilk: 9.6, wantStatus: "closed"},
+		{name: "one without restock", steps: []step{{lines: []models.RefundLine{line("latte", 1, false)}, wantAmount: 5}}, wantMilk: 9.4, wantStatus: "closed"},
+		{name: "one then the rest", steps: []step{
+			{lines: []models.RefundLine{line("latte", 1, false)}, wantAmount: 5},
+			{wantAmount: 10},
+		}, wantMilk: 9.4, wantStatus: "refunded"},
+		{name: "more than sold", steps: []step{{lines: []models.RefundLine{line("latte", 4, true)}, wantErr: ErrInvalidRefund}}, wantMilk: 9.4, wantStatus: "closed"},
+		{name: "more than left", steps: []step{
+			{lines: []models.RefundLine{line("latte", 2, true)}, wantAmount: 10},
+			{lines: []models.RefundLine{line("latte", 2, true)}, wantErr: ErrInvalidRefund},
+		}, wantMilk: 9.8, wantStatus: "closed"},
+		{name: "not on the order", steps: []step{{lines: []models.RefundLine{line("tea", 1, false)}, wantErr: ErrInvalidRefund}}, wantMilk: 9.4, wantStatus: "closed"},
+		{name: "zero quantity", steps: []step{{lines: []models.RefundLine{line("latte", 0, false)}, wantErr: ErrInvalidRefund}}, wantMilk: 9.4, wantStatus: "closed"},
+		{name: "twice in full", steps: []step{{wantAmount: 15}, {wantErr: models.ErrInvalidTransition}}, wantMilk: 9.4, wantStatus: "refunded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shop := newTestShop(t)
+			order := shop.sell(t, models.Order{Items: []models.OrderItem{{ProductID: "latte", Quantity: 3}}}, models.TenderCash)
+
+			for i, step := range tt.steps {
+				refund, err := shop.Refunds.RefundOrder(order.ID, models.RefundRequest{Lines: step.lines})
+				if !errors.Is(err, step.wantErr) {
+					t.Fatalf("RefundOrder() #%d error = %v, want %v", i+1, err, step.wantErr)
+				}
+				if err == nil && refund.Amount != step.wantAmount {
+					t.Errorf("RefundOrder() #%d amount = %.2f, want %.2f", i+1, refund.Amount, step.wantAmount)
+				}
+			}
+			if got := milkLeft(t, shop.Orders); !moneyEqual(got, tt.wantMilk) {
+				t.Errorf("milk = %.2f, want %.2f", got, tt.wantMilk)
+			}
+			if stored, _ := shop.Orders.OrderRepo.GetOrderByID(order.ID); stored.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", stored.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRefundOrderCappedAtPaid(t *testing.T) {
+	shop := newTestShop(t)
+	withFreeLatte(t, shop.Orders)
+	// 11.00 of items less a 5.00 reward: each line refunds 6/11 of its price.
+	order := shop.sell(t, models.Order{
+		CustomerID:     "c1",
+		RedeemRewardID: "free-latte",
+		Items:          []models.OrderItem{{ProductID: "latte", Quantity: 1}, {ProductID: "tea", Quantity: 2}},
+	}, models.TenderCash)
+	if order.Total != 6 {
+		t.Fatalf("order total = %.2f, want 6.00", order.Total)
+	}
+
+	var refunded float64
+	for _, productID := range []string{"tea", "tea", "latte"} {
+		refund, err := shop.Refunds.RefundOrder(order.ID, models.RefundRequest{Lines: []models.RefundLine{{ProductID: productID, Quantity: 1}}})
+		if err != nil {
+			t.Fatalf("RefundOrder(%s) error = %v", productID, err)
+		}
+		refunded += refund.Amount
+	}
+	// Rounded line amounts add up to 6.01; the last refund gives back only
+	// what is left.
+	if !moneyEqual(refunded, 6) {
+		t.Errorf("refunded %.2f, want the 6.00 paid", refunded)
+	}
+}
+
+func TestVoidOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      models.VoidRequest
+		paid     float64
+		wantErr  error
+		wantMilk float64
+	}{
+		{name: "with restock", req: models.VoidRequest{Reason: "dropped", RestoreInventory: true}, wantMilk: 10},
+		{name: "without restock", req: models.VoidRequest{Reason: "made wrong"}, wantMilk: 9.6},
+		{name: "partly paid", req: models.VoidRequest{RestoreInventory: true}, paid: 5, wantErr: ErrInvalidRefund, wantMilk: 9.6},
+		{name: "closed", req: models.VoidRequest{RestoreInventory: true}, paid: 10, wantErr: models.ErrInvalidTransition, wantMilk: 9.6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shop := newTestShop(t)
+			if _, err := shop.Sessions.OpenSession(models.CashSession{}); err != nil {
+				t.Fatalf("OpenSession() error = %v", err)
+			}
+			order, err := shop.Orders.CreateOrder(models.Order{CustomerName: "Alice", Items: []models.OrderItem{{ProductID: "latte", Quantity: 2}}})
+			if err != nil {
+				t.Fatalf("CreateOrder() error = %v", err)
+			}
+			if tt.paid > 0 {
+				if _, err := shop.Payments.AddPayment(order.ID, models.Payment{Tender: models.TenderCash, Amount: tt.paid}); err != nil {
+					t.Fatalf("AddPayment() error = %v", err)
+				}
+			}
+
+			voided, err := shop.Refunds.VoidOrder(order.ID, tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VoidOrder() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (voided.Status != "voided" || voided.VoidReason != tt.req.Reason) {
+				t.Errorf("voided order = %s (%q), want voided (%q)", voided.Status, voided.VoidReason, tt.req.Reason)
+			}
+			if got := milkLeft(t, shop.Orders); !moneyEqual(got, tt.wantMilk) {
+				t.Errorf("milk = %.2f, want %.2f", got, tt.wantMilk)
+			}
+		})
+	}
+}
@@ -1,7 +1,16 @@
 package service
 
 import (
+	"fmt"
 	"hot-coffee/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	SortByQuantity = "quantity"
+	SortByRevenue  = "revenue"
 )
 
 type OrderRepository interface {
@@ -12,85 +21,339 @@ type MenuRepository interface {
 	LoadMenuItems() ([]models.MenuItem, error)
 }
 
+type PaymentRepository interface {
+	LoadPayments() ([]models.Payment, error)
+}
+
+type RefundRepository interface {
+	LoadRefunds() ([]models.Refund, error)
+}
+
+type CashSessionRepository interface {
+	LoadSessions() ([]models.CashSession, error)
+}
+
+type GiftCardRepository interface {
+	LoadGiftCardTransactions() ([]models.GiftCardTransaction, error)
+}
+
+type AggregateRepository interface {
+	LoadAggregates() (models.ReportAggregates, error)
+}
+
 type ReportService struct {
-	orderRepo OrderRepository
-	menuRepo  MenuRepository
+	orderRepo     OrderRepository
+	menuRepo      MenuRepository
+	paymentRepo   PaymentRepository
+	refundRepo    RefundRepository
+	sessionRepo   CashSessionRepository
+	inventoryRepo InventoryRepository
+	aggregateRepo AggregateRepository
+	chartRepo     ChartRepository
+	giftCardRepo  GiftCardRepository
+	location      *time.Location
 }
 
-func NewReportService(orderRepo OrderRepository, menuRepo MenuRepository) *ReportService {
+func NewReportService(orderRepo OrderRepository, menuRepo MenuRepository, paymentRepo PaymentRepository, refundRepo RefundRepository, sessionRepo CashSessionRepository, inventoryRepo InventoryRepository, aggregateRepo AggregateRepository, chartRepo ChartRepository, giftCardRepo GiftCardRepository, location *time.Location) *ReportService {
 	return &ReportService{
-		orderRepo: orderRepo,
-		menuRepo:  menuRepo,
+		orderRepo:     orderRepo,
+		menuRepo:      menuRepo,
+		paymentRepo:   paymentRepo,
+		refundRepo:    refundRepo,
+		sessionRepo:   sessionRepo,
+		inventoryRepo: inventoryRepo,
+		aggregateRepo: aggregateRepo,
+		chartRepo:     chartRepo,
+		giftCardRepo:  giftCardRepo,
+		location:      location,
 	}
 }
 
 func (s *ReportService) GetTotalSales() (float64, error) {
-	orders, err := s.orderRepo.LoadOrders()
+	agg, err := s.aggregateRepo.LoadAggregates()
 	if err != nil {
 		return 0, err
 	}
 
+	var total float64
+	for _, day := range agg.Days {
+		total += day.Gross - day.Discounts + day.Taxes - day.Refunds
+	}
+	return roundMoney(total), nil
+}
+
+func (s *ReportService) GetPopularItems(from string, to string, sortBy string, category string, limit int) ([]models.PopularItemReport, error) {
+	if sortBy == "" {
+		sortBy = SortByQuantity
+	}
+	if sortBy != SortByQuantity && sortBy != SortByRevenue {
+		return nil, fmt.Errorf("%w: invalid sort '%s': expected %s or %s", ErrInvalidQuery, sortBy, SortByQuantity, SortByRevenue)
+	}
+	if limit < 0 {
+		return nil, fmt.Errorf("%w: limit must not be negative", ErrInvalidQuery)
+	}
+	p, err := s.parsePeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make(map[string]*models.PopularItemReport)
+	entry := func(id string) *models.PopularItemReport {
+		e, ok := items[id]
+		if !ok {
+			e = &models.PopularItemReport{ProductID: id}
+			items[id] = e
+		}
+		return e
+	}
+
+	if fromDate, toDate, ok := s.dayBounds(p); ok {
+		agg, err := s.aggregateRepo.LoadAggregates()
+		if err != nil {
+			return nil, err
+		}
+		for _, day := range sortedDays(agg, fromDate, toDate) {
+			for id, item := range day.Items {
+				e := entry(id)
+				if item.Name != "" {
+					e.Name = item.Name
+					e.Category = item.Category
+				}
+				e.Count += item.Quantity - item.RefundedQuantity
+				e.Revenue += item.Gross - item.Refunds + item.RefundTaxes
+			}
+		}
+	} else if err := s.collectPopularItems(p, entry); err != nil {
+		return nil, err
+	}
+
+	result := []models.PopularItemReport{}
+	for _, e := range items {
+		if e.Count <= 0 {
+			continue
+		}
+		if category != "" && !strings.EqualFold(e.Category, category) {
+			continue
+		}
+		e.Revenue = roundMoney(e.Revenue)
+		result = append(result, *e)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if sortBy == SortByRevenue && a.Revenue != b.Revenue {
+			return a.Revenue > b.Revenue
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Revenue != b.Revenue {
+			return a.Revenue > b.Revenue
+		}
+		return a.ProductID < b.ProductID
+	})
+
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (s *ReportService) collectPopularItems(p period, entry func(id string) *models.PopularItemReport) error {
+	orders, err := s.orderRepo.LoadOrders()
+	if err != nil {
+		return err
+	}
+
 	menuItems, err := s.menuRepo.LoadMenuItems()
 	if err != nil {
-		return 0, err
+		return err
 	}
 
-	menuMap := make(map[string]float64)
-	for _, item := range menuItems {
-		menuMap[item.ID] = item.Price
+	refunds, err := s.refundRepo.LoadRefunds()
+	if err != nil {
+		return err
 	}
 
-	var total float64
 	for _, order := range orders {
-		if order.Status != "closed" {
+		if !isSold(order.Status) {
+			continue
+		}
+		createdAt, err := time.Parse(time.RFC3339, order.CreatedAt)
+		if err != nil || !p.contains(createdAt) {
 			continue
 		}
 		for _, item := range order.Items {
-			price, ok := menuMap[item.ProductID]
-			if !ok {
-				continue
+			e := entry(item.ProductID)
+			if e.Name == "" || item.Name != "" {
+				e.Name = itemName(item, menuItems)
+				e.Category = itemCategory(item, menuItems)
 			}
-			total += price * float64(item.Quantity)
+			e.Count += item.Quantity
+			e.Revenue += unitPrice(item, menuItems) * float64(item.Quantity)
 		}
 	}
 
-	return total, nil
+	// Revenue is before tax, so refunds count without the tax they gave back.
+	for _, refund := range fillRefundTax(refunds, orders, menuItems) {
+		createdAt, err := time.Parse(time.RFC3339, refund.CreatedAt)
+		if err != nil || !p.contains(createdAt) {
+			continue
+		}
+		for _, line := range refund.Lines {
+			e := entry(line.ProductID)
+			if e.Name == "" {
+				item := models.OrderItem{ProductID: line.ProductID}
+				e.Name = itemName(item, menuItems)
+				e.Category = itemCategory(item, menuItems)
+			}
+			e.Count -= line.Quantity
+			e.Revenue -= refundLineNet(refund, line)
+		}
+	}
+	return nil
 }
 
-func (s *ReportService) GetPopularItems() ([]models.PopularItemReport, error) {
-	orders, err := s.orderRepo.LoadOrders()
+func (s *ReportService) GetPaymentsBreakdown() (models.PaymentsReport, error) {
+	payments, err := s.paymentRepo.LoadPayments()
 	if err != nil {
-		return nil, err
+		return models.PaymentsReport{}, err
+	}
+
+	refunds, err := s.refundRepo.LoadRefunds()
+	if err != nil {
+		return models.PaymentsReport{}, err
+	}
+
+	report := models.PaymentsReport{Tenders: tenderBreakdown(payments, refunds)}
+	for _, t := range report.Tenders {
+		report.TotalAmount += t.Amount
+		report.TotalTips += t.Tips
+		report.TotalRefunds += t.Refunds
+	}
+	report.TotalAmount = roundMoney(report.TotalAmount)
+	report.TotalTips = roundMoney(report.TotalTips)
+	report.TotalRefunds = roundMoney(report.TotalRefunds)
+	return report, nil
+}
+
+func (s *ReportService) GetZReport(date string) (models.ZReport, error) {
+	if date == "" {
+		date = time.Now().In(s.location).Format(time.DateOnly)
+	}
+	if _, err := time.Parse(time.DateOnly, date); err != nil {
+		return models.ZReport{}, fmt.Errorf("%w: invalid date '%s', expected YYYY-MM-DD", ErrInvalidQuery, date)
 	}
 
+	orders, err := s.orderRepo.LoadOrders()
+	if err != nil {
+		return models.ZReport{}, err
+	}
 	menuItems, err := s.menuRepo.LoadMenuItems()
 	if err != nil {
-		return nil, err
+		return models.ZReport{}, err
+	}
+	payments, err := s.paymentRepo.LoadPayments()
+	if err != nil {
+		return models.ZReport{}, err
+	}
+	refunds, err := s.refundRepo.LoadRefunds()
+	if err != nil {
+		return models.ZReport{}, err
+	}
+	sessions, err := s.sessionRepo.LoadSessions()
+	if err != nil {
+		return models.ZReport{}, err
+	}
+	giftCardTxns, err := s.giftCardRepo.LoadGiftCardTransactions()
+	if err != nil {
+		return models.ZReport{}, err
 	}
 
-	counts := make(map[string]int)
+	report := models.ZReport{Date: date, Sessions: []models.CashSessionSummary{}}
 	for _, order := range orders {
-		if order.Status != "closed" {
+		if !isSold(order.Status) || !s.onDate(order.CreatedAt, date) {
 			continue
 		}
-		for _, item := range order.Items {
-			counts[item.ProductID] += item.Quantity
+		report.OrderCount++
+		report.GrossSales += orderSubtotal(order, menuItems)
+		report.Discounts += orderDiscounts(order)
+		report.Taxes += order.Tax
+	}
+
+	var dayPayments []models.Payment
+	for _, p := range payments {
+		if s.onDate(p.CreatedAt, date) {
+			dayPayments = append(dayPayments, p)
+		}
+	}
+	var dayRefunds []models.Refund
+	for _, r := range refunds {
+		if s.onDate(r.CreatedAt, date) {
+			dayRefunds = append(dayRefunds, r)
+			report.Refunds += r.Amount
+		}
+	}
+	report.Tenders = tenderBreakdown(dayPayments, dayRefunds)
+
+	for _, session := range sessions {
+		if s.onDate(session.OpenedAt, date) {
+			report.Sessions = append(report.Sessions, summarizeCashSession(session, payments, refunds, giftCardTxns))
 		}
 	}
 
-	menuMap := make(map[string]string)
-	for _, item := range menuItems {
-		menuMap[item.ID] = item.Name
+	report.GrossSales = roundMoney(report.GrossSales)
+	report.Discounts = roundMoney(report.Discounts)
+	report.Taxes = roundMoney(report.Taxes)
+	report.Refunds = roundMoney(report.Refunds)
+	report.NetSales = roundMoney(report.GrossSales - report.Discounts + report.Taxes - report.Refunds)
+	return report, nil
+}
+
+func tenderBreakdown(payments []models.Payment, refunds []models.Refund) []models.TenderReport {
+	tenders := make(map[string]*models.TenderReport)
+	var order []string
+	tender := func(name string) *models.TenderReport {
+		t, ok := tenders[name]
+		if !ok {
+			t = &models.TenderReport{Tender: name}
+			tenders[name] = t
+			order = append(order, name)
+		}
+		return t
 	}
 
-	var result []models.PopularItemReport
-	for id, count := range counts {
-		result = append(result, models.PopularItemReport{
-			ProductID: id,
-			Name:      menuMap[id],
-			Count:     count,
-		})
+	for _, p := range payments {
+		t := tender(p.Tender)
+		t.Count++
+		t.Amount += p.Amount
+		t.Tips += p.Tip
+		t.ChangeGiven += p.ChangeGiven
+	}
+	for _, r := range refunds {
+		tender(r.Tender).Refunds += r.Amount
 	}
 
-	return result, nil
+	result := []models.TenderReport{}
+	for _, name := range order {
+		t := tenders[name]
+		t.Amount = roundMoney(t.Amount)
+		t.Tips = roundMoney(t.Tips)
+		t.ChangeGiven = roundMoney(t.ChangeGiven)
+		t.Refunds = roundMoney(t.Refunds)
+		result = append(result, *t)
+	}
+	return result
+}
+
+func (s *ReportService) onDate(timestamp string, date string) bool {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return false
+	}
+	return t.In(s.location).Format(time.DateOnly) == date
+}
+
+func isSold(status string) bool {
+	return status == "closed" || status == "refunded"
 }
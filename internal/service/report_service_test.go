@@ -0,0 +1,75 @@
+package service
+
+import (
+	"errors"
+	"hot-coffee/models"
+	"testing"
+)
+
+func TestGetPopularItems(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		sortBy   string
+		category string
+		limit    int
+		want     []models.PopularItemReport
+	}{
+		{name: "by quantity", want: []models.PopularItemReport{
+			{ProductID: "latte", Name: "Latte", Category: "coffee", Count: 3, Revenue: 15},
+			{ProductID: "tea", Name: "Tea", Category: "tea", Count: 2, Revenue: 6},
+			{ProductID: "cake", Name: "Cake", Category: "bakery", Count: 1, Revenue: 20},
+		}},
+		{name: "by revenue", sortBy: SortByRevenue, want: []models.PopularItemReport{
+			{ProductID: "cake", Name: "Cake", Category: "bakery", Count: 1, Revenue: 20},
+			{ProductID: "latte", Name: "Latte", Category: "coffee", Count: 3, Revenue: 15},
+			{ProductID: "tea", Name: "Tea", Category: "tea", Count: 2, Revenue: 6},
+		}},
+		{name: "limited", sortBy: SortByRevenue, limit: 1, want: []models.PopularItemReport{
+			{ProductID: "cake", Name: "Cake", Category: "bakery", Count: 1, Revenue: 20},
+		}},
+		{name: "category of a removed product", category: "Bakery", want: []models.PopularItemReport{
+			{ProductID: "cake", Name: "Cake", Category: "bakery", Count: 1, Revenue: 20},
+		}},
+		{name: "one day", from: "2026-03-09", to: "2026-03-09", want: []models.PopularItemReport{
+			{ProductID: "tea", Name: "Tea", Category: "tea", Count: 1, Revenue: 3},
+		}},
+		{name: "only the refund", from: "2026-03-09T12:30:00Z", to: "2026-03-09T14:00:00Z", want: []models.PopularItemReport{}},
+		{name: "partial day", from: "2026-03-02T00:00:00Z", to: "2026-03-02T09:00:00Z", want: []models.PopularItemReport{
+			{ProductID: "tea", Name: "Tea", Category: "tea", Count: 1, Revenue: 3},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shop := newTestSalesShop(t)
+			// The cake has since been taken off the menu, so only its
+			// snapshot names it.
+			shop.soldAt(t, "2026-03-05T10:00:00Z", models.OrderItem{ProductID: "cake", Quantity: 1, Name: "Cake", Price: 20, Category: "bakery"})
+			shop.inZone(t, shop.Reports.location)
+
+			got, err := shop.Reports.GetPopularItems(tt.from, tt.to, tt.sortBy, tt.category, tt.limit)
+			if err != nil {
+				t.Fatalf("GetPopularItems() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("GetPopularItems() = %+v, want %+v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("item %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGetPopularItemsInvalid(t *testing.T) {
+	shop := newTestShop(t)
+	if _, err := shop.Reports.GetPopularItems("", "", "name", "", 0); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("GetPopularItems() error = %v, want %v", err, ErrInvalidQuery)
+	}
+	if _, err := shop.Reports.GetPopularItems("", "", "", "", -1); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("GetPopularItems() error = %v, want %v", err, ErrInvalidQuery)
+	}
+}
@@ -0,0 +1,342 @@
+package service
+
+import (
+	"fmt"
+	"hot-coffee/models"
+	"sort"
+	"time"
+)
+
+const (
+	GroupByHour  = "hour"
+	GroupByDay   = "day"
+	GroupByWeek  = "week"
+	GroupByMonth = "month"
+	GroupByItem  = "item"
+)
+
+// maxFilledBuckets bounds gap filling so a wide range grouped by hour cannot
+// produce an unbounded response.
+const maxFilledBuckets = 10000
+
+type period struct {
+	from time.Time
+	to   time.Time
+}
+
+func (p period) contains(t time.Time) bool {
+	if !p.from.IsZero() && t.Before(p.from) {
+		return false
+	}
+	if !p.to.IsZero() && !t.Before(p.to) {
+		return false
+	}
+	return true
+}
+
+func (s *ReportService) parsePeriod(from string, to string) (period, error) {
+	return parsePeriod(from, to, s.location)
+}
+
+// parsePeriod accepts YYYY-MM-DD dates in location or RFC 3339 timestamps.
+// A date-only "to" includes that whole day.
+func parsePeriod(from string, to string, location *time.Location) (period, error) {
+	var p period
+	if from != "" {
+		t, _, err := parseBound(from, location)
+		if err != nil {
+			return period{}, fmt.Errorf("%w: invalid from '%s': %w", ErrInvalidQuery, from, err)
+		}
+		p.from = t
+	}
+	if to != "" {
+		t, dateOnly, err := parseBound(to, location)
+		if err != nil {
+			return period{}, fmt.Errorf("%w: invalid to '%s': %w", ErrInvalidQuery, to, err)
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		p.to = t
+	}
+	if !p.from.IsZero() && !p.to.IsZero() && !p.from.Before(p.to) {
+		return period{}, fmt.Errorf("%w: from must be before to", ErrInvalidQuery)
+	}
+	return p, nil
+}
+
+func parseBound(value string, location *time.Location) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation(time.DateOnly, value, location); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("expected YYYY-MM-DD or RFC 3339 timestamp")
+	}
+	return t, false, nil
+}
+
+func (s *ReportService) GetSalesReport(from string, to string, groupBy string) (models.SalesReport, error) {
+	if groupBy == "" {
+		groupBy = GroupByDay
+	}
+	switch groupBy {
+	case GroupByHour, GroupByDay, GroupByWeek, GroupByMonth, GroupByItem:
+	default:
+		return models.SalesReport{}, fmt.Errorf("%w: invalid group_by '%s'", ErrInvalidQuery, groupBy)
+	}
+
+	p, err := s.parsePeriod(from, to)
+	if err != nil {
+		return models.SalesReport{}, err
+	}
+
+	buckets := make(map[string]*models.SalesBucket)
+	bucket := func(key string) *models.SalesBucket {
+		b, ok := buckets[key]
+		if !ok {
+			b = &models.SalesBucket{Bucket: key}
+			buckets[key] = b
+		}
+		return b
+	}
+
+	report := models.SalesReport{
+		From:     from,
+		To:       to,
+		GroupBy:  groupBy,
+		TimeZone: s.location.String(),
+		Buckets:  []models.SalesBucket{},
+	}
+
+	if fromDate, toDate, ok := s.dayBounds(p); ok && groupBy != GroupByHour {
+		err = s.aggregateSales(fromDate, toDate, groupBy, bucket, &report.Totals)
+	} else {
+		err = s.collectSales(p, groupBy, bucket, &report.Totals)
+	}
+	if err != nil {
+		return models.SalesReport{}, err
+	}
+
+	if groupBy != GroupByItem && !p.from.IsZero() && !p.to.IsZero() {
+		start := s.bucketStart(p.from, groupBy)
+		for t, n := start, 0; t.Before(p.to) && n < maxFilledBuckets; t, n = s.nextBucket(t, groupBy), n+1 {
+			bucket(s.bucketKey(t, groupBy))
+		}
+	}
+
+	for _, b := range buckets {
+		finishSalesBucket(b)
+		report.Buckets = append(report.Buckets, *b)
+	}
+	sort.Slice(report.Buckets, func(i, j int) bool {
+		return report.Buckets[i].Bucket < report.Buckets[j].Bucket
+	})
+	finishSalesBucket(&report.Totals)
+	report.Totals.Bucket = "total"
+	return report, nil
+}
+
+func (s *ReportService) collectSales(p period, groupBy string, bucket func(key string) *models.SalesBucket, totals *models.SalesBucket) error {
+	orders, err := s.orderRepo.LoadOrders()
+	if err != nil {
+		return err
+	}
+	menuItems, err := s.menuRepo.LoadMenuItems()
+	if err != nil {
+		return err
+	}
+	refunds, err := s.refundRepo.LoadRefunds()
+	if err != nil {
+		return err
+	}
+
+	for _, order := range orders {
+		if !isSold(order.Status) {
+			continue
+		}
+		createdAt, err := time.Parse(time.RFC3339, order.CreatedAt)
+		if err != nil || !p.contains(createdAt) {
+			continue
+		}
+
+		subtotal := orderSubtotal(order, menuItems)
+		discounts := orderDiscounts(order)
+		totals.OrderCount++
+		totals.Gross += subtotal
+		totals.Discounts += discounts
+
+		if groupBy != GroupByItem {
+			b := bucket(s.bucketKey(createdAt, groupBy))
+			b.OrderCount++
+			b.Gross += subtotal
+			b.Discounts += discounts
+			for _, item := range order.Items {
+				b.ItemsSold += item.Quantity
+				totals.ItemsSold += item.Quantity
+			}
+			continue
+		}
+
+		counted := make(map[string]bool)
+		for _, item := range order.Items {
+			line := unitPrice(item, menuItems) * float64(item.Quantity)
+			b := bucket(item.ProductID)
+			if b.Name == "" || item.Name != "" {
+				b.Name = itemName(item, menuItems)
+			}
+			if !counted[item.ProductID] {
+				b.OrderCount++
+				counted[item.ProductID] = true
+			}
+			b.ItemsSold += item.Quantity
+			b.Gross += line
+			if subtotal > 0 {
+				b.Discounts += discounts * line / subtotal
+			}
+			totals.ItemsSold += item.Quantity
+		}
+	}
+
+	// Gross is before tax, so refunds count without the tax they gave back.
+	for _, refund := range fillRefundTax(refunds, orders, menuItems) {
+		createdAt, err := time.Parse(time.RFC3339, refund.CreatedAt)
+		if err != nil || !p.contains(createdAt) {
+			continue
+		}
+		totals.Refunds += refundNet(refund)
+		if groupBy != GroupByItem {
+			bucket(s.bucketKey(createdAt, groupBy)).Refunds += refundNet(refund)
+			continue
+		}
+		for _, line := range refund.Lines {
+			bucket(line.ProductID).Refunds += refundLineNet(refund, line)
+		}
+	}
+	return nil
+}
+
+// aggregateSales answers day-aligned queries from the daily aggregates.
+func (s *ReportService) aggregateSales(fromDate string, toDate string, groupBy string, bucket func(key string) *models.SalesBucket, totals *models.SalesBucket) error {
+	agg, err := s.aggregateRepo.LoadAggregates()
+	if err != nil {
+		return err
+	}
+
+	for _, day := range sortedDays(agg, fromDate, toDate) {
+		totals.OrderCount += day.OrderCount
+		totals.ItemsSold += day.ItemsSold
+		totals.Gross += day.Gross
+		totals.Discounts += day.Discounts
+		totals.Refunds += day.Refunds - day.RefundTaxes
+
+		if groupBy != GroupByItem {
+			date, err := time.ParseInLocation(time.DateOnly, day.Date, s.location)
+			if err != nil {
+				continue
+			}
+			b := bucket(s.bucketKey(date, groupBy))
+			b.OrderCount += day.OrderCount
+			b.ItemsSold += day.ItemsSold
+			b.Gross += day.Gross
+			b.Discounts += day.Discounts
+			b.Refunds += day.Refunds - day.RefundTaxes
+			continue
+		}
+
+		for id, item := range day.Items {
+			b := bucket(id)
+			if item.Name != "" {
+				b.Name = item.Name
+			}
+			b.OrderCount += item.OrderCount
+			b.ItemsSold += item.Quantity
+			b.Gross += item.Gross
+			b.Discounts += item.Discounts
+			b.Refunds += item.Refunds - item.RefundTaxes
+		}
+	}
+	return nil
+}
+
+// dayBounds returns the shop-local dates [from, to) covering p when both ends
+// fall on midnight, which is when the daily aggregates can answer a query.
+func (s *ReportService) dayBounds(p period) (string, string, bool) {
+	var fromDate, toDate string
+	for i, t := range []time.Time{p.from, p.to} {
+		if t.IsZero() {
+			continue
+		}
+		local := t.In(s.location)
+		if local.Hour() != 0 || local.Minute() != 0 || local.Second() != 0 || local.Nanosecond() != 0 {
+			return "", "", false
+		}
+		if i == 0 {
+			fromDate = local.Format(time.DateOnly)
+		} else {
+			toDate = local.Format(time.DateOnly)
+		}
+	}
+	return fromDate, toDate, true
+}
+
+func sortedDays(agg models.ReportAggregates, fromDate string, toDate string) []*models.DailyAggregate {
+	var days []*models.DailyAggregate
+	for date, day := range agg.Days {
+		if fromDate != "" && date < fromDate || toDate != "" && date >= toDate {
+			continue
+		}
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].Date < days[j].Date
+	})
+	return days
+}
+
+func finishSalesBucket(b *models.SalesBucket) {
+	b.Gross = roundMoney(b.Gross)
+	b.Discounts = roundMoney(b.Discounts)
+	b.Refunds = roundMoney(b.Refunds)
+	b.Net = roundMoney(b.Gross - b.Discounts - b.Refunds)
+}
+
+func (s *ReportService) bucketStart(t time.Time, groupBy string) time.Time {
+	t = t.In(s.location)
+	switch groupBy {
+	case GroupByHour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, s.location)
+	case GroupByWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.location)
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case GroupByMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, s.location)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.location)
+	}
+}
+
+func (s *ReportService) nextBucket(t time.Time, groupBy string) time.Time {
+	switch groupBy {
+	case GroupByHour:
+		return t.Add(time.Hour)
+	case GroupByWeek:
+		return t.AddDate(0, 0, 7)
+	case GroupByMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+func (s *ReportService) bucketKey(t time.Time, groupBy string) string {
+	start := s.bucketStart(t, groupBy)
+	switch groupBy {
+	case GroupByHour:
+		return start.Format("2006-01-02T15:00")
+	case GroupByMonth:
+		return start.Format("2006-01")
+	default:
+		return start.Format(time.DateOnly)
+	}
+}
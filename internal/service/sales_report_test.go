@@ -0,0 +1,155 @@
+package service
+
+import (
+	"errors"
+	"hot-coffee/models"
+	"testing"
+	"time"
+)
+
+// soldAt stores a closed order placed at createdAt, bypassing the order
+// service so reports can be tested against fixed dates.
+func (shop *testShop) soldAt(t *testing.T, createdAt string, items ...models.OrderItem) models.Order {
+	t.Helper()
+	order, err := shop.Orders.OrderRepo.CreateOrder(models.Order{CustomerName: "Alice", Items: items, Status: "open", CreatedAt: createdAt})
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	if err := shop.Orders.OrderRepo.CloseOrder(order.ID); err != nil {
+		t.Fatalf("CloseOrder() error = %v", err)
+	}
+	order, _ = shop.Orders.OrderRepo.GetOrderByID(order.ID)
+	return order
+}
+
+// refundedAt stores a tax-free refund of quantity units of productID made at
+// createdAt.
+func (shop *testShop) refundedAt(t *testing.T, order models.Order, createdAt string, productID string, quantity int, amount float64) {
+	t.Helper()
+	refund := models.Refund{
+		ID:        order.ID + "-r1",
+		OrderID:   order.ID,
+		Lines:     []models.RefundLine{{ProductID: productID, Quantity: quantity, Amount: amount}},
+		Amount:    amount,
+		CreatedAt: createdAt,
+	}
+	if err := shop.Refunds.RefundRepo.AddRefund(refund); err != nil {
+		t.Fatalf("AddRefund() error = %v", err)
+	}
+}
+
+// inZone moves the reports and their daily aggregates to location.
+func (shop *testShop) inZone(t *testing.T, location *time.Location) {
+	t.Helper()
+	shop.Reports.location = location
+	shop.Orders.Aggregates.location = location
+	if _, err := shop.Orders.Aggregates.Rebuild(); err != nil {
+		t.Fatalf("Rebuild() error = %v", err)
+	}
+}
+
+// newTestSalesShop returns a shop with four orders in March 2026, the
+// first on Sunday the 1st, and a refunded tea on the 9th:
+//
+//	2026-03-01T10:00Z  latte     5.00
+//	2026-03-02T02:00Z  tea       3.00
+//	2026-03-02T09:15Z  2 lattes 10.00
+//	2026-03-09T12:00Z  2 teas    6.00, one refunded at 13:00
+func newTestSalesShop(t *testing.T) *testShop {
+	t.Helper()
+	shop := newTestShop(t)
+	shop.soldAt(t, "2026-03-01T10:00:00Z", models.OrderItem{ProductID: "latte", Quantity: 1})
+	shop.soldAt(t, "2026-03-02T02:00:00Z", models.OrderItem{ProductID: "tea", Quantity: 1})
+	shop.soldAt(t, "2026-03-02T09:15:00Z", models.OrderItem{ProductID: "latte", Quantity: 2})
+	teas := shop.soldAt(t, "2026-03-09T12:00:00Z", models.OrderItem{ProductID: "tea", Quantity: 2})
+	shop.refundedAt(t, teas, "2026-03-09T13:00:00Z", "tea", 1, 3)
+	shop.inZone(t, time.UTC)
+	return shop
+}
+
+func TestGetSalesReport(t *testing.T) {
+	tests := []struct {
+		name        string
+		location    *time.Location
+		from        string
+		to          string
+		groupBy     string
+		wantBuckets []models.SalesBucket
+		wantTotals  models.SalesBucket
+	}{
+		{name: "days", from: "2026-03-01", to: "2026-03-02", wantBuckets: []models.SalesBucket{
+			{Bucket: "2026-03-01", OrderCount: 1, ItemsSold: 1, Gross: 5, Net: 5},
+			{Bucket: "2026-03-02", OrderCount: 2, ItemsSold: 3, Gross: 13, Net: 13},
+		}, wantTotals: models.SalesBucket{OrderCount: 3, ItemsSold: 4, Gross: 18, Net: 18}},
+		{name: "days in the shop's time zone", location: time.FixedZone("UTC-5", -5*60*60), from: "2026-03-01", to: "2026-03-02", wantBuckets: []models.SalesBucket{
+			{Bucket: "2026-03-01", OrderCount: 2, ItemsSold: 2, Gross: 8, Net: 8},
+			{Bucket: "2026-03-02", OrderCount: 1, ItemsSold: 2, Gross: 10, Net: 10},
+		}, wantTotals: models.SalesBucket{OrderCount: 3, ItemsSold: 4, Gross: 18, Net: 18}},
+		{name: "hours with gaps", from: "2026-03-01T09:00:00Z", to: "2026-03-01T12:00:00Z", groupBy: GroupByHour, wantBuckets: []models.SalesBucket{
+			{Bucket: "2026-03-01T09:00"},
+			{Bucket: "2026-03-01T10:00", OrderCount: 1, ItemsSold: 1, Gross: 5, Net: 5},
+			{Bucket: "2026-03-01T11:00"},
+		}, wantTotals: models.SalesBucket{OrderCount: 1, ItemsSold: 1, Gross: 5, Net: 5}},
+		{name: "weeks start on monday", groupBy: GroupByWeek, wantBuckets: []models.SalesBucket{
+			{Bucket: "2026-02-23", OrderCount: 1, ItemsSold: 1, Gross: 5, Net: 5},
+			{Bucket: "2026-03-02", OrderCount: 2, ItemsSold: 3, Gross: 13, Net: 13},
+			{Bucket: "2026-03-09", OrderCount: 1, ItemsSold: 2, Gross: 6, Refunds: 3, Net: 3},
+		}, wantTotals: models.SalesBucket{OrderCount: 4, ItemsSold: 6, Gross: 24, Refunds: 3, Net: 21}},
+		{name: "months", groupBy: GroupByMonth, wantBuckets: []models.SalesBucket{
+			{Bucket: "2026-03", OrderCount: 4, ItemsSold: 6, Gross: 24, Refunds: 3, Net: 21},
+		}, wantTotals: models.SalesBucket{OrderCount: 4, ItemsSold: 6, Gross: 24, Refunds: 3, Net: 21}},
+		{name: "items", from: "2026-03-02", to: "2026-03-09", groupBy: GroupByItem, wantBuckets: []models.SalesBucket{
+			{Bucket: "latte", Name: "Latte", OrderCount: 1, ItemsSold: 2, Gross: 10, Net: 10},
+			{Bucket: "tea", Name: "Tea", OrderCount: 2, ItemsSold: 3, Gross: 9, Refunds: 3, Net: 6},
+		}, wantTotals: models.SalesBucket{OrderCount: 3, ItemsSold: 5, Gross: 19, Refunds: 3, Net: 16}},
+		{name: "items by the hour", from: "2026-03-02T00:00:00Z", to: "2026-03-02T09:00:00Z", groupBy: GroupByItem, wantBuckets: []models.SalesBucket{
+			{Bucket: "tea", Name: "Tea", OrderCount: 1, ItemsSold: 1, Gross: 3, Net: 3},
+		}, wantTotals: models.SalesBucket{OrderCount: 1, ItemsSold: 1, Gross: 3, Net: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shop := newTestSalesShop(t)
+			if tt.location != nil {
+				shop.inZone(t, tt.location)
+			}
+			report, err := shop.Reports.GetSalesReport(tt.from, tt.to, tt.groupBy)
+			if err != nil {
+				t.Fatalf("GetSalesReport() error = %v", err)
+			}
+			if len(report.Buckets) != len(tt.wantBuckets) {
+				t.Fatalf("buckets = %+v, want %+v", report.Buckets, tt.wantBuckets)
+			}
+			for i, want := range tt.wantBuckets {
+				if report.Buckets[i] != want {
+					t.Errorf("bucket %d = %+v, want %+v", i, report.Buckets[i], want)
+				}
+			}
+			tt.wantTotals.Bucket = "total"
+			if report.Totals != tt.wantTotals {
+				t.Errorf("totals = %+v, want %+v", report.Totals, tt.wantTotals)
+			}
+		})
+	}
+}
+
+func TestGetSalesReportInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		groupBy string
+	}{
+		{name: "unknown grouping", groupBy: "year"},
+		{name: "bad date", from: "03/01/2026"},
+		{name: "from after to", from: "2026-03-09", to: "2026-03-01"},
+		{name: "empty range", from: "2026-03-01T10:00:00Z", to: "2026-03-01T10:00:00Z"},
+	}
+	shop := newTestSalesShop(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := shop.Reports.GetSalesReport(tt.from, tt.to, tt.groupBy); !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("GetSalesReport() error = %v, want %v", err, ErrInvalidQuery)
+			}
+		})
+	}
+}
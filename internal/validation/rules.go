@@ -0,0 +1,144 @@
+package validation
+
+import (
+	"fmt"
+	"hot-coffee/models"
+	"strings"
+)
+
+// NewInventoryItem checks an item being created. Its ID may be left empty for
+// the server to generate.
+func NewInventoryItem(item models.InventoryItem) error {
+	return inventoryItem(item, true)
+}
+
+func InventoryItem(item models.InventoryItem) error {
+	return inventoryItem(item, false)
+}
+
+func inventoryItem(item models.InventoryItem, create bool) error {
+	var errs Errors
+	identifier(&errs, "ingredient_id", item.IngredientID, create)
+	required(&errs, "name", item.Name)
+	required(&errs, "unit", item.Unit)
+	if item.Quantity < 0 {
+		errs.Add("quantity", CodeMin, "cannot be negative")
+	}
+	if item.UnitCost < 0 {
+		errs.Add("unit_cost", CodeMin, "cannot be negative")
+	}
+	return errs.Err()
+}
+
+// NewMenuItem checks an item being created against the set of known
+// ingredient IDs. Its ID may be left empty for the server to generate.
+func NewMenuItem(item models.MenuItem, ingredients map[string]bool) error {
+	return menuItem(item, ingredients, true)
+}
+
+// MenuItem checks item against the set of known ingredient IDs.
+func MenuItem(item models.MenuItem, ingredients map[string]bool) error {
+	return menuItem(item, ingredients, false)
+}
+
+func menuItem(item models.MenuItem, ingredients map[string]bool, create bool) error {
+	var errs Errors
+	identifier(&errs, "product_id", item.ID, create)
+	required(&errs, "name", item.Name)
+	if item.Price < 0 {
+		errs.Add("price", CodeMin, "cannot be negative")
+	}
+
+	seen := make(map[string]bool)
+	for i, ing := range item.Ingredients {
+		field := fmt.Sprintf("ingredients[%d]", i)
+		switch {
+		case ing.IngredientID == "":
+			errs.Add(field+".ingredient_id", CodeRequired, "is required")
+		case !ingredients[ing.IngredientID]:
+			errs.Add(field+".ingredient_id", CodeNotFound, "inventory item '%s' does not exist", ing.IngredientID)
+		case seen[ing.IngredientID]:
+			errs.Add(field+".ingredient_id", CodeDuplicate, "'%s' is listed more than once", ing.IngredientID)
+		}
+		seen[ing.IngredientID] = true
+		if ing.Quantity <= 0 {
+			errs.Add(field+".quantity", CodeMin, "must be greater than zero")
+		}
+	}
+	return errs.Err()
+}
+
+// NewOrder checks an order being created. On top of the Order rules it rejects
+// fields the server sets itself, so a client cannot pick an order's ID, status,
+// history or totals.
+func NewOrder(order models.Order, products map[string]bool) error {
+	var errs Errors
+	readOnly(&errs, "order_id", order.ID != "")
+	readOnly(&errs, "ticket", order.Ticket != "")
+	readOnly(&errs, "status", order.Status != "")
+	readOnly(&errs, "discounts", order.Discounts != nil)
+	readOnly(&errs, "tax", order.Tax != 0)
+	readOnly(&errs, "subtotal", order.Subtotal != 0)
+	readOnly(&errs, "total", order.Total != 0)
+	readOnly(&errs, "created_at", order.CreatedAt != "")
+	readOnly(&errs, "void_reason", order.VoidReason != "")
+	readOnly(&errs, "voided_at", order.VoidedAt != "")
+	readOnly(&errs, "history", order.History != nil)
+	readOnly(&errs, "version", order.Version != 0)
+	for i, item := range order.Items {
+		field := fmt.Sprintf("items[%d]", i)
+		readOnly(&errs, field+".name", item.Name != "")
+		readOnly(&errs, field+".price", item.Price != 0)
+		readOnly(&errs, field+".category", item.Category != "")
+		readOnly(&errs, field+".unit_cost", item.UnitCost != nil)
+	}
+	orderRules(&errs, order, products)
+	return errs.Err()
+}
+
+// Order checks order against the set of known product IDs. A customer name is
+// only required for orders not linked to a customer record.
+func Order(order models.Order, products map[string]bool) error {
+	var errs Errors
+	orderRules(&errs, order, products)
+	return errs.Err()
+}
+
+func orderRules(errs *Errors, order models.Order, products map[string]bool) {
+	if order.CustomerID == "" {
+		required(errs, "customer_name", order.CustomerName)
+	}
+	if len(order.Items) == 0 {
+		errs.Add("items", CodeRequired, "must contain at least one item")
+	}
+	for i, item := range order.Items {
+		field := fmt.Sprintf("items[%d]", i)
+		switch {
+		case item.ProductID == "":
+			errs.Add(field+".product_id", CodeRequired, "is required")
+		case !products[item.ProductID]:
+			errs.Add(field+".product_id", CodeNotFound, "menu item '%s' does not exist", item.ProductID)
+		}
+		if item.Quantity <= 0 {
+			errs.Add(field+".quantity", CodeMin, "must be greater than zero")
+		}
+	}
+}
+
+func readOnly(errs *Errors, field string, set bool) {
+	if set {
+		errs.Add(field, CodeReadOnly, "is set by the server")
+	}
+}
+
+// identifier requires an ID on updates. On creation an ID is optional, but a
+// client-chosen one must be usable as a single URL path segment.
+func identifier(errs *Errors, field string, id string, create bool) {
+	if !create {
+		required(errs, field, id)
+		return
+	}
+	if strings.ContainsAny(id, "/?#% \t\r\n") {
+		errs.Add(field, CodeInvalidFormat, "must not contain whitespace or URL delimiters")
+	}
+}
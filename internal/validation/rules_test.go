@@ -0,0 +1,124 @@
+package validation
+
+import (
+	"errors"
+	"hot-coffee/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewOrder(t *testing.T) {
+	products := map[string]bool{"latte": true}
+	cost := 0.4
+	valid := func() models.Order {
+		return models.Order{
+			CustomerName: "Alice",
+			Items:        []models.OrderItem{{ProductID: "latte", Quantity: 1}},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(o *models.Order)
+		want   []FieldError
+	}{
+		{name: "valid", modify: func(o *models.Order) {}},
+		{name: "linked customer needs no name", modify: func(o *models.Order) { o.CustomerID, o.CustomerName = "c1", "" }},
+		{name: "order ID", modify: func(o *models.Order) { o.ID = "20260101-0001" }, want: []FieldError{{Field: "order_id", Code: CodeReadOnly}}},
+		{name: "status", modify: func(o *models.Order) { o.Status = "closed" }, want: []FieldError{{Field: "status", Code: CodeReadOnly}}},
+		{name: "ticket", modify: func(o *models.Order) { o.Ticket = "A1" }, want: []FieldError{{Field: "ticket", Code: CodeReadOnly}}},
+		{name: "version", modify: func(o *models.Order) { o.Version = 3 }, want: []FieldError{{Field: "version", Code: CodeReadOnly}}},
+		{name: "created at", modify: func(o *models.Order) { o.CreatedAt = "2026-01-01T00:00:00Z" }, want: []FieldError{{Field: "created_at", Code: CodeReadOnly}}},
+		{name: "history", modify: func(o *models.Order) { o.History = []models.StatusChange{{Status: "open"}} }, want: []FieldError{{Field: "history", Code: CodeReadOnly}}},
+		{name: "discounts", modify: func(o *models.Order) { o.Discounts = []models.OrderDiscount{{Amount: 1}} }, want: []FieldError{{Field: "discounts", Code: CodeReadOnly}}},
+		{name: "void", modify: func(o *models.Order) { o.VoidReason, o.VoidedAt = "x", "2026-01-01T00:00:00Z" }, want: []FieldError{
+			{Field: "void_reason", Code: CodeReadOnly},
+			{Field: "voided_at", Code: CodeReadOnly},
+		}},
+		{name: "totals", modify: func(o *models.Order) { o.Tax, o.Subtotal, o.Total = 1, 3.5, 4.5 }, want: []FieldError{
+			{Field: "tax", Code: CodeReadOnly},
+			{Field: "subtotal", Code: CodeReadOnly},
+			{Field: "total", Code: CodeReadOnly},
+		}},
+		{name: "item snapshot", modify: func(o *models.Order) {
+			o.Items[0].Name, o.Items[0].Price, o.Items[0].Category, o.Items[0].UnitCost = "Latte", 0.01, "coffee", &cost
+		}, want: []FieldError{
+			{Field: "items[0].name", Code: CodeReadOnly},
+			{Field: "items[0].price", Code: CodeReadOnly},
+			{Field: "items[0].category", Code: CodeReadOnly},
+			{Field: "items[0].unit_cost", Code: CodeReadOnly},
+		}},
+		{name: "read-only and invalid together", modify: func(o *models.Order) {
+			o.Status, o.CustomerName = "open", " "
+			o.Items = append(o.Items, models.OrderItem{ProductID: "mocha", Quantity: 0})
+		}, want: []FieldError{
+			{Field: "status", Code: CodeReadOnly},
+			{Field: "customer_name", Code: CodeRequired},
+			{Field: "items[1].product_id", Code: CodeNotFound},
+			{Field: "items[1].quantity", Code: CodeMin},
+		}},
+		{name: "no items", modify: func(o *models.Order) { o.Items = nil }, want: []FieldError{{Field: "items", Code: CodeRequired}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := valid()
+			tt.modify(&order)
+			err := NewOrder(order, products)
+
+			var got []FieldError
+			var errs Errors
+			if errors.As(err, &errs) {
+				for _, fe := range errs {
+					got = append(got, FieldError{Field: fe.Field, Code: fe.Code})
+				}
+			} else if err != nil {
+				t.Fatalf("NewOrder() error = %v, want Errors", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewOrder() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderAllowsServerFields(t *testing.T) {
+	// Updates send back what the server returned, so Order itself must not
+	// reject server-set fields.
+	order := models.Order{
+		ID:           "20260101-0001",
+		Status:       "open",
+		Version:      2,
+		CustomerName: "Alice",
+		Items:        []models.OrderItem{{ProductID: "latte", Quantity: 1, Name: "Latte", Price: 3.5}},
+	}
+	if err := Order(order, map[string]bool{"latte": true}); err != nil {
+		t.Errorf("Order() error = %v", err)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want FieldError
+	}{
+		{name: "empty", body: "", want: FieldError{Code: CodeMalformed}},
+		{name: "unknown field", body: `{"customer_name":"A","colour":"red"}`, want: FieldError{Field: "colour", Code: CodeUnknownField}},
+		{name: "wrong type", body: `{"customer_name":5}`, want: FieldError{Field: "customer_name", Code: CodeInvalidType}},
+		{name: "trailing data", body: `{"customer_name":"A"} {}`, want: FieldError{Code: CodeMalformed}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var order models.Order
+			err := Decode(strings.NewReader(tt.body), &order)
+			var errs Errors
+			if !errors.As(err, &errs) || len(errs) != 1 {
+				t.Fatalf("Decode() error = %v, want one field error", err)
+			}
+			if got := (FieldError{Field: errs[0].Field, Code: errs[0].Code}); got != tt.want {
+				t.Errorf("Decode() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
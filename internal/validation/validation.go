@@ -0,0 +1,108 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+const (
+	CodeMalformed     = "malformed_json"
+	CodeUnknownField  = "unknown_field"
+	CodeInvalidType   = "invalid_type"
+	CodeInvalidFormat = "invalid_format"
+	CodeRequired      = "required"
+	CodeMin           = "min"
+	CodeDuplicate     = "duplicate"
+	CodeNotFound      = "not_found"
+	CodeReadOnly      = "read_only"
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors collects every problem found in a payload so clients can fix them in
+// one round trip.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		if fe.Field == "" {
+			messages[i] = fe.Message
+			continue
+		}
+		messages[i] = fe.Field + ": " + fe.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *Errors) Add(field string, code string, format string, args ...any) {
+	*e = append(*e, FieldError{Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// Err returns nil when nothing was collected, avoiding a non-nil error
+// interface that wraps an empty slice.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Decode reads exactly one JSON value into v and rejects fields v does not
+// declare. Failures are reported as Errors so handlers can answer 400 with the
+// same body shape as a 422.
+func Decode(body io.Reader, v any) error {
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err == nil && dec.More() {
+		err = errors.New("unexpected data after the JSON value")
+	}
+	if err == nil {
+		return nil
+	}
+
+	var errs Errors
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr):
+		errs.Add(typeErr.Field, CodeInvalidType, "must be %s", jsonType(typeErr.Type))
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		errs.Add(field, CodeUnknownField, "is not a recognised field")
+	case errors.Is(err, io.EOF):
+		errs.Add("", CodeMalformed, "request body is empty")
+	default:
+		errs.Add("", CodeMalformed, "%v", err)
+	}
+	return errs
+}
+
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	default:
+		return "a number"
+	}
+}
+
+func required(errs *Errors, field string, value string) {
+	if strings.TrimSpace(value) == "" {
+		errs.Add(field, CodeRequired, "is required")
+	}
+}
@@ -0,0 +1,28 @@
+package models
+
+type Account struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+type ChartOfAccounts struct {
+	Revenue           Account            `json:"revenue"`
+	RevenueByCategory map[string]Account `json:"revenue_by_category,omitempty"`
+	Discounts         Account            `json:"discounts"`
+	Refunds           Account            `json:"refunds"`
+	SalesTax          Account            `json:"sales_tax"`
+	Tips              Account            `json:"tips"`
+	Receivable        Account            `json:"receivable"`
+	CostOfGoods       Account            `json:"cost_of_goods"`
+	Inventory         Account            `json:"inventory"`
+	Tenders           map[string]Account `json:"tenders"`
+}
+
+type JournalLine struct {
+	Date        string
+	EntryID     string
+	Account     Account
+	Description string
+	Debit       float64
+	Credit      float64
+}
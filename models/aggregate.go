@@ -0,0 +1,44 @@
+package models
+
+type ItemAggregate struct {
+	ProductID        string  `json:"product_id"`
+	Name             string  `json:"name"`
+	Category         string  `json:"category,omitempty"`
+	OrderCount       int     `json:"order_count"`
+	Quantity         int     `json:"quantity"`
+	Gross            float64 `json:"gross"`
+	Discounts        float64 `json:"discounts"`
+	RefundedQuantity int     `json:"refunded_quantity"`
+	Refunds          float64 `json:"refunds"`
+	RefundTaxes      float64 `json:"refund_taxes"`
+}
+
+type DailyAggregate struct {
+	Date       string  `json:"date"`
+	OrderCount int     `json:"order_count"`
+	ItemsSold  int     `json:"items_sold"`
+	Gross      float64 `json:"gross"`
+	Discounts  float64 `json:"discounts"`
+	Taxes      float64 `json:"taxes"`
+	Refunds    float64 `json:"refunds"`
+	// RefundTaxes is the sales tax included in Refunds.
+	RefundTaxes float64                   `json:"refund_taxes"`
+	Items       map[string]*ItemAggregate `json:"items"`
+}
+
+type ReportAggregates struct {
+	Version   int                        `json:"version"`
+	TimeZone  string                     `json:"time_zone"`
+	RebuiltAt string                     `json:"rebuilt_at,omitempty"`
+	Days      map[string]*DailyAggregate `json:"days"`
+	Orders    map[string]string          `json:"orders"`
+	Refunds   map[string]string          `json:"refunds"`
+}
+
+type AggregateStatus struct {
+	TimeZone  string `json:"time_zone"`
+	RebuiltAt string `json:"rebuilt_at,omitempty"`
+	Days      int    `json:"days"`
+	Orders    int    `json:"orders"`
+	Refunds   int    `json:"refunds"`
+}
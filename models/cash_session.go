@@ -0,0 +1,30 @@
+package models
+
+const (
+	CashEventIn  = "cash_in"
+	CashEventOut = "cash_out"
+)
+
+type CashSession struct {
+	ID             string      `json:"session_id"`
+	Status         string      `json:"status"`
+	OpenedBy       string      `json:"opened_by"`
+	OpenedAt       string      `json:"opened_at"`
+	OpeningFloat   float64     `json:"opening_float"`
+	Events         []CashEvent `json:"events"`
+	ClosedAt       string      `json:"closed_at,omitempty"`
+	CountedAmount  float64     `json:"counted_amount"`
+	ExpectedAmount float64     `json:"expected_amount"`
+	OverShort      float64     `json:"over_short"`
+}
+
+type CashEvent struct {
+	Type   string  `json:"type"`
+	Amount float64 `json:"amount"`
+	Reason string  `json:"reason"`
+	At     string  `json:"at"`
+}
+
+type CloseCashSessionRequest struct {
+	CountedAmount float64 `json:"counted_amount"`
+}
@@ -0,0 +1,18 @@
+package models
+
+type Customer struct {
+	ID        string `json:"customer_id"`
+	Name      string `json:"name"`
+	Phone     string `json:"phone"`
+	Email     string `json:"email"`
+	Notes     string `json:"notes"`
+	CreatedAt string `json:"created_at"`
+}
+
+type CustomerHistory struct {
+	Customer      Customer            `json:"customer"`
+	OrderCount    int                 `json:"order_count"`
+	LifetimeSpend float64             `json:"lifetime_spend"`
+	FavoriteItems []PopularItemReport `json:"favorite_items"`
+	Orders        []Order             `json:"orders"`
+}
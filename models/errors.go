@@ -0,0 +1,81 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Domain errors shared by the data and service layers. Callers wrap them with
+// context using %w and handlers map them to HTTP statuses.
+var (
+	ErrNotFound          = errors.New("not found")
+	ErrConflict          = errors.New("conflict")
+	ErrInvalidTransition = errors.New("invalid status transition")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrVersionMismatch   = errors.New("version mismatch")
+)
+
+// Error kinds. Packages declare their own sentinels of a kind with NewError so
+// handlers can map every sentinel of a kind to the same HTTP status.
+var (
+	ErrInvalidInput        = errors.New("invalid input")
+	ErrUnauthorized        = errors.New("unauthorized")
+	ErrDeclined            = errors.New("declined")
+	ErrUpstreamTimeout     = errors.New("upstream timeout")
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")
+)
+
+// NewError returns a sentinel with its own message that also matches kind with
+// errors.Is.
+func NewError(kind error, message string) error {
+	return &kindError{kind: kind, message: message}
+}
+
+type kindError struct {
+	kind    error
+	message string
+}
+
+func (e *kindError) Error() string {
+	return e.message
+}
+
+func (e *kindError) Is(target error) bool {
+	return target == e.kind
+}
+
+// CheckVersion guards optimistic writes. An expected version of 0 means the
+// caller did not ask for a check.
+func CheckVersion(kind string, id string, expected int, current int) error {
+	if expected != 0 && expected != current {
+		return fmt.Errorf("%w: %s '%s' is at version %d, not %d", ErrVersionMismatch, kind, id, current, expected)
+	}
+	return nil
+}
+
+type StockShortage struct {
+	IngredientID string  `json:"ingredient_id"`
+	Name         string  `json:"name"`
+	Required     float64 `json:"required"`
+	Available    float64 `json:"available"`
+	Unit         string  `json:"unit"`
+}
+
+// InsufficientStockError lists every ingredient that cannot cover a request.
+// It matches ErrInsufficientStock with errors.Is.
+type InsufficientStockError struct {
+	Shortages []StockShortage
+}
+
+func (e *InsufficientStockError) Error() string {
+	parts := make([]string, len(e.Shortages))
+	for i, s := range e.Shortages {
+		parts[i] = fmt.Sprintf("'%s' requires %.2f, %.2f available", s.Name, s.Required, s.Available)
+	}
+	return ErrInsufficientStock.Error() + ": " + strings.Join(parts, "; ")
+}
+
+func (e *InsufficientStockError) Is(target error) bool {
+	return target == ErrInsufficientStock
+}
@@ -0,0 +1,39 @@
+package models
+
+const (
+	GiftCardIssue  = "issue"
+	GiftCardReload = "reload"
+	GiftCardRedeem = "redeem"
+	GiftCardRefund = "refund"
+	GiftCardVoid   = "void"
+)
+
+type GiftCard struct {
+	Code         string  `json:"code"`
+	Balance      float64 `json:"balance"`
+	InitialValue float64 `json:"initial_value"`
+	IssuedAt     string  `json:"issued_at"`
+}
+
+type GiftCardTransaction struct {
+	ID           string  `json:"transaction_id"`
+	Code         string  `json:"code"`
+	Type         string  `json:"type"`
+	Amount       float64 `json:"amount"`
+	BalanceAfter float64 `json:"balance_after"`
+	OrderID      string  `json:"order_id,omitempty"`
+	Reference    string  `json:"reference,omitempty"`
+	// Tender, SessionID and GatewayTransactionID record how an issue or
+	// reload was paid for, and which payment a void takes back.
+	Tender               string `json:"tender,omitempty"`
+	SessionID            string `json:"session_id,omitempty"`
+	GatewayTransactionID string `json:"gateway_transaction_id,omitempty"`
+	CreatedAt            string `json:"created_at"`
+}
+
+type GiftCardAmountRequest struct {
+	Code      string  `json:"code"`
+	Amount    float64 `json:"amount"`
+	Tender    string  `json:"tender"`
+	CardToken string  `json:"card_token,omitempty"`
+}
@@ -0,0 +1,15 @@
+package models
+
+// IdempotencyRecord is the first response given for an Idempotency-Key,
+// replayed verbatim when a client retries the same request.
+type IdempotencyRecord struct {
+	Key         string            `json:"key"`
+	Method      string            `json:"method"`
+	Path        string            `json:"path"`
+	RequestHash string            `json:"request_hash"`
+	Status      int               `json:"status"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        string            `json:"body"`
+	CreatedAt   string            `json:"created_at"`
+	ExpiresAt   string            `json:"expires_at"`
+}
@@ -5,4 +5,6 @@ type InventoryItem struct {
 	Name         string  `json:"name"`
 	Quantity     float64 `json:"quantity"`
 	Unit         string  `json:"unit"`
+	UnitCost     float64 `json:"unit_cost,omitempty"`
+	Version      int     `json:"version"`
 }
@@ -0,0 +1,30 @@
+package models
+
+const (
+	MovementInitial = "initial"
+	MovementReceive = "receive"
+	MovementWaste   = "waste"
+	MovementAdjust  = "adjust"
+	MovementCount   = "count"
+)
+
+type InventoryMovement struct {
+	ID           string  `json:"movement_id"`
+	IngredientID string  `json:"ingredient_id"`
+	Type         string  `json:"type"`
+	Quantity     float64 `json:"quantity"`
+	StockAfter   float64 `json:"stock_after"`
+	Reason       string  `json:"reason,omitempty"`
+	CreatedAt    string  `json:"created_at"`
+}
+
+type InventoryMovementRequest struct {
+	Type     string  `json:"type"`
+	Quantity float64 `json:"quantity"`
+	Reason   string  `json:"reason"`
+}
+
+type InventoryCountRequest struct {
+	Counted float64 `json:"counted"`
+	Reason  string  `json:"reason"`
+}
@@ -0,0 +1,37 @@
+package models
+
+const (
+	LoyaltyEarn    = "earn"
+	LoyaltyRedeem  = "redeem"
+	LoyaltyReverse = "reverse"
+	LoyaltyRestore = "restore"
+)
+
+type LoyaltyProgram struct {
+	PointsPerCurrencyUnit float64         `json:"points_per_currency_unit"`
+	PointsPerItem         int             `json:"points_per_item"`
+	Rewards               []LoyaltyReward `json:"rewards"`
+}
+
+type LoyaltyReward struct {
+	ID         string `json:"reward_id"`
+	Name       string `json:"name"`
+	PointsCost int    `json:"points_cost"`
+	ProductID  string `json:"product_id"`
+}
+
+type LoyaltyEntry struct {
+	ID         string `json:"entry_id"`
+	CustomerID string `json:"customer_id"`
+	OrderID    string `json:"order_id,omitempty"`
+	Type       string `json:"type"`
+	Points     int    `json:"points"`
+	Note       string `json:"note,omitempty"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type LoyaltyAccount struct {
+	CustomerID string         `json:"customer_id"`
+	Balance    int            `json:"balance"`
+	Entries    []LoyaltyEntry `json:"entries"`
+}
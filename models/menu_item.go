@@ -4,8 +4,10 @@ type MenuItem struct {
 	ID          string               `json:"product_id"`
 	Name        string               `json:"name"`
 	Description string               `json:"description"`
+	Category    string               `json:"category"`
 	Price       float64              `json:"price"`
 	Ingredients []MenuItemIngredient `json:"ingredients"`
+	Version     int                  `json:"version"`
 }
 
 type MenuItemIngredient struct {
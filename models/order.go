@@ -1,14 +1,49 @@
 package models
 
 type Order struct {
-	ID           string      `json:"order_id"`
-	CustomerName string      `json:"customer_name"`
-	Items        []OrderItem `json:"items"`
-	Status       string      `json:"status"`
-	CreatedAt    string      `json:"created_at"`
+	ID             string          `json:"order_id"`
+	Ticket         string          `json:"ticket,omitempty"`
+	CustomerID     string          `json:"customer_id,omitempty"`
+	CustomerName   string          `json:"customer_name"`
+	StaffID        string          `json:"staff_id,omitempty"`
+	Items          []OrderItem     `json:"items"`
+	Status         string          `json:"status"`
+	RedeemRewardID string          `json:"redeem_reward_id,omitempty"`
+	Discounts      []OrderDiscount `json:"discounts,omitempty"`
+	Tax            float64         `json:"tax"`
+	Subtotal       float64         `json:"subtotal"`
+	Total          float64         `json:"total"`
+	CreatedAt      string          `json:"created_at"`
+	VoidReason     string          `json:"void_reason,omitempty"`
+	VoidedAt       string          `json:"voided_at,omitempty"`
+	History        []StatusChange  `json:"history,omitempty"`
+	Version        int             `json:"version"`
+}
+
+type StatusChange struct {
+	Status  string `json:"status"`
+	At      string `json:"at"`
+	StaffID string `json:"staff_id,omitempty"`
+}
+
+type ReadyRequest struct {
+	StaffID string `json:"staff_id"`
 }
 
 type OrderItem struct {
-	ProductID string `json:"product_id"`
-	Quantity  int    `json:"quantity"`
+	ProductID string  `json:"product_id"`
+	Quantity  int     `json:"quantity"`
+	Name      string  `json:"name,omitempty"`
+	Price     float64 `json:"price,omitempty"`
+	Category  string  `json:"category,omitempty"`
+	// UnitCost is the ingredient cost of one unit when the order was taken.
+	UnitCost *float64 `json:"unit_cost,omitempty"`
+}
+
+type OrderDiscount struct {
+	Type        string  `json:"type"`
+	RewardID    string  `json:"reward_id,omitempty"`
+	ProductID   string  `json:"product_id,omitempty"`
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount"`
 }
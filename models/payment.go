@@ -0,0 +1,35 @@
+package models
+
+const (
+	TenderCash     = "cash"
+	TenderCard     = "card"
+	TenderGiftCard = "gift_card"
+)
+
+type Payment struct {
+	ID                   string   `json:"payment_id"`
+	OrderID              string   `json:"order_id"`
+	Tender               string   `json:"tender"`
+	Amount               float64  `json:"amount"`
+	Tip                  float64  `json:"tip"`
+	Tendered             float64  `json:"tendered,omitempty"`
+	ChangeGiven          float64  `json:"change_given"`
+	CardToken            string   `json:"card_token,omitempty"`
+	GiftCardCode         string   `json:"gift_card_code,omitempty"`
+	GatewayTransactionID string   `json:"gateway_transaction_id,omitempty"`
+	GatewayStatus        string   `json:"gateway_status,omitempty"`
+	GatewayEvents        []string `json:"gateway_events,omitempty"`
+	SessionID            string   `json:"session_id,omitempty"`
+	CreatedAt            string   `json:"created_at"`
+}
+
+type OrderPayments struct {
+	OrderID    string    `json:"order_id"`
+	Status     string    `json:"status"`
+	Total      float64   `json:"total"`
+	Paid       float64   `json:"paid"`
+	Tips       float64   `json:"tips"`
+	Refunded   float64   `json:"refunded"`
+	BalanceDue float64   `json:"balance_due"`
+	Payments   []Payment `json:"payments"`
+}
@@ -0,0 +1,44 @@
+package models
+
+type Refund struct {
+	ID      string       `json:"refund_id"`
+	OrderID string       `json:"order_id"`
+	Reason  string       `json:"reason"`
+	Tender  string       `json:"tender"`
+	Lines   []RefundLine `json:"lines"`
+	Amount  float64      `json:"amount"`
+	// Tax is the part of Amount that gives back sales tax.
+	Tax             float64          `json:"tax"`
+	GatewayRefunds  []GatewayRefund  `json:"gateway_refunds,omitempty"`
+	GiftCardCredits []GiftCardCredit `json:"gift_card_credits,omitempty"`
+	SessionID       string           `json:"session_id,omitempty"`
+	CreatedAt       string           `json:"created_at"`
+}
+
+type RefundLine struct {
+	ProductID string  `json:"product_id"`
+	Quantity  int     `json:"quantity"`
+	Amount    float64 `json:"amount"`
+	Restock   bool    `json:"restock"`
+}
+
+type GatewayRefund struct {
+	TransactionID string  `json:"transaction_id"`
+	Amount        float64 `json:"amount"`
+}
+
+type GiftCardCredit struct {
+	Code   string  `json:"code"`
+	Amount float64 `json:"amount"`
+}
+
+type RefundRequest struct {
+	Reason string       `json:"reason"`
+	Tender string       `json:"tender"`
+	Lines  []RefundLine `json:"lines"`
+}
+
+type VoidRequest struct {
+	Reason           string `json:"reason"`
+	RestoreInventory bool   `json:"restore_inventory"`
+}
@@ -5,7 +5,197 @@ type TotalSalesReport struct {
 }
 
 type PopularItemReport struct {
-	ProductID string `json:"product_id"`
-	Name      string `json:"name"`
-	Count     int    `json:"count"`
+	ProductID string  `json:"product_id"`
+	Name      string  `json:"name"`
+	Category  string  `json:"category,omitempty"`
+	Count     int     `json:"count"`
+	Revenue   float64 `json:"revenue"`
+}
+
+type TenderReport struct {
+	Tender      string  `json:"tender"`
+	Count       int     `json:"count"`
+	Amount      float64 `json:"amount"`
+	Tips        float64 `json:"tips"`
+	ChangeGiven float64 `json:"change_given"`
+	Refunds     float64 `json:"refunds"`
+}
+
+type PaymentsReport struct {
+	Tenders      []TenderReport `json:"tenders"`
+	TotalAmount  float64        `json:"total_amount"`
+	TotalTips    float64        `json:"total_tips"`
+	TotalRefunds float64        `json:"total_refunds"`
+}
+
+type ZReport struct {
+	Date       string               `json:"date"`
+	OrderCount int                  `json:"order_count"`
+	GrossSales float64              `json:"gross_sales"`
+	Discounts  float64              `json:"discounts"`
+	Taxes      float64              `json:"taxes"`
+	Refunds    float64              `json:"refunds"`
+	NetSales   float64              `json:"net_sales"`
+	Tenders    []TenderReport       `json:"tenders"`
+	Sessions   []CashSessionSummary `json:"sessions"`
+}
+
+type CashSessionSummary struct {
+	SessionID    string         `json:"session_id"`
+	Status       string         `json:"status"`
+	OpenedAt     string         `json:"opened_at"`
+	ClosedAt     string         `json:"closed_at,omitempty"`
+	Tenders      []TenderReport `json:"tenders"`
+	Refunds      float64        `json:"refunds"`
+	OpeningFloat float64        `json:"opening_float"`
+	CashSales    float64        `json:"cash_sales"`
+	// CashGiftCardSales is cash taken for issuing and reloading gift cards.
+	CashGiftCardSales float64 `json:"cash_gift_card_sales"`
+	CashIn            float64 `json:"cash_in"`
+	CashOut           float64 `json:"cash_out"`
+	CashRefunds       float64 `json:"cash_refunds"`
+	Expected          float64 `json:"expected"`
+	Counted           float64 `json:"counted"`
+	OverShort         float64 `json:"over_short"`
+}
+
+type SalesBucket struct {
+	Bucket     string  `json:"bucket"`
+	Name       string  `json:"name,omitempty"`
+	OrderCount int     `json:"order_count"`
+	ItemsSold  int     `json:"items_sold"`
+	Gross      float64 `json:"gross"`
+	Discounts  float64 `json:"discounts"`
+	Refunds    float64 `json:"refunds"`
+	Net        float64 `json:"net"`
+}
+
+type SalesReport struct {
+	From     string        `json:"from,omitempty"`
+	To       string        `json:"to,omitempty"`
+	GroupBy  string        `json:"group_by"`
+	TimeZone string        `json:"time_zone"`
+	Buckets  []SalesBucket `json:"buckets"`
+	Totals   SalesBucket   `json:"totals"`
+}
+
+type IngredientUsage struct {
+	IngredientID    string   `json:"ingredient_id"`
+	Name            string   `json:"name"`
+	Unit            string   `json:"unit"`
+	Theoretical     float64  `json:"theoretical_usage"`
+	Received        float64  `json:"received"`
+	Wasted          float64  `json:"wasted"`
+	Adjusted        float64  `json:"adjusted"`
+	OpeningCount    *float64 `json:"opening_count"`
+	ClosingCount    *float64 `json:"closing_count"`
+	ActualUsage     *float64 `json:"actual_usage"`
+	Variance        *float64 `json:"variance"`
+	VariancePercent *float64 `json:"variance_percent"`
+	Flagged         bool     `json:"flagged"`
+}
+
+type InventoryUsageReport struct {
+	From        string            `json:"from,omitempty"`
+	To          string            `json:"to,omitempty"`
+	TimeZone    string            `json:"time_zone"`
+	Tolerance   float64           `json:"tolerance_percent"`
+	Ingredients []IngredientUsage `json:"ingredients"`
+}
+
+type ItemForecast struct {
+	ProductID string  `json:"product_id"`
+	Name      string  `json:"name"`
+	Expected  float64 `json:"expected"`
+	Prep      int     `json:"prep"`
+	PeakHour  int     `json:"peak_hour"`
+}
+
+type HourForecast struct {
+	Hour     int     `json:"hour"`
+	Expected float64 `json:"expected"`
+}
+
+type ForecastDay struct {
+	Date    string         `json:"date"`
+	Weekday string         `json:"weekday"`
+	Items   []ItemForecast `json:"items"`
+	Hours   []HourForecast `json:"hours"`
+}
+
+type IngredientForecast struct {
+	IngredientID     string  `json:"ingredient_id"`
+	Name             string  `json:"name"`
+	Unit             string  `json:"unit"`
+	Required         float64 `json:"required"`
+	ParLevel         float64 `json:"par_level"`
+	OnHand           float64 `json:"on_hand"`
+	SuggestedReorder float64 `json:"suggested_reorder"`
+}
+
+type ForecastReport struct {
+	GeneratedAt  string               `json:"generated_at"`
+	TimeZone     string               `json:"time_zone"`
+	HistoryWeeks int                  `json:"history_weeks"`
+	SafetyStock  float64              `json:"safety_stock_percent"`
+	Days         []ForecastDay        `json:"days"`
+	Ingredients  []IngredientForecast `json:"ingredients"`
+}
+
+type OrderExportLine struct {
+	OrderID      string
+	CreatedAt    string
+	Status       string
+	CustomerID   string
+	CustomerName string
+	ProductID    string
+	Name         string
+	Category     string
+	Quantity     int
+	UnitPrice    float64
+	LineTotal    float64
+	Subtotal     float64
+	Discounts    float64
+	Tax          float64
+	Total        float64
+}
+
+type DurationStats struct {
+	Count          int     `json:"count"`
+	AverageSeconds float64 `json:"average_seconds"`
+	P50Seconds     float64 `json:"p50_seconds"`
+	P90Seconds     float64 `json:"p90_seconds"`
+	P95Seconds     float64 `json:"p95_seconds"`
+	MaxSeconds     float64 `json:"max_seconds"`
+}
+
+type HourlyThroughput struct {
+	Hour   string `json:"hour"`
+	Orders int    `json:"orders"`
+	Items  int    `json:"items"`
+}
+
+type StaffMetrics struct {
+	StaffID           string        `json:"staff_id"`
+	OrderCount        int           `json:"order_count"`
+	AverageOrderValue float64       `json:"average_order_value"`
+	TimeToReady       DurationStats `json:"time_to_ready"`
+	TimeToClose       DurationStats `json:"time_to_close"`
+}
+
+type OperationsReport struct {
+	From              string             `json:"from,omitempty"`
+	To                string             `json:"to,omitempty"`
+	TimeZone          string             `json:"time_zone"`
+	StaffID           string             `json:"staff_id,omitempty"`
+	OrderCount        int                `json:"order_count"`
+	ItemCount         int                `json:"item_count"`
+	AverageOrderValue float64            `json:"average_order_value"`
+	ItemsPerOrder     float64            `json:"items_per_order"`
+	OpenHours         float64            `json:"open_hours"`
+	OrdersPerHour     float64            `json:"orders_per_hour"`
+	TimeToReady       DurationStats      `json:"time_to_ready"`
+	TimeToClose       DurationStats      `json:"time_to_close"`
+	Hourly            []HourlyThroughput `json:"hourly"`
+	ByStaff           []StaffMetrics     `json:"by_staff"`
 }